@@ -0,0 +1,200 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
+)
+
+// LoggingConfig defines the logger settings.
+type LoggingConfig struct {
+	// Development enables the zap development mode (console encoder, debug level, stack traces on warnings).
+	Development *bool `json:"development,omitempty"`
+}
+
+// OutputConfig defines the defaults applied to the objects holding calculation results.
+type OutputConfig struct {
+	// ManagedBy is the value of the managed-by annotation put on the result secrets.
+	ManagedBy string `json:"managedBy,omitempty"`
+	// ResultKey is the secret data key the result is stored under.
+	ResultKey string `json:"resultKey,omitempty"`
+}
+
+// LimitsConfig defines the limits applied to a single calculation. Zero disables a limit.
+type LimitsConfig struct {
+	// EvaluationTimeout is the maximum time a single calculation may take.
+	EvaluationTimeout *metav1.Duration `json:"evaluationTimeout,omitempty"`
+	// MaxDigits is the maximum number of decimal digits of a result.
+	MaxDigits *int `json:"maxDigits,omitempty"`
+	// MaxDepth is the maximum nesting depth of a calculation.
+	MaxDepth *int `json:"maxDepth,omitempty"`
+	// MaxOperands is the maximum number of operands of a calculation.
+	MaxOperands *int `json:"maxOperands,omitempty"`
+}
+
+// EvaluatorTLSConfig defines the TLS settings of the connection to an external evaluator.
+type EvaluatorTLSConfig struct {
+	// CAFile is the PEM file of the certificate authorities verifying the evaluator. The system pool is
+	// used when empty.
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile is the PEM file of the client certificate, for mutual TLS.
+	CertFile string `json:"certFile,omitempty"`
+	// KeyFile is the PEM file of the client key, for mutual TLS.
+	KeyFile string `json:"keyFile,omitempty"`
+	// ServerName overrides the name the evaluator certificate is verified against.
+	ServerName string `json:"serverName,omitempty"`
+	// InsecureSkipVerify disables the verification of the evaluator certificate.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// EvaluatorConfig defines an external evaluator reached over gRPC.
+type EvaluatorConfig struct {
+	// Name is the name Calculators reference the evaluator by.
+	Name string `json:"name"`
+	// Address is the gRPC target of the evaluator, such as evaluator.calc-system.svc:9090.
+	Address string `json:"address"`
+	// TLS secures the connection. The connection is in plain text when unset.
+	TLS *EvaluatorTLSConfig `json:"tls,omitempty"`
+	// Timeout is the maximum duration of a single call.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Retries is the number of times a call failing with a transient error is retried.
+	Retries *int `json:"retries,omitempty"`
+}
+
+// ConfigMapReference references a ConfigMap.
+type ConfigMapReference struct {
+	// Namespace of the ConfigMap.
+	Namespace string `json:"namespace"`
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+}
+
+// PluginsConfig defines the WebAssembly modules providing functions to expressions.
+type PluginsConfig struct {
+	// Path is a local directory, its .wasm files are loaded.
+	Path string `json:"path,omitempty"`
+	// ConfigMap is read at startup, its .wasm binaryData keys are loaded.
+	ConfigMap *ConfigMapReference `json:"configMap,omitempty"`
+	// MaxMemory is the maximum memory of a module instance, rounded up to 64Ki pages.
+	MaxMemory *resource.Quantity `json:"maxMemory,omitempty"`
+	// Fuel is the maximum number of WebAssembly function calls of a single plugin function call, bounding
+	// its recursions. Unset or zero disables it. Instructions are not metered, loops are only bounded by the
+	// evaluation timeout.
+	Fuel *int64 `json:"fuel,omitempty"`
+}
+
+// APIConfig defines the REST API serving calculations without Calculators.
+type APIConfig struct {
+	// BindAddress is the address the API listens on. "0" disables the API.
+	BindAddress string `json:"bindAddress,omitempty"`
+	// CertFile is the PEM file of the serving certificate. The API is served in plain text when empty.
+	CertFile string `json:"certFile,omitempty"`
+	// KeyFile is the PEM file of the serving key.
+	KeyFile string `json:"keyFile,omitempty"`
+}
+
+// TargetsConfig defines the objects Calculators may apply their results to.
+type TargetsConfig struct {
+	// Allowed lists the kinds of the targets. Calculators cannot write to other kinds, nothing is allowed when
+	// empty. The operator also needs the RBAC permissions to get and patch them.
+	Allowed []metav1.GroupKind `json:"allowed,omitempty"`
+}
+
+// EncryptionConfig configures the encryption of the result secrets.
+type EncryptionConfig struct {
+	// KeyringFile is the keyring of the Calculators without a key Secret, a stand-in for a KMS. Rotate the
+	// primary key by updating the file and restarting the operator.
+	KeyringFile string `json:"keyringFile,omitempty"`
+}
+
+// RateLimiterConfig defines how fast the failed reconciliations are retried.
+type RateLimiterConfig struct {
+	// BaseDelay is the delay of the first retry of a request, doubled on every failure.
+	BaseDelay *metav1.Duration `json:"baseDelay,omitempty"`
+	// MaxDelay caps the delay of the retries of a request.
+	MaxDelay *metav1.Duration `json:"maxDelay,omitempty"`
+	// QPS is the overall rate of the retries, in requests per second.
+	QPS *int `json:"qps,omitempty"`
+	// Burst is the number of retries allowed above the QPS.
+	Burst *int `json:"burst,omitempty"`
+}
+
+// ReconcileConfig defines how the Calculators are reconciled.
+type ReconcileConfig struct {
+	// MaxConcurrentReconciles is the number of Calculators reconciled in parallel.
+	MaxConcurrentReconciles *int `json:"maxConcurrentReconciles,omitempty"`
+	// RateLimiter configures the retries of the failed reconciliations.
+	RateLimiter RateLimiterConfig `json:"rateLimiter,omitempty"`
+	// FairQueuing hands the Calculators out to the workers one namespace after the other, instead of first in,
+	// first out, so that a burst in a namespace does not delay the other namespaces.
+	FairQueuing bool `json:"fairQueuing,omitempty"`
+}
+
+// ShardingConfig splits the Calculators between several replicas of the operator.
+type ShardingConfig struct {
+	// ShardID is the shard of the replica, between 0 and shardCount - 1. Every replica holds the lease of its
+	// shard, named after leaderElection.resourceName.
+	ShardID int `json:"shardID"`
+	// ShardCount is the number of shards.
+	ShardCount int `json:"shardCount"`
+}
+
+//+kubebuilder:object:root=true
+
+// OperatorConfig is the Schema for the operator configuration file.
+type OperatorConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ControllerManagerConfigurationSpec returns the configurations for controllers.
+	cfg.ControllerManagerConfigurationSpec `json:",inline"`
+
+	// Logging configures the operator logger.
+	Logging LoggingConfig `json:"logging,omitempty"`
+	// Output configures the result secrets.
+	Output OutputConfig `json:"output,omitempty"`
+	// Limits configures the calculation limits.
+	Limits LimitsConfig `json:"limits,omitempty"`
+	// WatchNamespaces restricts the operator to the listed namespaces. All namespaces are watched when empty.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+	// NamespaceSelector adds the namespaces matching the selector at startup to the watched namespaces.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Evaluators are the external evaluators Calculators select with spec.evaluatorRef.
+	Evaluators []EvaluatorConfig `json:"evaluators,omitempty"`
+	// DefaultEvaluator is the name of the evaluator of Calculators without an evaluatorRef. They are
+	// evaluated in process when empty.
+	DefaultEvaluator string `json:"defaultEvaluator,omitempty"`
+	// Plugins configures the WebAssembly functions available to expressions.
+	Plugins *PluginsConfig `json:"plugins,omitempty"`
+	// API configures the REST API. It is disabled when unset.
+	API *APIConfig `json:"api,omitempty"`
+	// Targets restricts the objects Calculators apply their results to.
+	Targets TargetsConfig `json:"targets,omitempty"`
+	// Encryption configures the encryption of the result secrets.
+	Encryption *EncryptionConfig `json:"encryption,omitempty"`
+	// Reconcile configures the workers and the queue of the Calculator controller.
+	Reconcile ReconcileConfig `json:"reconcile,omitempty"`
+	// Sharding runs the replica as one of several shards. A single leader reconciles every Calculator when unset.
+	Sharding *ShardingConfig `json:"sharding,omitempty"`
+	// FeatureGates enables or disables optional operator features by name.
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OperatorConfig{})
+}
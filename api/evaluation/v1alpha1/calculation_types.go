@@ -0,0 +1,84 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+)
+
+// CalculationSpec defines the calculation to perform.
+type CalculationSpec struct {
+	// X is available as the x variable in the expression.
+	X int `json:"x"`
+	// Y is available as the y variable in the expression.
+	Y int `json:"y"`
+	// Expression is the calculation, as the expression of a Calculator. Defaults to "x + y".
+	// +optional
+	Expression string `json:"expression,omitempty"`
+	// Variables are typed operands of the expression besides x and y, as the variables of a Calculator.
+	// +optional
+	Variables []calcv1alpha1.Variable `json:"variables,omitempty"`
+	// Mode is the number system of x, y and the number literals, as the mode of a Calculator. Defaults to Integer.
+	// +optional
+	Mode calcv1alpha1.Mode `json:"mode,omitempty"`
+	// Output configures the rendering of the result.
+	// +optional
+	Output *CalculationOutput `json:"output,omitempty"`
+}
+
+// CalculationOutput configures the rendering of the result, as the fields of the output of a Calculator of the
+// same names.
+type CalculationOutput struct {
+	// Precision renders a rational result as a decimal with this number of fractional digits in status.decimal.
+	// +optional
+	Precision *int32 `json:"precision,omitempty"`
+	// ComplexFormat renders a complex result in status.value. Defaults to Rectangular.
+	// +optional
+	ComplexFormat calcv1alpha1.ComplexFormat `json:"complexFormat,omitempty"`
+	// TimeZone renders a timestamp result in status.value in this IANA time zone. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// CalculationStatus holds the result of the calculation.
+type CalculationStatus struct {
+	// Result is the result of the expression, when it fits into an integer.
+	Result int `json:"result,omitempty"`
+	// Value is the canonical form of the result of the expression.
+	Value string `json:"value,omitempty"`
+	// Decimal is the decimal form of a rational result, at the precision of spec.output.
+	// +optional
+	Decimal string `json:"decimal,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// Calculation evaluates its spec on creation and returns the result in its status, like a TokenReview. It is
+// never persisted and no result secret is written.
+type Calculation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CalculationSpec   `json:"spec,omitempty"`
+	Status CalculationStatus `json:"status,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Calculation{})
+}
@@ -17,29 +17,266 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// PausedAnnotation set to "true" freezes the Calculator: the operator skips all writes but the Paused condition.
+	PausedAnnotation = "calc.example.com/paused"
+	// DryRunAnnotation set to "true" records the result in the status without touching the result secret.
+	DryRunAnnotation = "calc.example.com/dry-run"
+)
+
+// Condition types of a Calculator.
+const (
+	// ConditionPaused indicates whether the Calculator is paused by the PausedAnnotation.
+	ConditionPaused = "Paused"
+	// ConditionDryRun indicates whether the result was computed without being written to the secret.
+	ConditionDryRun = "DryRun"
+	// ConditionLimitExceeded indicates whether the calculation exceeded one of the operator limits.
+	ConditionLimitExceeded = "LimitExceeded"
+	// ConditionInvalid indicates whether the expression cannot be evaluated.
+	ConditionInvalid = "Invalid"
+	// ConditionTargetsSynced indicates whether the result was applied to all the targets.
+	ConditionTargetsSynced = "TargetsSynced"
+)
+
+// TargetType is the JSON type of the value written to a target field.
+// +kubebuilder:validation:Enum=String;Integer;JSON
+type TargetType string
+
+// Types of the values written to the targets.
+const (
+	// TargetTypeString writes the rendered template as a string.
+	TargetTypeString TargetType = "String"
+	// TargetTypeInteger writes the rendered template as a 64-bit integer.
+	TargetTypeInteger TargetType = "Integer"
+	// TargetTypeJSON writes the rendered template as a JSON value.
+	TargetTypeJSON TargetType = "JSON"
+)
+
+// Mode is the number system of the calculation.
+// +kubebuilder:validation:Enum=Integer;Rational;Real;Complex
+type Mode string
+
+// Number systems of the calculations.
+const (
+	// ModeInteger computes with arbitrary precision integers, the division truncates towards zero.
+	ModeInteger Mode = "Integer"
+	// ModeRational computes with exact fractions of arbitrary precision integers.
+	ModeRational Mode = "Rational"
+	// ModeReal computes with double precision floating point numbers.
+	ModeReal Mode = "Real"
+	// ModeComplex computes with complex numbers of double precision floating point parts.
+	ModeComplex Mode = "Complex"
+)
+
+// VariableType is the type of the value of a variable.
+// +kubebuilder:validation:Enum=Complex;Vector;Matrix;Quantity;Measure;Timestamp;Duration
+type VariableType string
+
+// Types of the variables.
+const (
+	// VariableTypeComplex is a complex number in rectangular form, such as 3+4i.
+	VariableTypeComplex VariableType = "Complex"
+	// VariableTypeVector is a vector of real numbers written as a JSON array, such as [1, 2, 3].
+	VariableTypeVector VariableType = "Vector"
+	// VariableTypeMatrix is a matrix of real numbers written as a JSON array of rows, such as [[1, 2], [3, 4]].
+	VariableTypeMatrix VariableType = "Matrix"
+	// VariableTypeQuantity is a Kubernetes resource quantity, such as 500m or 512Mi, of the resource of the variable.
+	VariableTypeQuantity VariableType = "Quantity"
+	// VariableTypeMeasure is a number and a physical unit separated by a space, such as 10 GB/s.
+	VariableTypeMeasure VariableType = "Measure"
+	// VariableTypeTimestamp is a point in time in RFC 3339 format, such as 2024-03-01T12:00:00Z.
+	VariableTypeTimestamp VariableType = "Timestamp"
+	// VariableTypeDuration is a duration in Go syntax, such as 720h or 1h30m.
+	VariableTypeDuration VariableType = "Duration"
+)
+
+// ComplexFormat is the rendering of a complex result.
+// +kubebuilder:validation:Enum=Rectangular;Polar
+type ComplexFormat string
+
+// Renderings of the complex results.
+const (
+	// ComplexFormatRectangular renders the real and imaginary parts, such as 3+4i.
+	ComplexFormatRectangular ComplexFormat = "Rectangular"
+	// ComplexFormatPolar renders the modulus and the argument in radians, such as 5∠0.9272952180016122.
+	ComplexFormatPolar ComplexFormat = "Polar"
+)
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // CalculatorSpec defines the desired state of Calculator.
 type CalculatorSpec struct {
-	// X is the first addend.
+	// X is the first addend, available as the x variable in the expression.
 	// +kubebuilder:validation:Minimum=-2147483648
 	// +kubebuilder:validation:Maximum=2147483647
 	// +kubebuilder:validation:ExclusiveMinimum=false
 	// +kubebuilder:validation:ExclusiveMaximum=false
-	// +kubebuilder:validation:Required
+	// +optional
 	X int `json:"x"`
 
-	// Y is the second addend.
+	// Y is the second addend, available as the y variable in the expression.
 	// +kubebuilder:validation:Minimum=-2147483648
 	// +kubebuilder:validation:Maximum=2147483647
 	// +kubebuilder:validation:ExclusiveMinimum=false
 	// +kubebuilder:validation:ExclusiveMaximum=false
-	// +kubebuilder:validation:Required
+	// +optional
 	Y int `json:"y"`
+
+	// Expression is the calculation, built from the operations of the operator registry,
+	// such as "x * y + 2" or "pow(x, 2)". Defaults to "x + y".
+	// +kubebuilder:validation:MaxLength=4096
+	// +optional
+	Expression string `json:"expression,omitempty"`
+
+	// Variables are typed operands of the expression besides x and y.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	Variables []Variable `json:"variables,omitempty"`
+
+	// Mode is the number system of x, y and the number literals: in Rational mode they are exact fractions and the
+	// division never rounds, in Real mode floating point numbers and in Complex mode complex numbers, so that
+	// sqrt(-1) is i. Defaults to Integer.
+	// +optional
+	Mode Mode `json:"mode,omitempty"`
+
+	// EvaluatorRef selects an external evaluator of the operator configuration. Defaults to the
+	// defaultEvaluator of the configuration, or to the operator itself.
+	// +optional
+	EvaluatorRef *EvaluatorReference `json:"evaluatorRef,omitempty"`
+
+	// Targets are the fields of objects of the namespace the result is applied to, with server-side apply.
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Targets []Target `json:"targets,omitempty"`
+
+	// Output configures the rendering of the result and the consumers of the result secret.
+	// +optional
+	Output *OutputSpec `json:"output,omitempty"`
+}
+
+// OutputSpec configures the rendering of the result and the consumers of the result secret.
+type OutputSpec struct {
+	// RolloutSelector selects the Deployments, StatefulSets and DaemonSets of the namespace consuming the result
+	// secret. Their pod templates are annotated with a checksum of the secret data, so they are rolled out when
+	// the result changes.
+	// +optional
+	RolloutSelector *metav1.LabelSelector `json:"rolloutSelector,omitempty"`
+
+	// Precision renders a rational result as a decimal with this number of fractional digits in status.decimal,
+	// the last digit rounded to nearest. The result secret holds the exact fraction.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Precision *int32 `json:"precision,omitempty"`
+
+	// ComplexFormat renders a complex result in status.value and the result secret. Defaults to Rectangular.
+	// +optional
+	ComplexFormat ComplexFormat `json:"complexFormat,omitempty"`
+
+	// TimeZone renders a timestamp result in status.value and the result secret in this IANA time zone, such as
+	// Europe/Kyiv. Defaults to UTC.
+	// +kubebuilder:validation:MaxLength=64
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Encryption encrypts the values of the result secret with AES-GCM, and keeps the result out of the status, the
+	// events and spec.targets, which it excludes. They are written in clear when unset.
+	// +optional
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+}
+
+// Variable is a typed operand of the expression.
+type Variable struct {
+	// Name of the variable in the expression, x and y are reserved.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_]*$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+	// Type of the value.
+	Type VariableType `json:"type"`
+	// Value of the variable in the string form of its type.
+	// +kubebuilder:validation:MaxLength=4096
+	Value string `json:"value"`
+	// Resource measured by a Quantity variable, such as cpu, memory or nvidia.com/gpu. Quantities of different
+	// resources cannot be mixed.
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	Resource string `json:"resource,omitempty"`
+}
+
+// EncryptionSpec selects the keys encrypting the result secret.
+type EncryptionSpec struct {
+	// KeySecretRef references a Secret of the namespace holding the keyring under its keyring.yaml key. The
+	// keyring file of the operator configuration is used when unset.
+	// +optional
+	KeySecretRef *corev1.LocalObjectReference `json:"keySecretRef,omitempty"`
+}
+
+// Target is a field of an object of the Calculator namespace the result is written to. The kind of the object
+// must be allowed by the operator configuration.
+type Target struct {
+	// APIVersion of the object, such as apps/v1.
+	// +kubebuilder:validation:MinLength=1
+	APIVersion string `json:"apiVersion"`
+	// Kind of the object, such as Deployment.
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+	// Name of the object. The object is never created.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Path is the JSONPath of the field, made of field names only, such as .spec.replicas or
+	// .data['result.txt'].
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// Template renders the written value with the Go text/template syntax, from .Value, .Result, .X, .Y and
+	// .Name. Defaults to "{{ .Value }}".
+	// +optional
+	Template string `json:"template,omitempty"`
+	// Type of the written value. Defaults to String.
+	// +kubebuilder:default=String
+	// +optional
+	Type TargetType `json:"type,omitempty"`
+	// Force takes the ownership of the field from its other managers instead of reporting a conflict.
+	// +optional
+	Force bool `json:"force,omitempty"`
+}
+
+// TargetStatus is the observed state of a target.
+type TargetStatus struct {
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+	// Kind of the object.
+	Kind string `json:"kind"`
+	// Name of the object.
+	Name string `json:"name"`
+	// Path of the field.
+	Path string `json:"path"`
+	// Synced indicates whether the value was applied to the field.
+	Synced bool `json:"synced"`
+	// Value is the last value applied to the field.
+	// +optional
+	Value string `json:"value,omitempty"`
+	// Reason is the CamelCase reason of the state, such as Applied, NotAllowed or Conflict.
+	Reason string `json:"reason"`
+	// Message explains the reason.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// LastSyncTime is the last time the value was applied.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// EvaluatorReference references an external evaluator by name.
+type EvaluatorReference struct {
+	// Name is the name of the evaluator in the operator configuration.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
 }
 
 // CalculatorStatus defines the observed state of Calculator.
@@ -49,8 +286,27 @@ type CalculatorStatus struct {
 
 	// Processed indicates whether the calculation has been performed.
 	Processed bool `json:"processed,omitempty"`
-	// Result is the sum of X and Y.
+	// Result is the result of the expression, when it fits into an integer.
 	Result int `json:"result,omitempty"`
+	// Value is the canonical form of the result of the expression, numerator/denominator in Rational mode.
+	Value string `json:"value,omitempty"`
+	// Decimal is the decimal form of a rational result, at the precision of spec.output.
+	// +optional
+	Decimal string `json:"decimal,omitempty"`
+	// Checksum is the HMAC-SHA256 of the value with the primary key of the keyring, set in place of the value, the
+	// result and the decimal when spec.output.encryption is set. It only changes with the value or the primary key.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// Targets are the states of the spec.targets, in the same order.
+	// +optional
+	Targets []TargetStatus `json:"targets,omitempty"`
+
+	// Conditions represent the latest available observations of the Calculator state.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
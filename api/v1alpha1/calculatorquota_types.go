@@ -0,0 +1,98 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionQuotaExceeded indicates whether the Calculator is not processed because it exceeds a CalculatorQuota
+// of its namespace.
+const ConditionQuotaExceeded = "QuotaExceeded"
+
+// CalculatorQuotaLimits are the limits of the Calculators of a namespace. An unset limit is not enforced.
+type CalculatorQuotaLimits struct {
+	// Calculators is the maximum number of Calculators.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Calculators *int64 `json:"calculators,omitempty"`
+	// EvaluationCost is the maximum total cost of the expressions, the cost of an expression being its number of
+	// operations and operands.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	EvaluationCost *int64 `json:"evaluationCost,omitempty"`
+	// Outputs is the maximum number of objects written by the Calculators: their secret and their targets.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Outputs *int64 `json:"outputs,omitempty"`
+}
+
+// CalculatorQuotaUsage is the usage of the Calculators of a namespace.
+type CalculatorQuotaUsage struct {
+	// Calculators is the number of Calculators.
+	Calculators int64 `json:"calculators"`
+	// EvaluationCost is the total cost of the expressions.
+	EvaluationCost int64 `json:"evaluationCost"`
+	// Outputs is the number of objects written by the Calculators.
+	Outputs int64 `json:"outputs"`
+}
+
+// CalculatorQuotaSpec defines the desired state of CalculatorQuota.
+type CalculatorQuotaSpec struct {
+	// Hard are the limits enforced on the Calculators of the namespace.
+	Hard CalculatorQuotaLimits `json:"hard"`
+}
+
+// CalculatorQuotaStatus defines the observed state of CalculatorQuota.
+type CalculatorQuotaStatus struct {
+	// Used is the current usage of the Calculators of the namespace.
+	// +optional
+	Used CalculatorQuotaUsage `json:"used,omitempty"`
+	// ObservedGeneration is the generation of the spec the usage was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Calculators",type=integer,JSONPath=`.status.used.calculators`
+//+kubebuilder:printcolumn:name="Cost",type=integer,JSONPath=`.status.used.evaluationCost`
+//+kubebuilder:printcolumn:name="Outputs",type=integer,JSONPath=`.status.used.outputs`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// CalculatorQuota is the Schema for the calculatorquotas API. It caps the Calculators of its namespace, every
+// quota of a namespace being enforced.
+type CalculatorQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CalculatorQuotaSpec   `json:"spec,omitempty"`
+	Status CalculatorQuotaStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CalculatorQuotaList contains a list of CalculatorQuota.
+type CalculatorQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CalculatorQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CalculatorQuota{}, &CalculatorQuotaList{})
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// calc-apiserver is an aggregated API server evaluating Calculations synchronously, without storing them.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/mykysha/kubCalculator/pkg/apiserver"
+)
+
+func main() {
+	options := apiserver.NewOptions()
+
+	cmd := &cobra.Command{
+		Use:          apiserver.Name,
+		Short:        "Serve the calculations resource of evaluation.calc.example.com",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := options.Validate(); err != nil {
+				return fmt.Errorf("invalid options: %w", err)
+			}
+
+			config, err := options.Config()
+			if err != nil {
+				return err
+			}
+
+			server, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			return server.PrepareRun().Run(cmd.Context().Done())
+		},
+	}
+
+	options.AddFlags(cmd.Flags())
+
+	if err := cmd.ExecuteContext(genericapiserver.SetupSignalContext()); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
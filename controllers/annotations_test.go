@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var _ = Describe("Calculator controller annotations", func() {
+	const (
+		namespaceName  = "test-annotations"
+		calculatorName = "test-annotations"
+	)
+
+	ctx := context.Background()
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+
+	typeNamespaceName := types.NamespacedName{Name: calculatorName, Namespace: namespaceName}
+
+	var reconciler *CalculatorReconciler
+
+	createCalculator := func(annotations map[string]string) {
+		calculator := &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        calculatorName,
+				Namespace:   namespaceName,
+				Annotations: annotations,
+			},
+			Spec: calcv1alpha1.CalculatorSpec{X: 2, Y: 3},
+		}
+		Expect(k8sClient.Create(ctx, calculator)).To(Succeed())
+	}
+
+	reconcileCalculator := func() *calcv1alpha1.Calculator {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		calculator := &calcv1alpha1.Calculator{}
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calculator)).To(Succeed())
+
+		return calculator
+	}
+
+	secretExists := func() bool {
+		err := k8sClient.Get(ctx, typeNamespaceName, &corev1.Secret{})
+		if errors.IsNotFound(err) {
+			return false
+		}
+
+		Expect(err).NotTo(HaveOccurred())
+
+		return true
+	}
+
+	BeforeEach(func() {
+		By("Creating namespace")
+		err := k8sClient.Create(ctx, namespace)
+		if !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		reconciler = &CalculatorReconciler{
+			Client:  k8sClient,
+			Service: &service.CalculatorService{},
+			Scheme:  k8sClient.Scheme(),
+		}
+	})
+
+	AfterEach(func() {
+		By("Deleting the Calculator and its secret")
+		Expect(k8sClient.Delete(ctx, &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+		})).To(Succeed())
+
+		err := k8sClient.Delete(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+		})
+		if !errors.IsNotFound(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	It("Should skip all writes of a paused Calculator", func() {
+		createCalculator(map[string]string{calcv1alpha1.PausedAnnotation: "true"})
+
+		calculator := reconcileCalculator()
+
+		By("Checking the Paused condition is set and nothing was computed")
+		Expect(meta.IsStatusConditionTrue(calculator.Status.Conditions, calcv1alpha1.ConditionPaused)).To(BeTrue())
+		Expect(calculator.Status.Processed).To(BeFalse())
+		Expect(calculator.Status.Result).To(BeZero())
+		Expect(secretExists()).To(BeFalse())
+
+		By("Resuming the Calculator")
+		delete(calculator.Annotations, calcv1alpha1.PausedAnnotation)
+		Expect(k8sClient.Update(ctx, calculator)).To(Succeed())
+
+		calculator = reconcileCalculator()
+
+		Expect(meta.IsStatusConditionFalse(calculator.Status.Conditions, calcv1alpha1.ConditionPaused)).To(BeTrue())
+		Expect(calculator.Status.Processed).To(BeTrue())
+		Expect(calculator.Status.Result).To(Equal(5))
+		Expect(secretExists()).To(BeTrue())
+	})
+
+	It("Should record the result of a dry-run Calculator without writing the secret", func() {
+		createCalculator(map[string]string{calcv1alpha1.DryRunAnnotation: "true"})
+
+		calculator := reconcileCalculator()
+
+		By("Checking the result is in the status only")
+		Expect(meta.IsStatusConditionTrue(calculator.Status.Conditions, calcv1alpha1.ConditionDryRun)).To(BeTrue())
+		Expect(calculator.Status.Processed).To(BeTrue())
+		Expect(calculator.Status.Result).To(Equal(5))
+		Expect(secretExists()).To(BeFalse())
+
+		By("Leaving the dry-run mode")
+		delete(calculator.Annotations, calcv1alpha1.DryRunAnnotation)
+		Expect(k8sClient.Update(ctx, calculator)).To(Succeed())
+
+		calculator = reconcileCalculator()
+
+		Expect(meta.IsStatusConditionFalse(calculator.Status.Conditions, calcv1alpha1.ConditionDryRun)).To(BeTrue())
+		Expect(secretExists()).To(BeTrue())
+	})
+})
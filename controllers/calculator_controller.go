@@ -22,27 +22,56 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/fairqueue"
 	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/sharding"
+	"github.com/mykysha/kubCalculator/pkg/targets"
 )
 
+// controllerName is the name of the Calculator controller, in its logs and metrics.
+const controllerName = "calculator"
+
 // CalculatorReconciler reconciles a Calculator object.
 type CalculatorReconciler struct {
 	client.Client
 	Service service.Repository
-	Scheme  *runtime.Scheme
+	// Evaluators are the repositories Calculators select with spec.evaluatorRef.
+	Evaluators map[string]service.Repository
+	Scheme     *runtime.Scheme
+	// Recorder records the results and the failures of the calculations as events, the history of a
+	// Calculator.
+	Recorder record.EventRecorder
+	// AllowedTargets are the kinds Calculators may apply their results to with spec.targets.
+	AllowedTargets targets.Allowlist
+	// KeyringFile is the keyring encrypting the secrets of the Calculators without a key Secret.
+	KeyringFile string
+	// Options configure the workers and the rate limiter of the controller, its Reconciler is ignored.
+	Options controller.Options
+	// FairQueuing hands the Calculators out to the workers one namespace after the other.
+	FairQueuing bool
+	// Sharding restricts the reconciler to the Calculators of its shard, nil to reconcile them all.
+	Sharding *sharding.Shard
 }
 
 //+kubebuilder:rbac:groups=calc.example.com,resources=calculators,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=calc.example.com,resources=calculators/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=calc.example.com,resources=calculators/finalizers,verbs=update
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -60,32 +89,155 @@ func (r *CalculatorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	logger.Info("Got Calculator", "x", calc.Spec.X, "\"y\"", calc.Spec.Y)
+	logger.Info("Got Calculator", "x", calc.Spec.X, "\"y\"", calc.Spec.Y, "expression", calc.Spec.Expression)
+
+	// Only the shard claiming the calculator writes it.
+	claimed, result, err := r.claim(ctx, calc)
+	if err != nil || !claimed {
+		return result, err
+	}
+
+	// A paused calculator only gets its Paused condition updated.
+	if annotationEnabled(calc, calcv1alpha1.PausedAnnotation) {
+		logger.Info("Calculator is paused, skipping")
+
+		setCondition(calc, calcv1alpha1.ConditionPaused, metav1.ConditionTrue, "PausedAnnotation",
+			fmt.Sprintf("Calculator is paused by the %s annotation", calcv1alpha1.PausedAnnotation))
+
+		return ctrl.Result{}, r.manageCalculator(ctx, calc)
+	}
+
+	setCondition(calc, calcv1alpha1.ConditionPaused, metav1.ConditionFalse, "NotPaused", "")
+
+	repository, ok := r.repository(calc)
+	if !ok {
+		logger.Info("Calculator references an unknown evaluator", "evaluator", calc.Spec.EvaluatorRef.Name)
+
+		setCondition(calc, calcv1alpha1.ConditionInvalid, metav1.ConditionTrue, "UnknownEvaluator",
+			fmt.Sprintf("Evaluator %q is not configured", calc.Spec.EvaluatorRef.Name))
+
+		return ctrl.Result{}, r.manageCalculator(ctx, calc)
+	}
+
+	exceeded, err := r.overQuota(ctx, calc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if exceeded != "" {
+		// The calculator is processed again when a quota or another calculator of the namespace changes.
+		logger.Info("Calculator exceeds a quota", "reason", exceeded)
+
+		if !conditionHolds(calc, calcv1alpha1.ConditionQuotaExceeded, exceeded) {
+			r.event(calc, corev1.EventTypeWarning, "QuotaExceeded", exceeded)
+		}
+
+		setCondition(calc, calcv1alpha1.ConditionQuotaExceeded, metav1.ConditionTrue, "QuotaExceeded", exceeded)
+
+		return ctrl.Result{}, r.manageCalculator(ctx, calc)
+	}
+
+	setCondition(calc, calcv1alpha1.ConditionQuotaExceeded, metav1.ConditionFalse, "WithinQuota", "")
+
+	previousValue, previousChecksum := calc.Status.Value, calc.Status.Checksum
 
 	// Process the calculator.
-	err = r.Service.ProcessCalculator(ctx, calc)
+	err = repository.ProcessCalculator(ctx, calc)
+	if service.IsLimitExceeded(err) {
+		// Retrying would exceed the limit again, the spec has to change first.
+		logger.Info("Calculator exceeds a limit", "reason", err.Error())
+
+		if !conditionHolds(calc, calcv1alpha1.ConditionLimitExceeded, err.Error()) {
+			r.event(calc, corev1.EventTypeWarning, "LimitExceeded", err.Error())
+		}
+
+		setCondition(calc, calcv1alpha1.ConditionLimitExceeded, metav1.ConditionTrue, "LimitExceeded", err.Error())
+
+		return ctrl.Result{}, r.manageCalculator(ctx, calc)
+	}
+
+	if service.IsInvalid(err) {
+		logger.Info("Calculator is invalid", "reason", err.Error())
+
+		if !conditionHolds(calc, calcv1alpha1.ConditionInvalid, err.Error()) {
+			r.event(calc, corev1.EventTypeWarning, "InvalidExpression", err.Error())
+		}
+
+		setCondition(calc, calcv1alpha1.ConditionInvalid, metav1.ConditionTrue, "InvalidExpression", err.Error())
+
+		return ctrl.Result{}, r.manageCalculator(ctx, calc)
+	}
+
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to process calculator: %w", err)
 	}
 
+	// The secret is defined from the value, the status of an encrypted calculator only holds its checksum.
+	value := calc.Status.Value
+
+	keyring, err := r.protectResult(ctx, calc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if calc.Status.Value != previousValue || calc.Status.Checksum != previousChecksum {
+		r.event(calc, corev1.EventTypeNormal, "Calculated", fmt.Sprintf("Result %s (x=%d, y=%d, expression %q)",
+			describeResult(calc), calc.Spec.X, calc.Spec.Y, calc.Spec.Expression))
+	}
+
+	setCondition(calc, calcv1alpha1.ConditionInvalid, metav1.ConditionFalse, "ValidExpression", "")
+	setCondition(calc, calcv1alpha1.ConditionLimitExceeded, metav1.ConditionFalse, "WithinLimits", "")
+
+	dryRun := annotationEnabled(calc, calcv1alpha1.DryRunAnnotation)
+	if dryRun {
+		setCondition(calc, calcv1alpha1.ConditionDryRun, metav1.ConditionTrue, "DryRunAnnotation",
+			fmt.Sprintf("Result %s was not written to the secret", describeResult(calc)))
+	} else {
+		setCondition(calc, calcv1alpha1.ConditionDryRun, metav1.ConditionFalse, "NotDryRun", "")
+	}
+
+	result = ctrl.Result{}
+
+	// The targets are synced before the status is saved, it reports their states.
+	if !dryRun && r.syncTargets(ctx, calc) {
+		result.RequeueAfter = targetRetryInterval
+	}
+
 	// Save the status.
 	err = r.manageCalculator(ctx, calc)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if dryRun {
+		logger.Info("Calculator is in dry-run mode, skipping the secret and the targets")
+
+		return result, nil
+	}
+
 	// Create a secret with the result.
-	secret, err := r.Service.DefineSecret(ctx, req.Name, req.Namespace, calc.Status.Result)
+	secret, err := repository.DefineSecret(ctx, req.Name, req.Namespace, value)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to define secret: %w", err)
 	}
 
+	err = r.encryptSecret(ctx, keyring, secret)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	err = r.manageSecret(ctx, secret)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Roll out the workloads consuming the secret.
+	err = r.rolloutConsumers(ctx, calc, secret)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return result, nil
 }
 
 //+kubebuilder:rbac:groups=calc.example.com,resources=calculators,verbs=get;list;watch;create;update;patch;delete
@@ -154,16 +306,107 @@ func (r *CalculatorReconciler) manageSecret(ctx context.Context, secret *corev1.
 	return nil
 }
 
+// repository returns the repository selected by the evaluatorRef of the calculator.
+func (r *CalculatorReconciler) repository(calc *calcv1alpha1.Calculator) (service.Repository, bool) {
+	if calc.Spec.EvaluatorRef == nil {
+		return r.Service, true
+	}
+
+	repository, ok := r.Evaluators[calc.Spec.EvaluatorRef.Name]
+
+	return repository, ok
+}
+
+// event records an event on the calculator, when the reconciler has a recorder.
+func (r *CalculatorReconciler) event(calc *calcv1alpha1.Calculator, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(calc, eventType, reason, message)
+	}
+}
+
+// describeResult returns the result of the calculator for the events and the conditions, its checksum when it is
+// encrypted.
+func describeResult(calc *calcv1alpha1.Calculator) string {
+	if calc.Status.Checksum != "" {
+		return "with checksum " + calc.Status.Checksum
+	}
+
+	return calc.Status.Value
+}
+
+// annotationEnabled reports whether the annotation is set to "true" on the calculator.
+func annotationEnabled(calc *calcv1alpha1.Calculator, annotation string) bool {
+	return calc.Annotations[annotation] == "true"
+}
+
+// setCondition sets a condition of the calculator for its current generation.
+func setCondition(calc *calcv1alpha1.Calculator, conditionType string, status metav1.ConditionStatus,
+	reason, message string,
+) {
+	meta.SetStatusCondition(&calc.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: calc.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// conditionHolds reports whether the condition is already true with the message.
+func conditionHolds(calc *calcv1alpha1.Calculator, conditionType, message string) bool {
+	condition := meta.FindStatusCondition(calc.Status.Conditions, conditionType)
+
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.Message == message
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *CalculatorReconciler) SetupWithManager(mgr ctrl.Manager, service service.Repository) error {
-	err := ctrl.NewControllerManagedBy(mgr).
-		For(&calcv1alpha1.Calculator{}).
-		Complete(r)
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &calcv1alpha1.Calculator{}, keySecretIndex,
+		indexKeySecret)
+	if err != nil {
+		return fmt.Errorf("failed to index key secrets: %w", err)
+	}
+
+	options := r.Options
+	options.Reconciler = r
+
+	var c controller.Controller
+
+	if r.FairQueuing {
+		c, err = fairqueue.New(controllerName, mgr, options)
+	} else {
+		c, err = controller.New(controllerName, mgr, options)
+	}
+
 	if err != nil {
 		return fmt.Errorf("failed to create controller: %w", err)
 	}
 
+	for _, w := range []struct {
+		kind    client.Object
+		handler handler.EventHandler
+	}{
+		{kind: &calcv1alpha1.Calculator{}, handler: &handler.EnqueueRequestForObject{}},
+		{kind: &corev1.Secret{}, handler: handler.EnqueueRequestsFromMapFunc(r.calculatorsOfKeySecret)},
+		{kind: &calcv1alpha1.CalculatorQuota{}, handler: handler.EnqueueRequestsFromMapFunc(r.calculatorsOfQuota)},
+	} {
+		var predicates []predicate.Predicate
+
+		if _, ok := w.kind.(*calcv1alpha1.Calculator); ok && r.Sharding != nil {
+			predicates = append(predicates, r.Sharding.Predicate())
+		}
+
+		err = c.Watch(&source.Kind{Type: w.kind}, w.handler, predicates...)
+		if err != nil {
+			return fmt.Errorf("failed to watch %T: %w", w.kind, err)
+		}
+	}
+
 	r.Service = service
 
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("calculator-controller")
+	}
+
 	return nil
 }
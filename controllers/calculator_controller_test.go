@@ -90,6 +90,7 @@ var _ = Describe("Calculator controller", func() {
 			Expect(err).To(Not(HaveOccurred()))
 
 			found.Status.Result = found.Spec.X + found.Spec.Y
+			found.Status.Value = fmt.Sprintf("%d", found.Status.Result)
 			found.Status.Processed = true
 
 			var repo mocks.Repository
@@ -109,7 +110,7 @@ var _ = Describe("Calculator controller", func() {
 			})
 
 			repo.On("DefineSecret", ctx, mock.AnythingOfType("string"),
-				mock.AnythingOfType("string"), mock.AnythingOfType("int")).Return(&corev1.Secret{
+				mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&corev1.Secret{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "Secret",
 					APIVersion: "v1",
@@ -0,0 +1,127 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/quota"
+	"github.com/mykysha/kubCalculator/pkg/sharding"
+)
+
+// CalculatorQuotaReconciler reconciles a CalculatorQuota object, reporting the usage of the Calculators of its
+// namespace.
+type CalculatorQuotaReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Sharding restricts the reconciler to the quotas of its shard, nil to reconcile them all.
+	Sharding *sharding.Shard
+}
+
+//+kubebuilder:rbac:groups=calc.example.com,resources=calculatorquotas,verbs=get;list;watch
+//+kubebuilder:rbac:groups=calc.example.com,resources=calculatorquotas/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=calc.example.com,resources=calculators,verbs=get;list;watch
+
+// Reconcile updates the usage in the status of the quota.
+func (r *CalculatorQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	calcQuota := &calcv1alpha1.CalculatorQuota{}
+
+	err := r.Get(ctx, req.NamespacedName, calcQuota)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, fmt.Errorf("failed to get calculator quota: %w", err)
+	}
+
+	if !r.Sharding.Owns(calcQuota) {
+		return ctrl.Result{}, nil
+	}
+
+	calculators := &calcv1alpha1.CalculatorList{}
+
+	err = r.List(ctx, calculators, client.InNamespace(req.Namespace))
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list calculators: %w", err)
+	}
+
+	used := quota.Total(calculators.Items)
+	if used == calcQuota.Status.Used && calcQuota.Status.ObservedGeneration == calcQuota.Generation {
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("Saving the usage", "used", used)
+
+	calcQuota.Status.Used = used
+	calcQuota.Status.ObservedGeneration = calcQuota.Generation
+
+	err = r.Status().Update(ctx, calcQuota)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update calculator quota status: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// quotasOfCalculator enqueues the quotas of the namespace of a calculator, whose usage changes with it.
+func (r *CalculatorQuotaReconciler) quotasOfCalculator(obj client.Object) []reconcile.Request {
+	quotas := &calcv1alpha1.CalculatorQuotaList{}
+
+	err := r.List(context.Background(), quotas, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		log.Log.Error(err, "Failed to list the quotas of a calculator", "calculator", obj.GetName())
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(quotas.Items))
+	for _, calcQuota := range quotas.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: calcQuota.Name, Namespace: calcQuota.Namespace},
+		})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CalculatorQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&calcv1alpha1.CalculatorQuota{}).
+		Watches(&source.Kind{Type: &calcv1alpha1.Calculator{}}, handler.EnqueueRequestsFromMapFunc(r.quotasOfCalculator)).
+		Complete(r)
+	if err != nil {
+		return fmt.Errorf("failed to create controller: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var _ = Describe("CalculatorQuota controller", func() {
+	const (
+		namespaceName = "test-quota"
+		quotaName     = "test-quota"
+	)
+
+	ctx := context.Background()
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+
+	quotaKey := types.NamespacedName{Name: quotaName, Namespace: namespaceName}
+
+	calculator := func(name string) *calcv1alpha1.Calculator {
+		return &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespaceName},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 2, Y: 3},
+		}
+	}
+
+	BeforeEach(func() {
+		By("Creating namespace and the quota")
+		err := k8sClient.Create(ctx, namespace)
+		if !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		calculators := int64(1)
+
+		Expect(k8sClient.Create(ctx, &calcv1alpha1.CalculatorQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: quotaName, Namespace: namespaceName},
+			Spec:       calcv1alpha1.CalculatorQuotaSpec{Hard: calcv1alpha1.CalculatorQuotaLimits{Calculators: &calculators}},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		By("Deleting the quota, the Calculators and their secrets")
+		for _, obj := range []client.Object{
+			&calcv1alpha1.CalculatorQuota{ObjectMeta: metav1.ObjectMeta{Name: quotaName, Namespace: namespaceName}},
+			calculator("first"),
+			calculator("second"),
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: namespaceName}},
+		} {
+			err := k8sClient.Delete(ctx, obj)
+			if !errors.IsNotFound(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+	})
+
+	It("Should report the usage and hold back the Calculators over the quota", func() {
+		first := calculator("first")
+		Expect(k8sClient.Create(ctx, first)).To(Succeed())
+
+		second := calculator("second")
+		Expect(k8sClient.Create(ctx, second)).To(Succeed())
+
+		By("Reporting the usage")
+		quotaReconciler := &CalculatorQuotaReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+		_, err := quotaReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: quotaKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		calcQuota := &calcv1alpha1.CalculatorQuota{}
+		Expect(k8sClient.Get(ctx, quotaKey, calcQuota)).To(Succeed())
+		Expect(calcQuota.Status.Used).To(Equal(calcv1alpha1.CalculatorQuotaUsage{
+			Calculators: 2, EvaluationCost: 6, Outputs: 2,
+		}))
+
+		By("Processing the Calculators within the quota only")
+		reconciler := &CalculatorReconciler{
+			Client:  k8sClient,
+			Service: &service.CalculatorService{},
+			Scheme:  k8sClient.Scheme(),
+		}
+
+		// The Calculators are admitted oldest first, by name when created within the same second.
+		for _, name := range []string{"first", "second"} {
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: name, Namespace: namespaceName},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "first", Namespace: namespaceName}, first)).To(Succeed())
+		Expect(first.Status.Processed).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(first.Status.Conditions, calcv1alpha1.ConditionQuotaExceeded)).To(BeTrue())
+
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "second", Namespace: namespaceName}, second)).To(Succeed())
+		Expect(second.Status.Processed).To(BeFalse())
+		Expect(meta.IsStatusConditionTrue(second.Status.Conditions, calcv1alpha1.ConditionQuotaExceeded)).To(BeTrue())
+	})
+})
@@ -0,0 +1,159 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/encryption"
+)
+
+// keySecretIndex indexes the Calculators by the name of their key Secret.
+const keySecretIndex = "spec.output.encryption.keySecretRef.name"
+
+// checksumKey binds the checksum of the status to its calculator, as the key of a secret entry.
+const checksumKey = "status.checksum"
+
+// errNoKeyring is returned for a Calculator without key Secret when the operator has no keyring file.
+var errNoKeyring = errors.New("no key secret referenced and no keyring file configured")
+
+// protectResult replaces the result in the status of the calculator by its checksum when the calculator asks for
+// encryption, and returns its keyring. The result is then only written to the encrypted secret.
+func (r *CalculatorReconciler) protectResult(ctx context.Context, calc *calcv1alpha1.Calculator,
+) (*encryption.Keyring, error) {
+	if calc.Spec.Output == nil || calc.Spec.Output.Encryption == nil {
+		calc.Status.Checksum = ""
+
+		return nil, nil
+	}
+
+	keyring, err := r.keyring(ctx, calc)
+	if err != nil {
+		r.event(calc, corev1.EventTypeWarning, "EncryptionFailed", err.Error())
+
+		return nil, err
+	}
+
+	calc.Status.Checksum = keyring.Checksum([]byte(calc.Status.Value),
+		encryption.AssociatedData(calc.Namespace, calc.Name, checksumKey))
+	calc.Status.Value, calc.Status.Result, calc.Status.Decimal = "", 0, ""
+
+	return keyring, nil
+}
+
+// encryptSecret encrypts the secret with the keyring of protectResult, if any. The unchanged values of the
+// existing secret are kept, so the secret only changes with the result or when the primary key is rotated.
+func (r *CalculatorReconciler) encryptSecret(ctx context.Context, keyring *encryption.Keyring,
+	secret *corev1.Secret,
+) error {
+	if keyring == nil {
+		return nil
+	}
+
+	existing := &corev1.Secret{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, existing)
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if existing != nil && existing.Annotations[encryption.KeyIDAnnotation] != keyring.Primary {
+		log.FromContext(ctx).Info("Re-encrypting the secret with the primary key", "key", keyring.Primary)
+	}
+
+	if err = keyring.EncryptSecret(secret, existing); err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	return nil
+}
+
+// keyring returns the keyring of the calculator, from its key Secret or from the keyring file of the operator.
+func (r *CalculatorReconciler) keyring(ctx context.Context, calc *calcv1alpha1.Calculator,
+) (*encryption.Keyring, error) {
+	ref := calc.Spec.Output.Encryption.KeySecretRef
+	if ref == nil {
+		if r.KeyringFile == "" {
+			return nil, errNoKeyring
+		}
+
+		keyring, err := encryption.LoadKeyring(r.KeyringFile)
+		if err != nil {
+			return nil, fmt.Errorf("keyring file: %w", err)
+		}
+
+		return keyring, nil
+	}
+
+	keySecret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: calc.Namespace}, keySecret); err != nil {
+		return nil, fmt.Errorf("failed to get key secret %s: %w", ref.Name, err)
+	}
+
+	keyring, err := encryption.ParseKeyring(keySecret.Data[encryption.KeyringKey])
+	if err != nil {
+		return nil, fmt.Errorf("key secret %s: %w", ref.Name, err)
+	}
+
+	return keyring, nil
+}
+
+// indexKeySecret returns the name of the key Secret of the calculator, for the keySecretIndex.
+func indexKeySecret(obj client.Object) []string {
+	calc, ok := obj.(*calcv1alpha1.Calculator)
+	if !ok || calc.Spec.Output == nil || calc.Spec.Output.Encryption == nil ||
+		calc.Spec.Output.Encryption.KeySecretRef == nil {
+		return nil
+	}
+
+	return []string{calc.Spec.Output.Encryption.KeySecretRef.Name}
+}
+
+// calculatorsOfKeySecret enqueues the calculators encrypted with the keys of the secret, which re-encrypts their
+// results when the primary key is rotated.
+func (r *CalculatorReconciler) calculatorsOfKeySecret(obj client.Object) []reconcile.Request {
+	calculators := &calcv1alpha1.CalculatorList{}
+
+	err := r.List(context.Background(), calculators, client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{keySecretIndex: obj.GetName()})
+	if err != nil {
+		log.Log.Error(err, "Failed to list the calculators of a key secret", "secret", obj.GetName())
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(calculators.Items))
+	for _, calc := range calculators.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: calc.Name, Namespace: calc.Namespace},
+		})
+	}
+
+	return requests
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/encryption"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var _ = Describe("Calculator controller encryption", func() {
+	const (
+		namespaceName  = "test-encryption"
+		calculatorName = "test-encryption"
+		keySecretName  = "calc-keys"
+		keys           = `
+keys:
+  k1: MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=
+  k2: ZmVkY2JhOTg3NjU0MzIxMA==
+`
+	)
+
+	ctx := context.Background()
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+
+	typeNamespaceName := types.NamespacedName{Name: calculatorName, Namespace: namespaceName}
+	keySecretKey := types.NamespacedName{Name: keySecretName, Namespace: namespaceName}
+
+	var reconciler *CalculatorReconciler
+
+	reconcileSecret := func() *corev1.Secret {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, typeNamespaceName, secret)).To(Succeed())
+
+		return secret
+	}
+
+	BeforeEach(func() {
+		By("Creating namespace and the key Secret")
+		err := k8sClient.Create(ctx, namespace)
+		if !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: keySecretName, Namespace: namespaceName},
+			StringData: map[string]string{encryption.KeyringKey: "primary: k1" + keys},
+		})).To(Succeed())
+
+		reconciler = &CalculatorReconciler{
+			Client:  k8sClient,
+			Service: &service.CalculatorService{},
+			Scheme:  k8sClient.Scheme(),
+		}
+	})
+
+	AfterEach(func() {
+		By("Deleting the Calculator and the secrets")
+		for _, obj := range []client.Object{
+			&calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: keySecretName, Namespace: namespaceName}},
+		} {
+			err := k8sClient.Delete(ctx, obj)
+			if !errors.IsNotFound(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+	})
+
+	It("Should encrypt the result and re-encrypt it when the key is rotated", func() {
+		Expect(k8sClient.Create(ctx, &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+			Spec: calcv1alpha1.CalculatorSpec{
+				X: 2,
+				Y: 3,
+				Output: &calcv1alpha1.OutputSpec{Encryption: &calcv1alpha1.EncryptionSpec{
+					KeySecretRef: &corev1.LocalObjectReference{Name: keySecretName},
+				}},
+			},
+		})).To(Succeed())
+
+		secret := reconcileSecret()
+		Expect(secret.Annotations).To(HaveKeyWithValue(encryption.KeyIDAnnotation, "k1"))
+		Expect(string(secret.Data[service.DefaultResultKey])).NotTo(Equal("5"))
+
+		keyring, err := encryption.ParseKeyring([]byte("primary: k2" + keys))
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := keyring.DecryptSecret(secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data[service.DefaultResultKey])).To(Equal("5"))
+
+		By("Keeping the ciphertext of an unchanged result")
+		Expect(reconcileSecret().Data).To(Equal(secret.Data))
+
+		By("Rotating the primary key")
+		keySecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, keySecretKey, keySecret)).To(Succeed())
+		keySecret.Data[encryption.KeyringKey] = []byte("primary: k2" + keys)
+		Expect(k8sClient.Update(ctx, keySecret)).To(Succeed())
+
+		rotated := reconcileSecret()
+		Expect(rotated.Annotations).To(HaveKeyWithValue(encryption.KeyIDAnnotation, "k2"))
+		Expect(rotated.Data).NotTo(Equal(secret.Data))
+	})
+
+	It("Should keep the result out of the status, the events and the conditions", func() {
+		recorder := record.NewFakeRecorder(10)
+		reconciler.Recorder = recorder
+
+		Expect(k8sClient.Create(ctx, &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+			Spec: calcv1alpha1.CalculatorSpec{
+				X:          1234,
+				Y:          4321,
+				Expression: "x + y",
+				Output: &calcv1alpha1.OutputSpec{Encryption: &calcv1alpha1.EncryptionSpec{
+					KeySecretRef: &corev1.LocalObjectReference{Name: keySecretName},
+				}},
+			},
+		})).To(Succeed())
+
+		secret := reconcileSecret()
+
+		keyring, err := encryption.ParseKeyring([]byte("primary: k1" + keys))
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := keyring.DecryptSecret(secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data[service.DefaultResultKey])).To(Equal("5555"))
+
+		calc := &calcv1alpha1.Calculator{}
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calc)).To(Succeed())
+		Expect(calc.Status.Processed).To(BeTrue())
+		Expect(calc.Status.Value).To(BeEmpty())
+		Expect(calc.Status.Result).To(BeZero())
+		Expect(calc.Status.Decimal).To(BeEmpty())
+		Expect(calc.Status.Checksum).To(MatchRegexp("^[0-9a-f]{64}$"))
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(Equal(`Normal Calculated Result with checksum ` + calc.Status.Checksum +
+			` (x=1234, y=4321, expression "x + y")`))
+
+		By("Keeping the checksum of an unchanged result without a new event")
+		reconcileSecret()
+		Expect(recorder.Events).To(BeEmpty())
+
+		By("Keeping the result out of the DryRun condition")
+		calc.Annotations = map[string]string{calcv1alpha1.DryRunAnnotation: "true"}
+		Expect(k8sClient.Update(ctx, calc)).To(Succeed())
+
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calc)).To(Succeed())
+		Expect(calc.Status.Value).To(BeEmpty())
+		Expect(meta.FindStatusCondition(calc.Status.Conditions, calcv1alpha1.ConditionDryRun).Message).To(Equal(
+			"Result with checksum " + calc.Status.Checksum + " was not written to the secret"))
+
+		for _, condition := range calc.Status.Conditions {
+			Expect(condition.Message).NotTo(ContainSubstring("5555"))
+		}
+
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
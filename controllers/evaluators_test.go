@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/external"
+	"github.com/mykysha/kubCalculator/pkg/external/externaltest"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var _ = Describe("Calculator controller evaluators", func() {
+	const namespaceName = "test-evaluators"
+
+	ctx := context.Background()
+
+	var (
+		server     *externaltest.Server
+		reconciler *CalculatorReconciler
+	)
+
+	BeforeEach(func() {
+		server = externaltest.NewServer(service.CalculatorService{})
+
+		evaluator, err := external.Dial(external.Options{
+			Name:        "remote",
+			Address:     externaltest.Address,
+			DialOptions: []grpc.DialOption{server.DialOption()},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(evaluator.Close()).To(Succeed())
+			server.Close()
+		})
+
+		reconciler = &CalculatorReconciler{
+			Client:     k8sClient,
+			Service:    &service.CalculatorService{},
+			Evaluators: map[string]service.Repository{"remote": evaluator},
+			Scheme:     k8sClient.Scheme(),
+		}
+	})
+
+	It("Should delegate to the referenced evaluator", func() {
+		By("Creating namespace")
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}})).
+			To(Succeed())
+
+		calculator := &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: namespaceName},
+			Spec: calcv1alpha1.CalculatorSpec{
+				X: 6, Y: 7, Expression: "x * y",
+				EvaluatorRef: &calcv1alpha1.EvaluatorReference{Name: "remote"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, calculator)).To(Succeed())
+
+		name := types.NamespacedName{Name: calculator.Name, Namespace: namespaceName}
+
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Checking the result of the evaluator was written")
+		Expect(server.Calls()).To(Equal(2))
+
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, name, secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKeyWithValue("result", []byte("42")))
+	})
+
+	It("Should report an unknown evaluator", func() {
+		calculator := &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "unknown", Namespace: namespaceName},
+			Spec: calcv1alpha1.CalculatorSpec{
+				EvaluatorRef: &calcv1alpha1.EvaluatorReference{Name: "unknown"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, calculator)).To(Succeed())
+
+		name := types.NamespacedName{Name: calculator.Name, Namespace: namespaceName}
+
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: name})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, name, calculator)).To(Succeed())
+
+		condition := meta.FindStatusCondition(calculator.Status.Conditions, calcv1alpha1.ConditionInvalid)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("UnknownEvaluator"))
+		Expect(server.Calls()).To(BeZero())
+	})
+})
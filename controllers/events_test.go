@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var _ = Describe("Calculator controller events", func() {
+	const namespaceName = "test-events"
+
+	ctx := context.Background()
+
+	It("Should record new results and failures once", func() {
+		By("Creating namespace")
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}})).
+			To(Succeed())
+
+		calculator := &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "events", Namespace: namespaceName},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 1, Y: 2},
+		}
+		Expect(k8sClient.Create(ctx, calculator)).To(Succeed())
+
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &CalculatorReconciler{
+			Client:   k8sClient,
+			Service:  &service.CalculatorService{},
+			Scheme:   k8sClient.Scheme(),
+			Recorder: recorder,
+		}
+
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "events", Namespace: namespaceName}}
+
+		By("Reconciling twice")
+		for i := 0; i < 2; i++ {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(Equal(`Normal Calculated Result 3 (x=1, y=2, expression "")`))
+
+		By("Making the expression invalid")
+		Expect(k8sClient.Get(ctx, request.NamespacedName, calculator)).To(Succeed())
+		calculator.Spec.Expression = "x / 0"
+		Expect(k8sClient.Update(ctx, calculator)).To(Succeed())
+
+		for i := 0; i < 2; i++ {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(Equal("Warning InvalidExpression division by zero"))
+	})
+})
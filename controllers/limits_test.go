@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var _ = Describe("Calculator controller limits", func() {
+	const (
+		namespaceName  = "test-limits"
+		calculatorName = "test-limits"
+	)
+
+	ctx := context.Background()
+
+	typeNamespaceName := types.NamespacedName{Name: calculatorName, Namespace: namespaceName}
+
+	It("Should report a LimitExceeded condition instead of failing", func() {
+		By("Creating namespace")
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}})).
+			To(Succeed())
+
+		calculator := &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 60, Y: 40},
+		}
+		Expect(k8sClient.Create(ctx, calculator)).To(Succeed())
+
+		reconciler := &CalculatorReconciler{
+			Client:  k8sClient,
+			Service: &service.CalculatorService{Limits: service.Limits{MaxDigits: 2}},
+			Scheme:  k8sClient.Scheme(),
+		}
+
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Checking the condition is set and no result was written")
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calculator)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(calculator.Status.Conditions, calcv1alpha1.ConditionLimitExceeded)).
+			To(BeTrue())
+		Expect(calculator.Status.Processed).To(BeFalse())
+
+		err = k8sClient.Get(ctx, typeNamespaceName, &corev1.Secret{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var _ = Describe("Calculator controller restricted to watched namespaces", func() {
+	const (
+		watchedNamespace = "test-watched"
+		ignoredNamespace = "test-ignored"
+		calculatorName   = "test-namespaces"
+		timeout          = 10 * time.Second
+		interval         = 250 * time.Millisecond
+	)
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+
+		By("Creating namespaces")
+		for _, name := range []string{watchedNamespace, ignoredNamespace} {
+			err := k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		By("Starting a manager watching a single namespace")
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme:             k8sClient.Scheme(),
+			MetricsBindAddress: "0",
+			NewCache:           cache.MultiNamespacedCacheBuilder([]string{watchedNamespace}),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		reconciler := &CalculatorReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}
+		Expect(reconciler.SetupWithManager(mgr, &service.CalculatorService{})).To(Succeed())
+
+		go func() {
+			defer GinkgoRecover()
+			Expect(mgr.Start(ctx)).To(Succeed())
+		}()
+	})
+
+	AfterEach(func() {
+		By("Stopping the manager")
+		cancel()
+
+		By("Deleting namespaces")
+		for _, name := range []string{watchedNamespace, ignoredNamespace} {
+			err := k8sClient.Delete(context.Background(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	It("Should only reconcile Calculators in the watched namespaces", func() {
+		By("Creating a Calculator in each namespace")
+		for _, namespace := range []string{watchedNamespace, ignoredNamespace} {
+			calculator := &calcv1alpha1.Calculator{
+				ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespace},
+				Spec:       calcv1alpha1.CalculatorSpec{X: 2, Y: 3},
+			}
+			Expect(k8sClient.Create(ctx, calculator)).To(Succeed())
+		}
+
+		By("Checking the Calculator in the watched namespace is processed")
+		Eventually(func() bool {
+			calculator := &calcv1alpha1.Calculator{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: calculatorName, Namespace: watchedNamespace}, calculator)
+
+			return err == nil && calculator.Status.Processed && calculator.Status.Result == 5
+		}, timeout, interval).Should(BeTrue())
+
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: calculatorName, Namespace: watchedNamespace},
+				&corev1.Secret{})
+		}, timeout, interval).Should(Succeed())
+
+		By("Checking the Calculator in the other namespace is ignored")
+		Consistently(func() bool {
+			calculator := &calcv1alpha1.Calculator{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: calculatorName, Namespace: ignoredNamespace}, calculator)
+
+			return err == nil && !calculator.Status.Processed
+		}, 2*time.Second, interval).Should(BeTrue())
+
+		err := k8sClient.Get(ctx, types.NamespacedName{Name: calculatorName, Namespace: ignoredNamespace},
+			&corev1.Secret{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
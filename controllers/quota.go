@@ -0,0 +1,83 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/quota"
+)
+
+//+kubebuilder:rbac:groups=calc.example.com,resources=calculatorquotas,verbs=get;list;watch
+
+// overQuota returns why the calculator exceeds a quota of its namespace, empty when it is within them. The webhook
+// rejects the calculators exceeding a quota, this catches those created before the quota or without the webhook.
+func (r *CalculatorReconciler) overQuota(ctx context.Context, calc *calcv1alpha1.Calculator) (string, error) {
+	quotas := &calcv1alpha1.CalculatorQuotaList{}
+
+	err := r.List(ctx, quotas, client.InNamespace(calc.Namespace))
+	if err != nil {
+		return "", fmt.Errorf("failed to list quotas: %w", err)
+	}
+
+	if len(quotas.Items) == 0 {
+		return "", nil
+	}
+
+	calculators := &calcv1alpha1.CalculatorList{}
+
+	err = r.List(ctx, calculators, client.InNamespace(calc.Namespace))
+	if err != nil {
+		return "", fmt.Errorf("failed to list calculators: %w", err)
+	}
+
+	exceeded, ok := quota.OverQuota(quotas.Items, calculators.Items)[calc.Name]
+	if !ok {
+		return "", nil
+	}
+
+	return exceeded.Error(), nil
+}
+
+// calculatorsOfQuota enqueues the calculators of the namespace of a quota, which are admitted again when it
+// changes.
+func (r *CalculatorReconciler) calculatorsOfQuota(obj client.Object) []reconcile.Request {
+	calculators := &calcv1alpha1.CalculatorList{}
+
+	err := r.List(context.Background(), calculators, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		log.Log.Error(err, "Failed to list the calculators of a quota", "quota", obj.GetName())
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(calculators.Items))
+	for _, calc := range calculators.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: calc.Name, Namespace: calc.Namespace},
+		})
+	}
+
+	return requests
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/rollout"
+)
+
+//+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch;patch
+
+// rolloutConsumers annotates the pod templates of the workloads selected by the rolloutSelector of the
+// calculator with the checksum of its secret. Only the workloads whose annotation differs are patched, which
+// rolls them out.
+func (r *CalculatorReconciler) rolloutConsumers(ctx context.Context, calc *calcv1alpha1.Calculator,
+	secret *corev1.Secret,
+) error {
+	if calc.Spec.Output == nil || calc.Spec.Output.RolloutSelector == nil {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	selector, err := metav1.LabelSelectorAsSelector(calc.Spec.Output.RolloutSelector)
+	if err != nil {
+		return fmt.Errorf("invalid rollout selector: %w", err)
+	}
+
+	annotation, checksum := rollout.Annotation(calc.Name), rollout.Checksum(secret)
+
+	for _, list := range rollout.Workloads() {
+		err = r.List(ctx, list, client.InNamespace(calc.Namespace), client.MatchingLabelsSelector{Selector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list consumers: %w", err)
+		}
+
+		for _, workload := range rollout.Items(list) {
+			patch := client.MergeFrom(workload.DeepCopyObject().(client.Object)) //nolint:forcetypeassert // Same type
+
+			if !rollout.Annotate(workload, annotation, checksum) {
+				continue
+			}
+
+			gvk, err := apiutil.GVKForObject(workload, r.Scheme)
+			if err != nil {
+				return fmt.Errorf("failed to get consumer kind: %w", err)
+			}
+
+			logger.Info("Rolling out a consumer", "kind", gvk.Kind, "name", workload.GetName(), "checksum", checksum)
+
+			if err = r.Patch(ctx, workload, patch); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+
+				return fmt.Errorf("failed to annotate %s %s: %w", gvk.Kind, workload.GetName(), err)
+			}
+
+			r.event(calc, corev1.EventTypeNormal, "RolloutTriggered",
+				fmt.Sprintf("Annotated %s %s with checksum %s", gvk.Kind, workload.GetName(), checksum))
+		}
+	}
+
+	return nil
+}
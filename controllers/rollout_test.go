@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/rollout"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var _ = Describe("Calculator controller rollouts", func() {
+	const (
+		namespaceName  = "test-rollout"
+		calculatorName = "test-rollout"
+	)
+
+	ctx := context.Background()
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+
+	typeNamespaceName := types.NamespacedName{Name: calculatorName, Namespace: namespaceName}
+
+	deployment := func(name string, labels map[string]string) *appsv1.Deployment {
+		podLabels := map[string]string{"pod": name}
+
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespaceName, Labels: labels},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}},
+				},
+			},
+		}
+	}
+
+	checksum := func(name string) string {
+		workload := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: namespaceName}, workload)).To(Succeed())
+
+		return workload.Spec.Template.Annotations[rollout.Annotation(calculatorName)]
+	}
+
+	var reconciler *CalculatorReconciler
+
+	BeforeEach(func() {
+		By("Creating namespace and the workloads")
+		err := k8sClient.Create(ctx, namespace)
+		if !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(k8sClient.Create(ctx, deployment("consumer", map[string]string{"uses": calculatorName}))).To(Succeed())
+		Expect(k8sClient.Create(ctx, deployment("bystander", nil))).To(Succeed())
+
+		reconciler = &CalculatorReconciler{
+			Client:  k8sClient,
+			Service: &service.CalculatorService{},
+			Scheme:  k8sClient.Scheme(),
+		}
+	})
+
+	AfterEach(func() {
+		By("Deleting the Calculator, its secret and the workloads")
+		for _, obj := range []client.Object{
+			&calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "consumer", Namespace: namespaceName}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "bystander", Namespace: namespaceName}},
+		} {
+			err := k8sClient.Delete(ctx, obj)
+			if !errors.IsNotFound(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+	})
+
+	It("Should annotate the selected consumers with the checksum of the result", func() {
+		Expect(k8sClient.Create(ctx, &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+			Spec: calcv1alpha1.CalculatorSpec{
+				X: 2,
+				Y: 3,
+				Output: &calcv1alpha1.OutputSpec{RolloutSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"uses": calculatorName},
+				}},
+			},
+		})).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		first := checksum("consumer")
+		Expect(first).NotTo(BeEmpty())
+		Expect(checksum("bystander")).To(BeEmpty())
+
+		By("Changing the result changes the checksum")
+		calculator := &calcv1alpha1.Calculator{}
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calculator)).To(Succeed())
+		calculator.Spec.X = 4
+		Expect(k8sClient.Update(ctx, calculator)).To(Succeed())
+
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(checksum("consumer")).NotTo(Equal(first))
+	})
+})
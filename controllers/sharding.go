@@ -0,0 +1,88 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/sharding"
+)
+
+// claimRetryInterval is the interval the claim of another shard is checked at, until its lease expires.
+const claimRetryInterval = 15 * time.Second
+
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get
+
+// claim claims the calculator for the shard of the reconciler, reporting whether it may write its results. A
+// calculator the shard no longer owns is released for its new owner.
+func (r *CalculatorReconciler) claim(ctx context.Context, calc *calcv1alpha1.Calculator) (bool, ctrl.Result, error) {
+	if r.Sharding == nil {
+		return true, ctrl.Result{}, nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	owns, claimed := r.Sharding.Owns(calc), r.Sharding.Claimed(calc)
+
+	switch {
+	case owns && claimed:
+		return true, ctrl.Result{}, nil
+	case claimed:
+		logger.Info("Releasing the Calculator for its new shard")
+
+		sharding.SetClaim(calc, -1)
+
+		if err := r.Update(ctx, calc); err != nil {
+			return false, ctrl.Result{}, fmt.Errorf("failed to release calculator: %w", err)
+		}
+
+		return false, ctrl.Result{}, nil
+	case !owns:
+		return false, ctrl.Result{}, nil
+	}
+
+	if id, ok := sharding.Claim(calc); ok {
+		expired, err := r.Sharding.LeaseExpired(ctx, id, time.Now())
+		if err != nil {
+			return false, ctrl.Result{}, err
+		}
+
+		if !expired {
+			// The other shard releases it once it runs with the new shard count.
+			logger.Info("Calculator is claimed by another shard, waiting", "shard", id)
+
+			return false, ctrl.Result{RequeueAfter: claimRetryInterval}, nil
+		}
+	}
+
+	logger.Info("Claiming the Calculator", "shard", r.Sharding.ID)
+
+	sharding.SetClaim(calc, r.Sharding.ID)
+
+	// The update fails with a conflict if another shard claimed it meanwhile.
+	if err := r.Update(ctx, calc); err != nil {
+		return false, ctrl.Result{}, fmt.Errorf("failed to claim calculator: %w", err)
+	}
+
+	return true, ctrl.Result{}, nil
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/sharding"
+)
+
+var _ = Describe("Calculator controller sharding", func() {
+	const namespaceName = "test-sharding"
+
+	ctx := context.Background()
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+
+	// calculatorName is owned by the shard 1 of 2, not by the shard 1 of 3.
+	calculatorName := ""
+	for i := 0; calculatorName == "" || sharding.Of(namespaceName, calculatorName, 2) != 1 ||
+		sharding.Of(namespaceName, calculatorName, 3) == 1; i++ {
+		calculatorName = fmt.Sprint("test-sharding-", i)
+	}
+
+	typeNamespaceName := types.NamespacedName{Name: calculatorName, Namespace: namespaceName}
+
+	reconcilerOf := func(id, count int) *CalculatorReconciler {
+		return &CalculatorReconciler{
+			Client:  k8sClient,
+			Service: &service.CalculatorService{},
+			Scheme:  k8sClient.Scheme(),
+			Sharding: &sharding.Shard{
+				ID: id, Count: count, LeaseNamespace: namespaceName, LeasePrefix: "calc", Reader: k8sClient,
+			},
+		}
+	}
+
+	reconcileWith := func(reconciler *CalculatorReconciler) reconcile.Result {
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		return result
+	}
+
+	// claim returns the shard claiming the Calculator, -1 when unclaimed.
+	claim := func() int {
+		calc := &calcv1alpha1.Calculator{}
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calc)).To(Succeed())
+
+		id, ok := sharding.Claim(calc)
+		if !ok {
+			return -1
+		}
+
+		return id
+	}
+
+	secretExists := func() bool {
+		err := k8sClient.Get(ctx, typeNamespaceName, &corev1.Secret{})
+		if errors.IsNotFound(err) {
+			return false
+		}
+
+		Expect(err).NotTo(HaveOccurred())
+
+		return true
+	}
+
+	BeforeEach(func() {
+		By("Creating namespace and the Calculator")
+		err := k8sClient.Create(ctx, namespace)
+		if !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(k8sClient.Create(ctx, &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 2, Y: 3},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		By("Deleting the Calculator, its secret and the lease")
+		for _, obj := range []client.Object{
+			&calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName}},
+			&coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "calc-shard-1", Namespace: namespaceName}},
+		} {
+			err := k8sClient.Delete(ctx, obj)
+			if !errors.IsNotFound(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+	})
+
+	It("Should only let the owner shard write the Calculator", func() {
+		reconcileWith(reconcilerOf(0, 2))
+		Expect(claim()).To(Equal(-1))
+		Expect(secretExists()).To(BeFalse())
+
+		reconcileWith(reconcilerOf(1, 2))
+		Expect(claim()).To(Equal(1))
+		Expect(secretExists()).To(BeTrue())
+	})
+
+	It("Should hand the Calculator over when resharding", func() {
+		holder, duration := "replica-1", int32(15)
+		Expect(k8sClient.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: "calc-shard-1", Namespace: namespaceName},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &duration,
+				RenewTime:            &metav1.MicroTime{Time: time.Now()},
+			},
+		})).To(Succeed())
+
+		reconcileWith(reconcilerOf(1, 2))
+		Expect(claim()).To(Equal(1))
+
+		By("Waiting for the claim of the running shard")
+		Expect(reconcileWith(reconcilerOf(0, 1)).RequeueAfter).To(Equal(claimRetryInterval))
+		Expect(claim()).To(Equal(1))
+
+		By("Releasing the Calculator once the previous owner runs with the new shard count")
+		reconcileWith(reconcilerOf(1, 3))
+		Expect(claim()).To(Equal(-1))
+
+		reconcileWith(reconcilerOf(0, 1))
+		Expect(claim()).To(Equal(0))
+	})
+})
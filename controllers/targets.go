@@ -0,0 +1,320 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/targets"
+)
+
+// targetRetryInterval is the delay before applying again the targets that could not be synced, such as
+// missing objects.
+const targetRetryInterval = 30 * time.Second
+
+// Reasons of the target statuses.
+const (
+	reasonApplied     = "Applied"
+	reasonNotAllowed  = "NotAllowed"
+	reasonInvalid     = "Invalid"
+	reasonNotFound    = "NotFound"
+	reasonUnknownKind = "UnknownKind"
+	reasonForbidden   = "Forbidden"
+	reasonConflict    = "Conflict"
+	reasonFailed      = "Failed"
+)
+
+// targetObject is an object written by some targets of a calculator, with a single apply.
+type targetObject struct {
+	groupKind schema.GroupKind
+	name      string
+	apply     *unstructured.Unstructured
+	// targets are the indexes of the targets of the object.
+	targets []int
+}
+
+// syncTargets applies the result of the calculator to its targets and reports their states in the status.
+// The fields of an object are applied together, as an apply declares every field of the field manager: the
+// fields of the objects the calculator no longer targets are released. It reports whether some targets should
+// be retried.
+func (r *CalculatorReconciler) syncTargets(ctx context.Context, calc *calcv1alpha1.Calculator) bool {
+	previous := make(map[string]calcv1alpha1.TargetStatus, len(calc.Status.Targets))
+	for _, status := range calc.Status.Targets {
+		previous[targetKey(status.APIVersion, status.Kind, status.Name, status.Path)] = status
+	}
+
+	statuses := make([]calcv1alpha1.TargetStatus, len(calc.Spec.Targets))
+
+	var objects []*targetObject
+
+	for i, target := range calc.Spec.Targets {
+		statuses[i] = calcv1alpha1.TargetStatus{
+			APIVersion: target.APIVersion,
+			Kind:       target.Kind,
+			Name:       target.Name,
+			Path:       target.Path,
+		}
+
+		objects = r.addTarget(calc, objects, i, &statuses[i])
+	}
+
+	now := metav1.Now()
+
+	for _, object := range objects {
+		reason, message := r.applyTarget(ctx, calc, object)
+
+		for _, i := range object.targets {
+			statuses[i].Reason, statuses[i].Message = reason, message
+			statuses[i].Synced = reason == reasonApplied
+
+			if !statuses[i].Synced {
+				continue
+			}
+
+			// The time only changes with the value, an unchanged status does not trigger a reconciliation.
+			last, ok := previous[targetKey(statuses[i].APIVersion, statuses[i].Kind, statuses[i].Name,
+				statuses[i].Path)]
+			if ok && last.Synced && last.Value == statuses[i].Value && last.LastSyncTime != nil {
+				statuses[i].LastSyncTime = last.LastSyncTime
+			} else {
+				statuses[i].LastSyncTime = &now
+			}
+		}
+	}
+
+	r.releaseTargets(ctx, calc, objects)
+
+	return r.reportTargets(calc, previous, statuses)
+}
+
+// addTarget renders the target i and adds its field to the apply of its object.
+func (r *CalculatorReconciler) addTarget(calc *calcv1alpha1.Calculator, objects []*targetObject, i int,
+	status *calcv1alpha1.TargetStatus,
+) []*targetObject {
+	target := calc.Spec.Targets[i]
+
+	groupKind, err := targets.GroupKind(target)
+	if err != nil {
+		status.Reason, status.Message = reasonInvalid, err.Error()
+
+		return objects
+	}
+
+	if !r.AllowedTargets.Allows(groupKind) {
+		status.Reason = reasonNotAllowed
+		status.Message = fmt.Sprintf("%s is not allowed by the operator configuration", groupKind)
+
+		return objects
+	}
+
+	text, value, err := targets.Render(target, calc)
+	if err != nil {
+		status.Reason, status.Message = reasonInvalid, err.Error()
+
+		return objects
+	}
+
+	status.Value = text
+
+	apply, err := targets.Object(target, calc.Namespace, value)
+	if err != nil {
+		status.Reason, status.Message = reasonInvalid, err.Error()
+
+		return objects
+	}
+
+	for _, object := range objects {
+		if object.groupKind != groupKind || object.name != target.Name {
+			continue
+		}
+
+		fields, _ := targets.ParsePath(target.Path)
+		if err = unstructured.SetNestedField(object.apply.Object, value, fields...); err != nil {
+			status.Reason, status.Message = reasonInvalid, fmt.Sprintf("path overlaps another target: %v", err)
+
+			return objects
+		}
+
+		object.targets = append(object.targets, i)
+
+		return objects
+	}
+
+	return append(objects, &targetObject{groupKind: groupKind, name: target.Name, apply: apply, targets: []int{i}})
+}
+
+// applyTarget applies the fields of the object, which must exist, and returns the reason and the message of
+// the state of its targets.
+func (r *CalculatorReconciler) applyTarget(ctx context.Context, calc *calcv1alpha1.Calculator,
+	object *targetObject,
+) (string, string) {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(object.apply.GroupVersionKind())
+
+	// Targets are never created, applying would create missing objects.
+	err := r.Get(ctx, types.NamespacedName{Name: object.name, Namespace: calc.Namespace}, existing)
+	if err == nil {
+		opts := []client.PatchOption{client.FieldOwner(targets.FieldManager(calc))}
+
+		for _, i := range object.targets {
+			if calc.Spec.Targets[i].Force {
+				opts = append(opts, client.ForceOwnership)
+
+				break
+			}
+		}
+
+		err = r.Patch(ctx, object.apply, client.Apply, opts...)
+	}
+
+	switch {
+	case err == nil:
+		return reasonApplied, ""
+	case errors.IsNotFound(err):
+		return reasonNotFound, fmt.Sprintf("%s %s does not exist", object.groupKind, object.name)
+	case meta.IsNoMatchError(err):
+		return reasonUnknownKind, err.Error()
+	case errors.IsForbidden(err):
+		return reasonForbidden, err.Error()
+	case errors.IsConflict(err):
+		return reasonConflict, err.Error()
+	case errors.IsInvalid(err):
+		return reasonInvalid, err.Error()
+	default:
+		log.FromContext(ctx).Error(err, "Failed to apply target", "kind", object.groupKind, "name", object.name)
+
+		return reasonFailed, err.Error()
+	}
+}
+
+// releaseTargets applies an empty configuration to the objects the calculator synced but no longer targets,
+// which removes the fields it owns. The objects deleted since are skipped.
+func (r *CalculatorReconciler) releaseTargets(ctx context.Context, calc *calcv1alpha1.Calculator,
+	objects []*targetObject,
+) {
+	logger := log.FromContext(ctx)
+	released := make(map[string]bool)
+
+	for _, status := range calc.Status.Targets {
+		groupVersion, err := schema.ParseGroupVersion(status.APIVersion)
+		if !status.Synced || err != nil {
+			continue
+		}
+
+		groupKind := groupVersion.WithKind(status.Kind).GroupKind()
+		key := targetKey(groupKind.Group, groupKind.Kind, status.Name, "")
+
+		if released[key] || targeted(objects, groupKind, status.Name) {
+			continue
+		}
+
+		released[key] = true
+
+		apply := &unstructured.Unstructured{}
+		apply.SetAPIVersion(status.APIVersion)
+		apply.SetKind(status.Kind)
+		apply.SetNamespace(calc.Namespace)
+		apply.SetName(status.Name)
+
+		// Applying would create missing objects.
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(apply.GroupVersionKind())
+
+		err = r.Get(ctx, types.NamespacedName{Name: status.Name, Namespace: calc.Namespace}, existing)
+		if err == nil {
+			err = r.Patch(ctx, apply, client.Apply, client.FieldOwner(targets.FieldManager(calc)))
+		}
+
+		if err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to release target", "kind", groupKind, "name", status.Name)
+		}
+	}
+}
+
+// reportTargets sets the target statuses and the TargetsSynced condition, and reports whether some targets
+// should be retried.
+func (r *CalculatorReconciler) reportTargets(calc *calcv1alpha1.Calculator,
+	previous map[string]calcv1alpha1.TargetStatus, statuses []calcv1alpha1.TargetStatus,
+) bool {
+	if len(statuses) == 0 {
+		calc.Status.Targets = nil
+		meta.RemoveStatusCondition(&calc.Status.Conditions, calcv1alpha1.ConditionTargetsSynced)
+
+		return false
+	}
+
+	calc.Status.Targets = statuses
+
+	retry := false
+	failed := 0
+
+	for _, status := range statuses {
+		if status.Synced {
+			continue
+		}
+
+		failed++
+
+		switch status.Reason {
+		case reasonNotFound, reasonUnknownKind, reasonForbidden, reasonConflict, reasonFailed:
+			retry = true
+		}
+
+		last, ok := previous[targetKey(status.APIVersion, status.Kind, status.Name, status.Path)]
+		if !ok || last.Reason != status.Reason {
+			r.event(calc, corev1.EventTypeWarning, "TargetNotSynced", fmt.Sprintf("%s %s %s: %s", status.Kind,
+				status.Name, status.Path, status.Message))
+		}
+	}
+
+	if failed > 0 {
+		setCondition(calc, calcv1alpha1.ConditionTargetsSynced, metav1.ConditionFalse, "TargetsNotSynced",
+			fmt.Sprintf("%d of %d targets are not synced", failed, len(statuses)))
+	} else {
+		setCondition(calc, calcv1alpha1.ConditionTargetsSynced, metav1.ConditionTrue, "TargetsSynced", "")
+	}
+
+	return retry
+}
+
+// targeted reports whether the object is one of the objects.
+func targeted(objects []*targetObject, groupKind schema.GroupKind, name string) bool {
+	for _, object := range objects {
+		if object.groupKind == groupKind && object.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func targetKey(apiVersion, kind, name, path string) string {
+	return apiVersion + "/" + kind + "/" + name + "/" + path
+}
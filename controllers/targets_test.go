@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/targets"
+)
+
+var _ = Describe("Calculator controller targets", func() {
+	const (
+		namespaceName  = "test-targets"
+		calculatorName = "test-targets"
+		configMapName  = "settings"
+	)
+
+	ctx := context.Background()
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+
+	typeNamespaceName := types.NamespacedName{Name: calculatorName, Namespace: namespaceName}
+
+	var reconciler *CalculatorReconciler
+
+	BeforeEach(func() {
+		By("Creating namespace and the target ConfigMap")
+		err := k8sClient.Create(ctx, namespace)
+		if !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: namespaceName},
+			Data:       map[string]string{"owner": "team-a"},
+		})).To(Succeed())
+
+		reconciler = &CalculatorReconciler{
+			Client:         k8sClient,
+			Service:        &service.CalculatorService{},
+			Scheme:         k8sClient.Scheme(),
+			AllowedTargets: targets.Allowlist{{Kind: "ConfigMap"}},
+		}
+	})
+
+	AfterEach(func() {
+		By("Deleting the Calculator, its secret and the target")
+		for _, obj := range []client.Object{
+			&calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: namespaceName}},
+		} {
+			err := k8sClient.Delete(ctx, obj)
+			if !errors.IsNotFound(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+	})
+
+	It("Should apply the result to the allowed targets", func() {
+		Expect(k8sClient.Create(ctx, &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+			Spec: calcv1alpha1.CalculatorSpec{
+				X: 2,
+				Y: 3,
+				Targets: []calcv1alpha1.Target{
+					{APIVersion: "v1", Kind: "ConfigMap", Name: configMapName, Path: ".data.replicas"},
+					{
+						APIVersion: "v1", Kind: "ConfigMap", Name: configMapName,
+						Path: ".metadata.annotations['calc.example.com/expression']", Template: "{{ .X }}+{{ .Y }}",
+					},
+					{APIVersion: "v1", Kind: "ConfigMap", Name: "missing", Path: ".data.replicas"},
+					{APIVersion: "v1", Kind: "Secret", Name: configMapName, Path: ".stringData.replicas"},
+				},
+			},
+		})).To(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(targetRetryInterval))
+
+		By("Checking the fields of the ConfigMap")
+		configMap := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: configMapName, Namespace: namespaceName},
+			configMap)).To(Succeed())
+		Expect(configMap.Data).To(Equal(map[string]string{"owner": "team-a", "replicas": "5"}))
+		Expect(configMap.Annotations).To(HaveKeyWithValue("calc.example.com/expression", "2+3"))
+
+		managers := []string{}
+		for _, entry := range configMap.ManagedFields {
+			managers = append(managers, entry.Manager)
+		}
+
+		Expect(managers).To(ContainElement("calculator-" + calculatorName))
+
+		By("Checking the target statuses")
+		calculator := &calcv1alpha1.Calculator{}
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calculator)).To(Succeed())
+		Expect(calculator.Status.Targets).To(HaveLen(4))
+		Expect(calculator.Status.Targets[0].Synced).To(BeTrue())
+		Expect(calculator.Status.Targets[0].Value).To(Equal("5"))
+		Expect(calculator.Status.Targets[1].Synced).To(BeTrue())
+		Expect(calculator.Status.Targets[2].Reason).To(Equal(reasonNotFound))
+		Expect(calculator.Status.Targets[3].Reason).To(Equal(reasonNotAllowed))
+		Expect(meta.IsStatusConditionFalse(calculator.Status.Conditions,
+			calcv1alpha1.ConditionTargetsSynced)).To(BeTrue())
+
+		By("Removing the targets releases the fields")
+		calculator.Spec.Targets = nil
+		Expect(k8sClient.Update(ctx, calculator)).To(Succeed())
+
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: configMapName, Namespace: namespaceName},
+			configMap)).To(Succeed())
+		Expect(configMap.Data).To(Equal(map[string]string{"owner": "team-a"}))
+
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calculator)).To(Succeed())
+		Expect(calculator.Status.Targets).To(BeEmpty())
+		Expect(meta.FindStatusCondition(calculator.Status.Conditions,
+			calcv1alpha1.ConditionTargetsSynced)).To(BeNil())
+	})
+
+	It("Should not recreate a deleted target when releasing it", func() {
+		Expect(k8sClient.Create(ctx, &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: calculatorName, Namespace: namespaceName},
+			Spec: calcv1alpha1.CalculatorSpec{
+				X: 2,
+				Y: 3,
+				Targets: []calcv1alpha1.Target{
+					{APIVersion: "v1", Kind: "ConfigMap", Name: configMapName, Path: ".data.replicas"},
+				},
+			},
+		})).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		calculator := &calcv1alpha1.Calculator{}
+		Expect(k8sClient.Get(ctx, typeNamespaceName, calculator)).To(Succeed())
+		Expect(calculator.Status.Targets).To(HaveLen(1))
+		Expect(calculator.Status.Targets[0].Synced).To(BeTrue())
+
+		By("Deleting the target, then removing it from the spec")
+		Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: namespaceName},
+		})).To(Succeed())
+
+		calculator.Spec.Targets = nil
+		Expect(k8sClient.Update(ctx, calculator)).To(Succeed())
+
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespaceName})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Get(ctx, types.NamespacedName{Name: configMapName, Namespace: namespaceName},
+			&corev1.ConfigMap{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
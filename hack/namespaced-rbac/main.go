@@ -0,0 +1,206 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command namespaced-rbac turns the generated manager ClusterRole into a Role and a RoleBinding
+// per watched namespace, for operators restricted to a set of namespaces. The cluster scoped resources,
+// which a Role cannot grant, are kept in a ClusterRole and a ClusterRoleBinding.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultClusterScoped lists the cluster scoped resources of the generated manager ClusterRole, such as the
+// reviews of the delegated authentication of the aggregated API.
+const defaultClusterScoped = "namespaces,tokenreviews.authentication.k8s.io,subjectaccessreviews.authorization.k8s.io"
+
+func main() {
+	var (
+		rolePath                string
+		namespaces              string
+		clusterScoped           string
+		namePrefix              string
+		serviceAccount          string
+		serviceAccountNamespace string
+	)
+
+	flag.StringVar(&rolePath, "role", "config/rbac/role.yaml", "The generated manager ClusterRole.")
+	flag.StringVar(&namespaces, "namespaces", "", "Comma-separated list of watched namespaces.")
+	flag.StringVar(&clusterScoped, "cluster-scoped", defaultClusterScoped,
+		"Comma-separated list of the cluster scoped resources of the ClusterRole, as resource.group.")
+	flag.StringVar(&namePrefix, "name-prefix", "kubcalculator-", "The prefix of the generated object names.")
+	flag.StringVar(&serviceAccount, "service-account", "kubcalculator-controller-manager",
+		"The service account of the manager.")
+	flag.StringVar(&serviceAccountNamespace, "service-account-namespace", "kubcalculator-system",
+		"The namespace of the manager service account.")
+	flag.Parse()
+
+	err := run(rolePath, namespaces, parseResources(clusterScoped), namePrefix, serviceAccount,
+		serviceAccountNamespace)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(rolePath, namespaces string, clusterScoped map[string]bool, namePrefix, serviceAccount,
+	serviceAccountNamespace string,
+) error {
+	content, err := os.ReadFile(rolePath)
+	if err != nil {
+		return fmt.Errorf("failed to read cluster role: %w", err)
+	}
+
+	clusterRole := &rbacv1.ClusterRole{}
+
+	if err = yaml.Unmarshal(content, clusterRole); err != nil {
+		return fmt.Errorf("failed to decode cluster role: %w", err)
+	}
+
+	rules, clusterRules := splitRules(clusterRole.Rules, clusterScoped)
+	name := namePrefix + clusterRole.Name
+	subjects := []rbacv1.Subject{{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      serviceAccount,
+		Namespace: serviceAccountNamespace,
+	}}
+
+	var objects []interface{}
+
+	// A Role cannot grant the cluster scoped resources, they keep a ClusterRole named apart from the cluster-wide
+	// manager role.
+	if len(clusterRules) > 0 {
+		clusterName := name + "-cluster-scoped"
+
+		objects = append(objects,
+			&rbacv1.ClusterRole{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+				ObjectMeta: metav1.ObjectMeta{Name: clusterName},
+				Rules:      clusterRules,
+			},
+			&rbacv1.ClusterRoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: clusterName + "-binding"},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterName},
+				Subjects:   subjects,
+			},
+		)
+	}
+
+	for _, namespace := range strings.Split(namespaces, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			continue
+		}
+
+		objects = append(objects,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Rules:      rules,
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: name + "binding", Namespace: namespace},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+				Subjects:   subjects,
+			},
+		)
+	}
+
+	for _, obj := range objects {
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to encode %T: %w", obj, err)
+		}
+
+		fmt.Printf("---\n%s", out)
+	}
+
+	return nil
+}
+
+// parseResources parses a comma-separated list of resource.group, the group of the core resources being empty.
+func parseResources(list string) map[string]bool {
+	resources := make(map[string]bool)
+
+	for _, resource := range strings.Split(list, ",") {
+		if resource = strings.TrimSpace(resource); resource != "" {
+			resources[resource] = true
+		}
+	}
+
+	return resources
+}
+
+// splitRules splits the rules into the rules of the namespaced resources and of the cluster scoped resources.
+func splitRules(rules []rbacv1.PolicyRule, clusterScoped map[string]bool,
+) ([]rbacv1.PolicyRule, []rbacv1.PolicyRule) {
+	namespaced := make([]rbacv1.PolicyRule, 0, len(rules))
+
+	var cluster []rbacv1.PolicyRule
+
+	for _, rule := range rules {
+		var namespacedResources, clusterResources []string
+
+		for _, resource := range rule.Resources {
+			if isClusterScoped(clusterScoped, rule.APIGroups, resource) {
+				clusterResources = append(clusterResources, resource)
+			} else {
+				namespacedResources = append(namespacedResources, resource)
+			}
+		}
+
+		if len(namespacedResources) > 0 {
+			namespacedRule := rule
+			namespacedRule.Resources = namespacedResources
+			namespaced = append(namespaced, namespacedRule)
+		}
+
+		if len(clusterResources) > 0 {
+			clusterRule := rule
+			clusterRule.Resources = clusterResources
+			cluster = append(cluster, clusterRule)
+		}
+	}
+
+	return namespaced, cluster
+}
+
+// isClusterScoped reports whether the resource of one of the groups, or its subresource, is cluster scoped.
+func isClusterScoped(clusterScoped map[string]bool, groups []string, resource string) bool {
+	resource, _, _ = strings.Cut(resource, "/")
+
+	for _, group := range groups {
+		qualified := resource
+		if group != "" {
+			qualified += "." + group
+		}
+
+		if clusterScoped[qualified] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -17,21 +17,34 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	configv1alpha1 "github.com/mykysha/kubCalculator/api/config/v1alpha1"
 	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
 	"github.com/mykysha/kubCalculator/controllers"
+	"github.com/mykysha/kubCalculator/pkg/config"
+	"github.com/mykysha/kubCalculator/pkg/external"
+	"github.com/mykysha/kubCalculator/pkg/httpapi"
 	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/wasm"
+	"github.com/mykysha/kubCalculator/pkg/webhook"
 )
 
 var (
@@ -46,58 +59,183 @@ func init() {
 	//+kubebuilder:scaffold:scheme
 }
 
-const (
-	defaultManagerPort = 9443
-	defaultMetricsAddr = ":8080"
-	defaultProbeAddr   = ":8081"
-)
-
 func main() {
-	var (
-		managerPort          int
-		metricsAddr          string
-		enableLeaderElection bool
-		probeAddr            string
-	)
+	var configFile string
 
-	flag.IntVar(&managerPort, "manager-port", defaultManagerPort, "The port the manager should bind to.")
-	flag.StringVar(&metricsAddr, "metrics-bind-address", defaultMetricsAddr, "The address the metric endpoint binds to.")
-	flag.StringVar(&probeAddr, "health-probe-bind-address", defaultProbeAddr, "The address the probe endpoint binds to.")
-	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
+	flag.StringVar(&configFile, "config", "",
+		"The operator configuration file. Flags set on the command line take precedence over its values.")
+	flag.Int(config.FlagManagerPort, config.DefaultManagerPort, "The port the manager should bind to.")
+	flag.String(config.FlagMetricsAddr, config.DefaultMetricsAddr, "The address the metric endpoint binds to.")
+	flag.String(config.FlagProbeAddr, config.DefaultProbeAddr, "The address the probe endpoint binds to.")
+	flag.String(config.FlagWatchNamespaces, "",
+		"Comma-separated list of namespaces to watch. All namespaces are watched when empty.")
+	flag.Int(config.FlagShardID, 0, "The shard of the replica, when sharding is configured.")
+	flag.Bool(config.FlagLeaderElect, false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 
 	opts := zap.Options{
-		Development: true,
+		Development: config.DefaultDevelopment,
 	}
 
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	operatorConfig, err := config.Load(configFile, flag.CommandLine)
+	if err != nil {
+		ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+		setupLog.Error(err, "unable to load the operator configuration")
+		os.Exit(1)
+	}
+
+	opts.Development = *operatorConfig.Logging.Development
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     metricsAddr,
-		Port:                   managerPort,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "e7da643b.example.com",
-	})
+	options, err := ctrl.Options{Scheme: scheme}.AndFrom(operatorConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to apply the operator configuration")
+		os.Exit(1)
+	}
+
+	shard := config.Shard(operatorConfig)
+	if shard != nil {
+		// Every shard has a leader.
+		setupLog.Info("running a shard", "shard", shard.ID, "shards", shard.Count)
+		options.LeaderElectionID = shard.LeaseName(shard.ID)
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+	restConfig := ctrl.GetConfigOrDie()
+
+	namespaces, err := watchedNamespaces(ctx, restConfig, operatorConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to resolve the watched namespaces")
+		os.Exit(1)
+	}
+
+	switch len(namespaces) {
+	case 0:
+		setupLog.Info("watching all namespaces")
+	case 1:
+		setupLog.Info("watching a single namespace", "namespace", namespaces[0])
+		options.Namespace = namespaces[0]
+	default:
+		setupLog.Info("watching multiple namespaces", "namespaces", namespaces)
+		options.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	calculatorService := &service.CalculatorService{
+		Output: service.OutputOptions{
+			ManagedBy: operatorConfig.Output.ManagedBy,
+			ResultKey: operatorConfig.Output.ResultKey,
+		},
+		Limits:   config.Limits(operatorConfig),
+		Registry: service.NewDefaultRegistry(),
+	}
+
+	// Calculators select the external evaluators by name, the default evaluator replaces the service.
+	var (
+		repository service.Repository = calculatorService
+		validator  webhook.Validator  = calculatorService
+		evaluators                    = make(map[string]service.Repository)
+		validators                    = make(map[string]webhook.Validator)
+	)
+
+	if operatorConfig.Plugins != nil {
+		plugins, err := pluginRepository(ctx, restConfig, operatorConfig, calculatorService)
+		if err != nil {
+			setupLog.Error(err, "unable to load the WebAssembly plugins")
+			os.Exit(1)
+		}
+
+		defer plugins.Close(ctx)
+
+		repository, validator = plugins, plugins
+	}
+
+	for _, evaluatorOptions := range config.Evaluators(operatorConfig) {
+		evaluator, err := external.Dial(evaluatorOptions)
+		if err != nil {
+			setupLog.Error(err, "unable to create evaluator client", "evaluator", evaluatorOptions.Name)
+			os.Exit(1)
+		}
+
+		defer evaluator.Close() //nolint:gocritic // The evaluators live as long as the operator
+
+		evaluators[evaluatorOptions.Name] = evaluator
+		validators[evaluatorOptions.Name] = evaluator
+
+		if evaluatorOptions.Name == operatorConfig.DefaultEvaluator {
+			repository, validator = evaluator, evaluator
+		}
+	}
+
+	if shard != nil {
+		shard.Reader = mgr.GetAPIReader()
+	}
+
 	if err = (&controllers.CalculatorReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr, &service.CalculatorService{}); err != nil {
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Evaluators: evaluators,
+		// The operator also needs the RBAC permissions to get and patch the allowed kinds.
+		AllowedTargets: config.AllowedTargets(operatorConfig),
+		KeyringFile:    config.KeyringFile(operatorConfig),
+		Options:        config.ControllerOptions(operatorConfig),
+		FairQueuing:    operatorConfig.Reconcile.FairQueuing,
+		Sharding:       shard,
+	}).SetupWithManager(mgr, repository); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Calculator")
 		os.Exit(1)
 	}
+
+	if err = (&controllers.CalculatorQuotaReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Sharding: shard,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CalculatorQuota")
+		os.Exit(1)
+	}
+
+	// Serving the webhook requires certificates, disable it to run the operator locally.
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&webhook.CalculatorValidator{
+			Validator:      validator,
+			Evaluators:     validators,
+			AllowedTargets: config.AllowedTargets(operatorConfig),
+			Reader:         mgr.GetClient(),
+			Namespaces:     namespaces,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Calculator")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	if config.APIEnabled(operatorConfig) {
+		if err = mgr.Add(&httpapi.Server{
+			BindAddress:   operatorConfig.API.BindAddress,
+			CertFile:      operatorConfig.API.CertFile,
+			KeyFile:       operatorConfig.API.KeyFile,
+			Repository:    repository,
+			Evaluators:    evaluators,
+			Reader:        mgr.GetClient(),
+			Authenticator: &httpapi.KubernetesAuth{Client: mgr.GetClient()},
+			Authorizer:    &httpapi.KubernetesAuth{Client: mgr.GetClient()},
+		}); err != nil {
+			setupLog.Error(err, "unable to add the REST API")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -110,8 +248,69 @@ func main() {
 
 	setupLog.Info("starting manager")
 
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// watchedNamespaces resolves the configured namespaces with a direct client, as the manager cache
+// depends on the result.
+func watchedNamespaces(ctx context.Context, restConfig *rest.Config, operatorConfig *configv1alpha1.OperatorConfig,
+) ([]string, error) {
+	var reader client.Reader
+
+	if operatorConfig.NamespaceSelector != nil {
+		directClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client: %w", err)
+		}
+
+		reader = directClient
+	}
+
+	namespaces, err := config.WatchedNamespaces(ctx, reader, operatorConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watched namespaces: %w", err)
+	}
+
+	return namespaces, nil
+}
+
+// pluginRepository loads the WebAssembly plugins from their directory or their ConfigMap.
+func pluginRepository(ctx context.Context, restConfig *rest.Config, operatorConfig *configv1alpha1.OperatorConfig,
+	calculatorService *service.CalculatorService,
+) (*wasm.Repository, error) {
+	var modules map[string][]byte
+
+	if ref := operatorConfig.Plugins.ConfigMap; ref != nil {
+		// The cache is not started yet.
+		directClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client: %w", err)
+		}
+
+		configMap := &corev1.ConfigMap{}
+		if err = directClient.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name},
+			configMap); err != nil {
+			return nil, fmt.Errorf("failed to get plugin ConfigMap: %w", err)
+		}
+
+		modules = wasm.FromConfigMap(configMap)
+	} else {
+		var err error
+
+		if modules, err = wasm.ReadDir(operatorConfig.Plugins.Path); err != nil {
+			return nil, fmt.Errorf("failed to read plugins: %w", err)
+		}
+	}
+
+	repository, err := wasm.NewRepository(ctx, *calculatorService, config.Plugins(operatorConfig), modules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugins: %w", err)
+	}
+
+	setupLog.Info("loaded WebAssembly plugins", "modules", len(modules))
+
+	return repository, nil
+}
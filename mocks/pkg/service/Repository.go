@@ -17,13 +17,13 @@ type Repository struct {
 	mock.Mock
 }
 
-// DefineSecret provides a mock function with given fields: ctx, name, namespace, result
-func (_m *Repository) DefineSecret(ctx context.Context, name string, namespace string, result int) (*v1.Secret, error) {
-	ret := _m.Called(ctx, name, namespace, result)
+// DefineSecret provides a mock function with given fields: ctx, name, namespace, value
+func (_m *Repository) DefineSecret(ctx context.Context, name string, namespace string, value string) (*v1.Secret, error) {
+	ret := _m.Called(ctx, name, namespace, value)
 
 	var r0 *v1.Secret
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) *v1.Secret); ok {
-		r0 = rf(ctx, name, namespace, result)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *v1.Secret); ok {
+		r0 = rf(ctx, name, namespace, value)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*v1.Secret)
@@ -31,8 +31,8 @@ func (_m *Repository) DefineSecret(ctx context.Context, name string, namespace s
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
-		r1 = rf(ctx, name, namespace, result)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, name, namespace, value)
 	} else {
 		r1 = ret.Error(1)
 	}
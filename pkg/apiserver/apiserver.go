@@ -0,0 +1,138 @@
+// Package apiserver is an aggregated API server serving the virtual calculations resource of the
+// evaluation.calc.example.com group: creating a Calculation evaluates it and returns the result in the
+// response, nothing is persisted. It also serves the results of the Calculators as external metrics of the
+// external.metrics.k8s.io group, for the HorizontalPodAutoscalers.
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	externalmetricsv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	evaluationv1alpha1 "github.com/mykysha/kubCalculator/api/evaluation/v1alpha1"
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/webhook"
+)
+
+// Name is the name of the API server.
+const Name = "calc-apiserver"
+
+var (
+	// Scheme holds the types served by the API server.
+	Scheme = runtime.NewScheme()
+	// Codecs encode and decode the types of the Scheme.
+	Codecs = serializer.NewCodecFactory(Scheme)
+)
+
+// unversioned is the version of the discovery types and of the statuses.
+var unversioned = schema.GroupVersion{Group: "", Version: "v1"}
+
+func init() {
+	utilruntime.Must(evaluationv1alpha1.AddToScheme(Scheme))
+
+	// The internal version shares the Go types of v1alpha1, so no conversion is needed.
+	Scheme.AddKnownTypes(schema.GroupVersion{
+		Group:   evaluationv1alpha1.GroupVersion.Group,
+		Version: runtime.APIVersionInternal,
+	}, &evaluationv1alpha1.Calculation{})
+
+	utilruntime.Must(externalmetricsv1beta1.AddToScheme(Scheme))
+
+	metav1.AddToGroupVersion(Scheme, unversioned)
+	Scheme.AddUnversionedTypes(unversioned,
+		&metav1.Status{},
+		&metav1.APIVersions{},
+		&metav1.APIGroupList{},
+		&metav1.APIGroup{},
+		&metav1.APIResourceList{},
+	)
+}
+
+// Config is the configuration of the API server.
+type Config struct {
+	GenericConfig *genericapiserver.RecommendedConfig
+	// Repository evaluates the Calculations.
+	Repository service.Repository
+	// Validator validates the Calculations before their evaluation.
+	Validator webhook.Validator
+	// Calculators reads the Calculators whose results are served as external metrics. The
+	// external.metrics.k8s.io API is not served when nil.
+	Calculators client.Reader
+	// Cache is started with the server, when set.
+	Cache cache.Cache
+}
+
+// New creates the API server.
+func (c *Config) New() (*genericapiserver.GenericAPIServer, error) {
+	server, err := c.GenericConfig.Complete().New(Name, genericapiserver.NewEmptyDelegate())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API server: %w", err)
+	}
+
+	apiGroupInfo := genericapiserver.NewDefaultAPIGroupInfo(evaluationv1alpha1.GroupVersion.Group, Scheme,
+		metav1.ParameterCodec, Codecs)
+	apiGroupInfo.VersionedResourcesStorageMap[evaluationv1alpha1.GroupVersion.Version] = map[string]rest.Storage{
+		"calculations": &CalculationREST{Repository: c.Repository, Validator: c.Validator},
+	}
+
+	if err = server.InstallAPIGroup(&apiGroupInfo); err != nil {
+		return nil, fmt.Errorf("failed to install API group: %w", err)
+	}
+
+	if c.Cache != nil {
+		if err = server.AddPostStartHook("start-calculator-cache", startCache(c.Cache)); err != nil {
+			return nil, fmt.Errorf("failed to add cache hook: %w", err)
+		}
+	}
+
+	if c.Calculators != nil {
+		handler := ExternalMetricsHandler(&ExternalMetricsProvider{Reader: c.Calculators})
+		prefix := "/apis/" + externalmetricsv1beta1.SchemeGroupVersion.Group
+
+		server.Handler.NonGoRestfulMux.Handle(prefix, handler)
+		server.Handler.NonGoRestfulMux.HandlePrefix(prefix+"/", handler)
+		server.DiscoveryGroupManager.AddGroup(externalMetricsGroup)
+	}
+
+	return server, nil
+}
+
+// startCache starts the cache and waits for the first sync of the Calculators.
+func startCache(informers cache.Cache) genericapiserver.PostStartHookFunc {
+	return func(hookContext genericapiserver.PostStartHookContext) error {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			<-hookContext.StopCh
+			cancel()
+		}()
+
+		go func() {
+			if err := informers.Start(ctx); err != nil {
+				utilruntime.HandleError(fmt.Errorf("calculator cache stopped: %w", err))
+			}
+		}()
+
+		if _, err := informers.GetInformer(ctx, &calcv1alpha1.Calculator{}); err != nil {
+			return fmt.Errorf("failed to get calculator informer: %w", err)
+		}
+
+		if !informers.WaitForCacheSync(ctx) {
+			return errors.New("failed to sync the calculator cache")
+		}
+
+		return nil
+	}
+}
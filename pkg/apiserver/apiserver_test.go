@@ -0,0 +1,212 @@
+package apiserver_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	genericoptions "k8s.io/apiserver/pkg/server/options"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	evaluationv1alpha1 "github.com/mykysha/kubCalculator/api/evaluation/v1alpha1"
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/apiserver"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+// startServer runs the API server in process, without delegated authentication and authorization: the
+// loopback client is the only one authenticated. The external metrics are served when calculators is set.
+func startServer(t *testing.T, calculators client.Reader) *rest.Config {
+	t.Helper()
+
+	listener, port, err := genericoptions.CreateListener("tcp", "127.0.0.1:0", net.ListenConfig{})
+	require.NoError(t, err)
+
+	options := apiserver.NewOptions()
+	options.RecommendedOptions.SecureServing.BindAddress = net.IPv4(127, 0, 0, 1)
+	options.RecommendedOptions.SecureServing.BindPort = port
+	options.RecommendedOptions.SecureServing.Listener = listener
+	options.RecommendedOptions.SecureServing.ServerCert.CertDirectory = t.TempDir()
+	options.RecommendedOptions.Authentication = nil
+	options.RecommendedOptions.Authorization = nil
+	options.RecommendedOptions.CoreAPI = nil
+
+	config, err := options.Config()
+	require.NoError(t, err)
+
+	config.Repository = &service.CalculatorService{Limits: service.Limits{MaxDigits: 10}}
+	config.Calculators = calculators
+
+	server, err := config.New()
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+
+	t.Cleanup(func() {
+		close(stopCh)
+		<-done
+	})
+
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, server.PrepareRun().Run(stopCh))
+	}()
+
+	restConfig := rest.CopyConfig(server.LoopbackClientConfig)
+
+	httpClient, err := rest.HTTPClientFor(restConfig)
+	require.NoError(t, err)
+
+	// Wait for the server to be healthy.
+	require.NoError(t, wait.PollImmediate(50*time.Millisecond, 30*time.Second, func() (bool, error) {
+		response, err := httpClient.Get(restConfig.Host + "/healthz")
+		if err != nil {
+			return false, nil //nolint:nilerr // The server is starting
+		}
+		defer response.Body.Close()
+
+		return response.StatusCode == http.StatusOK, nil
+	}))
+
+	return restConfig
+}
+
+func newClient(t *testing.T, restConfig *rest.Config) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, evaluationv1alpha1.AddToScheme(scheme))
+
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestCalculations(t *testing.T) { //nolint:funlen // Test function
+	t.Parallel()
+
+	restConfig := startServer(t, nil)
+
+	c := newClient(t, restConfig)
+	precision := int32(2)
+
+	// Test table
+	tests := []struct {
+		name      string
+		spec      evaluationv1alpha1.CalculationSpec
+		want      evaluationv1alpha1.CalculationStatus
+		wantError func(error) bool
+	}{
+		{
+			name: "Default expression",
+			spec: evaluationv1alpha1.CalculationSpec{X: 2, Y: 3},
+			want: evaluationv1alpha1.CalculationStatus{Result: 5, Value: "5"},
+		},
+		{
+			name: "Expression",
+			spec: evaluationv1alpha1.CalculationSpec{X: 2, Y: 10, Expression: "x ^ y"},
+			want: evaluationv1alpha1.CalculationStatus{Result: 1024, Value: "1024"},
+		},
+		{
+			name: "Rational mode",
+			spec: evaluationv1alpha1.CalculationSpec{
+				X: 1, Y: 3, Expression: "x / y", Mode: calcv1alpha1.ModeRational,
+				Output: &evaluationv1alpha1.CalculationOutput{Precision: &precision},
+			},
+			want: evaluationv1alpha1.CalculationStatus{Value: "1/3", Decimal: "0.33"},
+		},
+		{
+			name: "Variables",
+			spec: evaluationv1alpha1.CalculationSpec{
+				Expression: "abs(z) + x",
+				Variables:  []calcv1alpha1.Variable{{Name: "z", Type: calcv1alpha1.VariableTypeComplex, Value: "3+4i"}},
+			},
+			want: evaluationv1alpha1.CalculationStatus{Value: "5"},
+		},
+		{
+			name:      "Unsupported mode",
+			spec:      evaluationv1alpha1.CalculationSpec{Mode: "Octal"},
+			wantError: apierrors.IsInvalid,
+		},
+		{
+			name:      "Invalid variable",
+			spec:      evaluationv1alpha1.CalculationSpec{Variables: []calcv1alpha1.Variable{{Name: "x"}}},
+			wantError: apierrors.IsInvalid,
+		},
+		{
+			name:      "Invalid expression",
+			spec:      evaluationv1alpha1.CalculationSpec{Expression: "x +"},
+			wantError: apierrors.IsInvalid,
+		},
+		{
+			name:      "Limit exceeded",
+			spec:      evaluationv1alpha1.CalculationSpec{X: 10, Y: 20, Expression: "x ^ y"},
+			wantError: apierrors.IsInvalid,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calculation := &evaluationv1alpha1.Calculation{
+				ObjectMeta: metav1.ObjectMeta{Name: "calculation", Namespace: "default"},
+				Spec:       tt.spec,
+			}
+
+			err := c.Create(context.Background(), calculation)
+			if tt.wantError != nil {
+				assert.True(t, tt.wantError(err), "unexpected error %v", err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, calculation.Status)
+		})
+	}
+}
+
+func TestCalculationsAreNotStored(t *testing.T) {
+	t.Parallel()
+
+	restConfig := startServer(t, nil)
+
+	c := newClient(t, restConfig)
+
+	calculation := &evaluationv1alpha1.Calculation{
+		ObjectMeta: metav1.ObjectMeta{Name: "calculation", Namespace: "default"},
+	}
+
+	require.NoError(t, c.Create(context.Background(), calculation))
+
+	err := c.Get(context.Background(), client.ObjectKeyFromObject(calculation), calculation)
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+
+	// Discovery only advertises create.
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	require.NoError(t, err)
+
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(evaluationv1alpha1.GroupVersion.String())
+	require.NoError(t, err)
+	require.Len(t, resources.APIResources, 1)
+	assert.Equal(t, "calculations", resources.APIResources[0].Name)
+	assert.True(t, resources.APIResources[0].Namespaced)
+	assert.Equal(t, metav1.Verbs{"create"}, resources.APIResources[0].Verbs)
+}
@@ -0,0 +1,124 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	evaluationv1alpha1 "github.com/mykysha/kubCalculator/api/evaluation/v1alpha1"
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/webhook"
+)
+
+// CalculationREST evaluates the created Calculations. It only supports create, nothing is stored.
+type CalculationREST struct {
+	Repository service.Repository
+	Validator  webhook.Validator
+}
+
+var (
+	_ rest.Creater            = &CalculationREST{}
+	_ rest.Scoper             = &CalculationREST{}
+	_ rest.Storage            = &CalculationREST{}
+	_ rest.KindProvider       = &CalculationREST{}
+	_ rest.ShortNamesProvider = &CalculationREST{}
+)
+
+// New returns an empty Calculation.
+func (r *CalculationREST) New() runtime.Object {
+	return &evaluationv1alpha1.Calculation{}
+}
+
+// Destroy has nothing to clean up.
+func (r *CalculationREST) Destroy() {}
+
+// NamespaceScoped is true, the creation of Calculations is authorized per namespace.
+func (r *CalculationREST) NamespaceScoped() bool {
+	return true
+}
+
+// Kind is Calculation.
+func (r *CalculationREST) Kind() string {
+	return "Calculation"
+}
+
+// ShortNames are the short names of calculations.
+func (r *CalculationREST) ShortNames() []string {
+	return []string{"calc"}
+}
+
+// Create evaluates the Calculation and returns it with its status.
+func (r *CalculationREST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc,
+	_ *metav1.CreateOptions,
+) (runtime.Object, error) {
+	calculation, ok := obj.(*evaluationv1alpha1.Calculation)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("not a Calculation: %T", obj))
+	}
+
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	calc := &calcv1alpha1.Calculator{
+		ObjectMeta: calculation.ObjectMeta,
+		Spec: calcv1alpha1.CalculatorSpec{
+			X:          calculation.Spec.X,
+			Y:          calculation.Spec.Y,
+			Expression: calculation.Spec.Expression,
+			Variables:  calculation.Spec.Variables,
+			Mode:       calculation.Spec.Mode,
+		},
+	}
+
+	if output := calculation.Spec.Output; output != nil {
+		calc.Spec.Output = &calcv1alpha1.OutputSpec{
+			Precision:     output.Precision,
+			ComplexFormat: output.ComplexFormat,
+			TimeZone:      output.TimeZone,
+		}
+	}
+
+	// The schema of the Calculators does not apply to the Calculations.
+	if errs := service.ValidateSchema(&calc.Spec); len(errs) > 0 {
+		return nil, invalid(calculation, errs)
+	}
+
+	if errs := r.Validator.ValidateCalculator(calc); len(errs) > 0 {
+		return nil, invalid(calculation, errs)
+	}
+
+	err := r.Repository.ProcessCalculator(ctx, calc)
+
+	switch {
+	case service.IsLimitExceeded(err):
+		return nil, invalid(calculation, field.ErrorList{field.Forbidden(field.NewPath("spec"), err.Error())})
+	case service.IsInvalid(err):
+		return nil, invalid(calculation, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "expression"), calculation.Spec.Expression, err.Error()),
+		})
+	case err != nil:
+		return nil, apierrors.NewInternalError(err)
+	}
+
+	calculation.Status = evaluationv1alpha1.CalculationStatus{
+		Result:  calc.Status.Result,
+		Value:   calc.Status.Value,
+		Decimal: calc.Status.Decimal,
+	}
+
+	return calculation, nil
+}
+
+func invalid(calculation *evaluationv1alpha1.Calculation, errs field.ErrorList) error {
+	return apierrors.NewInvalid(evaluationv1alpha1.GroupVersion.WithKind("Calculation").GroupKind(),
+		calculation.Name, errs)
+}
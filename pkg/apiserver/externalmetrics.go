@@ -0,0 +1,190 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	externalmetricsv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+)
+
+const (
+	// ResultMetric is the external metric serving the results of the Calculators.
+	ResultMetric = "calculator-result"
+	// CalculatorLabel is the metric label holding the name of the Calculator of a value.
+	CalculatorLabel = "calc.example.com/calculator"
+)
+
+// ExternalMetricsProvider serves the results of the Calculators as the ResultMetric external metric. A value
+// is served for every Calculator matching the metric selector with a result, the labels of the Calculator
+// being the labels of the value.
+type ExternalMetricsProvider struct {
+	// Reader reads the Calculators, usually from a cache.
+	Reader client.Reader
+	// Now returns the timestamp of the values. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// ListAllExternalMetrics returns the names of the served metrics.
+func (p *ExternalMetricsProvider) ListAllExternalMetrics() []string {
+	return []string{ResultMetric}
+}
+
+// GetExternalMetric returns the values of the metric in the namespace.
+func (p *ExternalMetricsProvider) GetExternalMetric(ctx context.Context, namespace, name string,
+	selector labels.Selector,
+) (*externalmetricsv1beta1.ExternalMetricValueList, error) {
+	if name != ResultMetric {
+		return nil, apierrors.NewNotFound(externalmetricsv1beta1.SchemeGroupVersion.WithResource(name).GroupResource(),
+			namespace)
+	}
+
+	calculators := &calcv1alpha1.CalculatorList{}
+	if err := p.Reader.List(ctx, calculators, client.InNamespace(namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list calculators: %w", err)
+	}
+
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+
+	values := &externalmetricsv1beta1.ExternalMetricValueList{Items: []externalmetricsv1beta1.ExternalMetricValue{}}
+
+	for i := range calculators.Items {
+		calc := &calculators.Items[i]
+
+		if !calc.Status.Processed {
+			continue
+		}
+
+		// Results that are not numbers are not metrics.
+		value, err := resource.ParseQuantity(calc.Status.Value)
+		if err != nil {
+			continue
+		}
+
+		metricLabels := make(map[string]string, len(calc.Labels)+1)
+		for key, label := range calc.Labels {
+			metricLabels[key] = label
+		}
+
+		metricLabels[CalculatorLabel] = calc.Name
+
+		values.Items = append(values.Items, externalmetricsv1beta1.ExternalMetricValue{
+			MetricName:   name,
+			MetricLabels: metricLabels,
+			Timestamp:    metav1.NewTime(now()),
+			Value:        value,
+		})
+	}
+
+	return values, nil
+}
+
+// externalMetricsHandler serves the external.metrics.k8s.io API:
+//
+//	GET /apis/external.metrics.k8s.io                                          the API group
+//	GET /apis/external.metrics.k8s.io/v1beta1                                  the metrics
+//	GET /apis/external.metrics.k8s.io/v1beta1/namespaces/{namespace}/{metric}  the values, by labelSelector
+type externalMetricsHandler struct {
+	provider *ExternalMetricsProvider
+}
+
+// ExternalMetricsHandler returns the handler of the external.metrics.k8s.io API. It does not authenticate nor
+// authorize the requests, the filters of the API server do.
+func ExternalMetricsHandler(provider *ExternalMetricsProvider) http.Handler {
+	return &externalMetricsHandler{provider: provider}
+}
+
+var externalMetricsGroup = metav1.APIGroup{
+	Name: externalmetricsv1beta1.SchemeGroupVersion.Group,
+	Versions: []metav1.GroupVersionForDiscovery{{
+		GroupVersion: externalmetricsv1beta1.SchemeGroupVersion.String(),
+		Version:      externalmetricsv1beta1.SchemeGroupVersion.Version,
+	}},
+	PreferredVersion: metav1.GroupVersionForDiscovery{
+		GroupVersion: externalmetricsv1beta1.SchemeGroupVersion.String(),
+		Version:      externalmetricsv1beta1.SchemeGroupVersion.Version,
+	},
+}
+
+func (h *externalMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	groupVersion := externalmetricsv1beta1.SchemeGroupVersion
+
+	if r.Method != http.MethodGet {
+		responsewriters.ErrorNegotiated(apierrors.NewMethodNotSupported(groupVersion.WithResource("").GroupResource(),
+			r.Method), Codecs, groupVersion, w, r)
+
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/apis/"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == groupVersion.Group:
+		group := externalMetricsGroup
+		responsewriters.WriteObjectNegotiated(Codecs, negotiation.DefaultEndpointRestrictions,
+			unversioned, w, r, http.StatusOK, &group)
+	case path == groupVersion.String():
+		responsewriters.WriteObjectNegotiated(Codecs, negotiation.DefaultEndpointRestrictions,
+			unversioned, w, r, http.StatusOK, h.resources())
+	case len(parts) == 5 && parts[0]+"/"+parts[1] == groupVersion.String() && parts[2] == "namespaces" &&
+		parts[3] != "" && parts[4] != "":
+		h.values(w, r, parts[3], parts[4])
+	default:
+		responsewriters.ErrorNegotiated(apierrors.NewGenericServerResponse(http.StatusNotFound, r.Method,
+			groupVersion.WithResource("").GroupResource(), "", fmt.Sprintf("path %s not found", r.URL.Path), 0,
+			false), Codecs, groupVersion, w, r)
+	}
+}
+
+func (h *externalMetricsHandler) resources() *metav1.APIResourceList {
+	resources := &metav1.APIResourceList{GroupVersion: externalmetricsv1beta1.SchemeGroupVersion.String()}
+
+	for _, metric := range h.provider.ListAllExternalMetrics() {
+		resources.APIResources = append(resources.APIResources, metav1.APIResource{
+			Name:       metric,
+			Namespaced: true,
+			Kind:       "ExternalMetricValueList",
+			Verbs:      metav1.Verbs{"get"},
+		})
+	}
+
+	return resources
+}
+
+func (h *externalMetricsHandler) values(w http.ResponseWriter, r *http.Request, namespace, metric string) {
+	groupVersion := externalmetricsv1beta1.SchemeGroupVersion
+
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("invalid label selector: %v", err)),
+			Codecs, groupVersion, w, r)
+
+		return
+	}
+
+	values, err := h.provider.GetExternalMetric(r.Context(), namespace, metric, selector)
+	if err != nil {
+		responsewriters.ErrorNegotiated(err, Codecs, groupVersion, w, r)
+
+		return
+	}
+
+	responsewriters.WriteObjectNegotiated(Codecs, negotiation.DefaultEndpointRestrictions, groupVersion, w, r,
+		http.StatusOK, values)
+}
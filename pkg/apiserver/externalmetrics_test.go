@@ -0,0 +1,205 @@
+package apiserver_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	externalmetricsv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	externalmetrics "k8s.io/metrics/pkg/client/external_metrics"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/apiserver"
+)
+
+var timestamp = time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+
+// newCache returns a fake cache holding Calculators of two apps.
+func newCache(t *testing.T) client.Reader {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, calcv1alpha1.AddToScheme(scheme))
+
+	calculator := func(name string, app string, status calcv1alpha1.CalculatorStatus) *calcv1alpha1.Calculator {
+		return &calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Status:     status,
+		}
+	}
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		calculator("web-replicas", "web", calcv1alpha1.CalculatorStatus{Processed: true, Result: 12, Value: "12"}),
+		calculator("web-pending", "web", calcv1alpha1.CalculatorStatus{}),
+		calculator("worker-replicas", "worker", calcv1alpha1.CalculatorStatus{
+			Processed: true,
+			Value:     "123456789012345678901234567890",
+		}),
+	).Build()
+}
+
+func TestExternalMetricsProvider(t *testing.T) { //nolint:funlen // Test function
+	t.Parallel()
+
+	provider := &apiserver.ExternalMetricsProvider{
+		Reader: newCache(t),
+		Now:    func() time.Time { return timestamp },
+	}
+
+	// Test table
+	tests := []struct {
+		name      string
+		namespace string
+		metric    string
+		selector  string
+		want      []externalmetricsv1beta1.ExternalMetricValue
+		wantError func(error) bool
+	}{
+		{
+			name:      "Select by labels",
+			namespace: "default",
+			metric:    apiserver.ResultMetric,
+			selector:  "app=web",
+			want: []externalmetricsv1beta1.ExternalMetricValue{{
+				MetricName:   apiserver.ResultMetric,
+				MetricLabels: map[string]string{"app": "web", apiserver.CalculatorLabel: "web-replicas"},
+				Timestamp:    metav1.NewTime(timestamp),
+				Value:        resource.MustParse("12"),
+			}},
+		},
+		{
+			name:      "Big results",
+			namespace: "default",
+			metric:    apiserver.ResultMetric,
+			selector:  "app=worker",
+			want: []externalmetricsv1beta1.ExternalMetricValue{{
+				MetricName:   apiserver.ResultMetric,
+				MetricLabels: map[string]string{"app": "worker", apiserver.CalculatorLabel: "worker-replicas"},
+				Timestamp:    metav1.NewTime(timestamp),
+				Value:        resource.MustParse("123456789012345678901234567890"),
+			}},
+		},
+		{
+			name:      "No match",
+			namespace: "default",
+			metric:    apiserver.ResultMetric,
+			selector:  "app=db",
+			want:      []externalmetricsv1beta1.ExternalMetricValue{},
+		},
+		{
+			name:      "Other namespace",
+			namespace: "other",
+			metric:    apiserver.ResultMetric,
+			want:      []externalmetricsv1beta1.ExternalMetricValue{},
+		},
+		{
+			name:      "Unknown metric",
+			namespace: "default",
+			metric:    "queue-length",
+			wantError: apierrors.IsNotFound,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			selector, err := labels.Parse(tt.selector)
+			require.NoError(t, err)
+
+			got, err := provider.GetExternalMetric(context.Background(), tt.namespace, tt.metric, selector)
+			if tt.wantError != nil {
+				assert.True(t, tt.wantError(err), "unexpected error %v", err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, got.Items, len(tt.want))
+
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].MetricLabels, got.Items[i].MetricLabels)
+				assert.True(t, tt.want[i].Timestamp.Equal(&got.Items[i].Timestamp))
+				assert.Zero(t, tt.want[i].Value.Cmp(got.Items[i].Value), "value %s", got.Items[i].Value.String())
+			}
+		})
+	}
+}
+
+func TestExternalMetricsHandler(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(apiserver.ExternalMetricsHandler(&apiserver.ExternalMetricsProvider{
+		Reader: newCache(t),
+	}))
+	t.Cleanup(server.Close)
+
+	restConfig := &rest.Config{Host: server.URL}
+
+	// Discovery lists the metrics.
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	require.NoError(t, err)
+
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(
+		externalmetricsv1beta1.SchemeGroupVersion.String())
+	require.NoError(t, err)
+	require.Len(t, resources.APIResources, 1)
+	assert.Equal(t, apiserver.ResultMetric, resources.APIResources[0].Name)
+	assert.True(t, resources.APIResources[0].Namespaced)
+
+	// The HPA client reads the values.
+	metricsClient, err := externalmetrics.NewForConfig(restConfig)
+	require.NoError(t, err)
+
+	values, err := metricsClient.NamespacedMetrics("default").List(apiserver.ResultMetric,
+		labels.SelectorFromSet(labels.Set{"app": "web"}))
+	require.NoError(t, err)
+	require.Len(t, values.Items, 1)
+	assert.Equal(t, int64(12), values.Items[0].Value.Value())
+	assert.Equal(t, "web-replicas", values.Items[0].MetricLabels[apiserver.CalculatorLabel])
+
+	_, err = metricsClient.NamespacedMetrics("default").List("queue-length", labels.Everything())
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+}
+
+func TestExternalMetricsAPI(t *testing.T) {
+	t.Parallel()
+
+	restConfig := startServer(t, newCache(t))
+
+	// The group is advertised next to the evaluation group.
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	require.NoError(t, err)
+
+	groups, err := discoveryClient.ServerGroups()
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(groups.Groups))
+	for _, group := range groups.Groups {
+		names = append(names, group.Name)
+	}
+
+	assert.Contains(t, names, externalmetricsv1beta1.SchemeGroupVersion.Group)
+
+	metricsClient, err := externalmetrics.NewForConfig(restConfig)
+	require.NoError(t, err)
+
+	values, err := metricsClient.NamespacedMetrics("default").List(apiserver.ResultMetric, labels.Everything())
+	require.NoError(t, err)
+	assert.Len(t, values.Items, 2)
+}
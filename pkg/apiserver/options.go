@@ -0,0 +1,96 @@
+package apiserver
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	genericoptions "k8s.io/apiserver/pkg/server/options"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	evaluationv1alpha1 "github.com/mykysha/kubCalculator/api/evaluation/v1alpha1"
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/config"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+// Options are the command line options of the API server.
+type Options struct {
+	RecommendedOptions *genericoptions.RecommendedOptions
+	// ConfigFile is the operator configuration file, the limits and the output options are read from it.
+	ConfigFile string
+}
+
+// NewOptions returns the default options. Nothing is stored, so there is neither etcd nor admission.
+func NewOptions() *Options {
+	recommended := genericoptions.NewRecommendedOptions("", Codecs.LegacyCodec(evaluationv1alpha1.GroupVersion))
+	recommended.Etcd = nil
+	recommended.Admission = nil
+
+	return &Options{RecommendedOptions: recommended}
+}
+
+// AddFlags adds the options to the flag set.
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	o.RecommendedOptions.AddFlags(fs)
+
+	fs.StringVar(&o.ConfigFile, "config", o.ConfigFile,
+		"The operator configuration file the limits of the calculations are read from.")
+}
+
+// Validate validates the options.
+func (o *Options) Validate() error {
+	return utilerrors.NewAggregate(o.RecommendedOptions.Validate())
+}
+
+// Config builds the API server configuration, evaluating the Calculations in process.
+func (o *Options) Config() (*Config, error) {
+	if err := o.RecommendedOptions.SecureServing.MaybeDefaultWithSelfSignedCerts("localhost", nil,
+		[]net.IP{net.ParseIP("127.0.0.1")}); err != nil {
+		return nil, fmt.Errorf("failed to create self-signed certificates: %w", err)
+	}
+
+	operatorConfig, err := config.Load(o.ConfigFile, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the operator configuration: %w", err)
+	}
+
+	genericConfig := genericapiserver.NewRecommendedConfig(Codecs)
+	if err = o.RecommendedOptions.ApplyTo(genericConfig); err != nil {
+		return nil, fmt.Errorf("failed to apply options: %w", err)
+	}
+
+	calculatorService := &service.CalculatorService{
+		Output: service.OutputOptions{
+			ManagedBy: operatorConfig.Output.ManagedBy,
+			ResultKey: operatorConfig.Output.ResultKey,
+		},
+		Limits:   config.Limits(operatorConfig),
+		Registry: service.NewDefaultRegistry(),
+	}
+
+	apiserverConfig := &Config{
+		GenericConfig: genericConfig,
+		Repository:    calculatorService,
+		Validator:     calculatorService,
+	}
+
+	// The external metrics are read from the cluster, they need a client of the API server.
+	if genericConfig.ClientConfig != nil {
+		calculatorScheme := runtime.NewScheme()
+		utilruntime.Must(calcv1alpha1.AddToScheme(calculatorScheme))
+
+		calculators, err := cache.New(genericConfig.ClientConfig, cache.Options{Scheme: calculatorScheme})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create calculator cache: %w", err)
+		}
+
+		apiserverConfig.Calculators, apiserverConfig.Cache = calculators, calculators
+	}
+
+	return apiserverConfig, nil
+}
@@ -0,0 +1,107 @@
+// Package cli implements the kubectl-calc plugin.
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(calcv1alpha1.AddToScheme(scheme))
+}
+
+// Options are the dependencies of the commands.
+type Options struct {
+	genericclioptions.IOStreams
+
+	// ConfigFlags are the standard kubectl flags selecting the cluster and the namespace.
+	ConfigFlags *genericclioptions.ConfigFlags
+	// Registry documents the operations and evaluates the local calculations. Defaults to the builtin operations.
+	Registry *service.Registry
+	// Client is the client of the cluster, created from ConfigFlags when nil.
+	Client client.Client
+}
+
+// NewOptions returns the options of the standard streams.
+func NewOptions(streams genericclioptions.IOStreams) *Options {
+	return &Options{
+		IOStreams:   streams,
+		ConfigFlags: genericclioptions.NewConfigFlags(true),
+		Registry:    service.NewDefaultRegistry(),
+	}
+}
+
+// NewCommand returns the root command of the plugin.
+func NewCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "kubectl calc",
+		Short:         "Create, inspect and evaluate Calculators",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.SetIn(o.In)
+	cmd.SetOut(o.Out)
+	cmd.SetErr(o.ErrOut)
+
+	o.ConfigFlags.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(
+		newCreateCommand(o),
+		newDecryptCommand(o),
+		newGetCommand(o),
+		newExplainCommand(o),
+		newFunctionsCommand(o),
+		newHistoryCommand(o),
+		newValidateCommand(o),
+	)
+
+	return cmd
+}
+
+func (o *Options) client() (client.Client, error) {
+	if o.Client != nil {
+		return o.Client, nil
+	}
+
+	restConfig, err := o.ConfigFlags.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	o.Client, err = client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return o.Client, nil
+}
+
+func (o *Options) namespace() (string, error) {
+	namespace, _, err := o.ConfigFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return "", fmt.Errorf("failed to get namespace: %w", err)
+	}
+
+	return namespace, nil
+}
+
+func (o *Options) registry() *service.Registry {
+	if o.Registry == nil {
+		o.Registry = service.NewDefaultRegistry()
+	}
+
+	return o.Registry
+}
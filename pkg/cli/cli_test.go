@@ -0,0 +1,306 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/cli"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+func init() {
+	cli.PollInterval = time.Millisecond
+}
+
+func newClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, calcv1alpha1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+// run runs the plugin with the arguments in the default namespace and returns its output.
+func run(t *testing.T, cl client.Client, args ...string) (string, error) {
+	t.Helper()
+
+	return execute(t, cl, "", args...)
+}
+
+// runWithInput runs the plugin offline with the standard input.
+func runWithInput(t *testing.T, in string, args ...string) (string, error) {
+	t.Helper()
+
+	return execute(t, nil, in, args...)
+}
+
+func execute(t *testing.T, cl client.Client, in string, args ...string) (string, error) {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	o := cli.NewOptions(genericclioptions.IOStreams{In: bytes.NewBufferString(in), Out: out, ErrOut: out})
+	o.Client = cl
+
+	cmd := cli.NewCommand(o)
+	cmd.SetArgs(append([]string{"--namespace", "default"}, args...))
+
+	err := cmd.ExecuteContext(context.Background())
+
+	return out.String(), err
+}
+
+func TestCreateLocal(t *testing.T) {
+	t.Parallel()
+
+	out, err := run(t, nil, "create", "--local", "--x", "2", "--y", "10", "--expression", "x ^ y")
+	assert.NoError(t, err)
+	assert.Equal(t, "1024\n", out)
+
+	_, err = run(t, nil, "create", "--local", "--expression", "x / 0")
+	assert.ErrorContains(t, err, "division by zero")
+
+	out, err = run(t, nil, "create", "--local", "--mode", "Rational", "--x", "1", "--y", "3", "--expression", "x / y")
+	assert.NoError(t, err)
+	assert.Equal(t, "1/3\n", out)
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	cl := newClient(t)
+
+	out, err := run(t, cl, "create", "sum", "--x", "1", "--y", "2", "--evaluator", "remote")
+	assert.NoError(t, err)
+	assert.Equal(t, "calculator.calc.example.com/sum created\n", out)
+
+	calc := &calcv1alpha1.Calculator{}
+	require.NoError(t, cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "sum"}, calc))
+	assert.Equal(t, calcv1alpha1.CalculatorSpec{
+		X: 1, Y: 2, EvaluatorRef: &calcv1alpha1.EvaluatorReference{Name: "remote"},
+	}, calc.Spec)
+
+	_, err = run(t, cl, "create")
+	assert.Error(t, err)
+}
+
+func TestCreateWait(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name    string
+		process func(calc *calcv1alpha1.Calculator)
+		want    string
+		wantErr string
+	}{
+		{
+			name: "Processed",
+			process: func(calc *calcv1alpha1.Calculator) {
+				calc.Status.Processed = true
+				calc.Status.Value = "3"
+			},
+			want: "calculator.calc.example.com/sum created\nx + y = 3\n",
+		},
+		{
+			name: "Invalid",
+			process: func(calc *calcv1alpha1.Calculator) {
+				meta.SetStatusCondition(&calc.Status.Conditions, metav1.Condition{
+					Type: calcv1alpha1.ConditionInvalid, Status: metav1.ConditionTrue, Reason: "InvalidExpression",
+					Message: "division by zero",
+				})
+			},
+			wantErr: "calculator was not processed: Invalid: division by zero",
+		},
+		{
+			name:    "Timeout",
+			process: func(calc *calcv1alpha1.Calculator) {},
+			wantErr: "calculator was not processed within 50ms",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cl := newClient(t)
+			done := make(chan struct{})
+
+			// Stands in for the operator.
+			go func() {
+				defer close(done)
+
+				calc := &calcv1alpha1.Calculator{}
+				key := client.ObjectKey{Namespace: "default", Name: "sum"}
+
+				for cl.Get(context.Background(), key, calc) != nil {
+					time.Sleep(time.Millisecond)
+				}
+
+				tt.process(calc)
+				assert.NoError(t, cl.Status().Update(context.Background(), calc))
+			}()
+
+			out, err := run(t, cl, "create", "sum", "--x", "1", "--y", "2", "--wait", "--timeout", "50ms")
+			<-done
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out)
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	cl := newClient(t,
+		&calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "sum", Namespace: "default"},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 1, Y: 2},
+			Status:     calcv1alpha1.CalculatorStatus{Processed: true, Result: 3, Value: "3"},
+		},
+		&calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "zero", Namespace: "default"},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 1, Expression: "x / y"},
+			Status: calcv1alpha1.CalculatorStatus{Conditions: []metav1.Condition{
+				{Type: calcv1alpha1.ConditionInvalid, Status: metav1.ConditionTrue},
+				{Type: calcv1alpha1.ConditionPaused, Status: metav1.ConditionFalse},
+			}},
+		},
+		&calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 1, Y: 2},
+			Status:     calcv1alpha1.CalculatorStatus{Processed: true, Checksum: "5f0c"},
+		},
+		&calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}},
+	)
+
+	out, err := run(t, cl, "get")
+	assert.NoError(t, err)
+	assert.Equal(t, `NAME     X   Y   EXPRESSION   VALUE         CONDITIONS
+secret   1   2   <none>       <encrypted>   <none>
+sum      1   2   <none>       3             <none>
+zero     1   0   x / y        <none>        Invalid
+`, out)
+
+	out, err = run(t, cl, "get", "sum", "-o", "yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "value: \"3\"")
+
+	_, err = run(t, cl, "get", "missing")
+	assert.Error(t, err)
+
+	_, err = run(t, cl, "get", "-o", "wide")
+	assert.Error(t, err)
+}
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	out, err := run(t, nil, "explain", "add")
+	assert.NoError(t, err)
+	assert.Equal(t, `NAME:        add
+SYMBOL:      +
+SIGNATURES:
+  add(int, int...) int
+  add(rational, rational...) rational
+  add(real, real...) real
+  add(complex, complex...) complex
+  add(quantity, quantity...) quantity
+  add(quantity, rational) quantity
+  add(rational, quantity) quantity
+  add(measure, measure...) measure
+  add(duration, duration...) duration
+  add(timestamp, duration) timestamp
+  add(duration, timestamp) timestamp
+DESCRIPTION:
+  Sum of the arguments.
+`, out)
+
+	// The - symbol is bound to the subtraction and to the negation.
+	out, err = run(t, nil, "explain", "--", "-")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "NAME:        neg")
+	assert.Contains(t, out, "NAME:        sub")
+
+	_, err = run(t, nil, "explain", "max")
+	assert.ErrorContains(t, err, "available operations: abs, acos, add")
+
+	out, err = run(t, nil, "explain", "sqrt")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "DOMAIN:\n  a real argument must be non-negative\n")
+}
+
+func TestFunctions(t *testing.T) {
+	t.Parallel()
+
+	out, err := run(t, nil, "functions")
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Regexp(t, `^NAME +SYMBOL +ARITY +DESCRIPTION +DOMAIN$`, lines[0])
+	assert.Len(t, lines, len(service.NewDefaultRegistry().Evaluators())+1)
+	assert.Regexp(t, `(?m)^add +\+ +2\+ +Sum of the arguments\. +any$`, out)
+	assert.Regexp(t, `(?m)^factorial +<none> +1 +Product .+ the argument must be between 0 and 100000$`, out)
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	event := func(name, object, reason string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{
+				Kind: "Calculator", APIVersion: calcv1alpha1.GroupVersion.String(), Name: object, Namespace: "default",
+			},
+			Type:          corev1.EventTypeNormal,
+			Reason:        reason,
+			Message:       reason + " " + object,
+			Count:         1,
+			LastTimestamp: metav1.NewTime(at),
+		}
+	}
+
+	at := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	cl := newClient(t,
+		event("second", "sum", "Calculated", at.Add(time.Minute)),
+		event("first", "sum", "Calculated", at),
+		event("other", "other", "Calculated", at),
+	)
+
+	out, err := run(t, cl, "history", "sum")
+	assert.NoError(t, err)
+	assert.Equal(t, `LAST SEEN              TYPE     REASON       COUNT   MESSAGE
+2022-11-01T12:00:00Z   Normal   Calculated   1       Calculated sum
+2022-11-01T12:01:00Z   Normal   Calculated   1       Calculated sum
+`, out)
+
+	out, err = run(t, cl, "history", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, "No history found for calculator default/missing.\n", out)
+}
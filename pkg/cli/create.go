@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+// DefaultWaitTimeout is the default duration create --wait waits for the result.
+const DefaultWaitTimeout = 30 * time.Second
+
+// PollInterval is the interval between two reads of a Calculator waited for.
+var PollInterval = 500 * time.Millisecond
+
+// ErrNotProcessed is returned when the operator did not produce a result.
+var ErrNotProcessed = errors.New("calculator was not processed")
+
+type createOptions struct {
+	*Options
+
+	spec    calcv1alpha1.CalculatorSpec
+	wait    bool
+	timeout time.Duration
+	local   bool
+}
+
+func newCreateCommand(o *Options) *cobra.Command {
+	c := &createOptions{Options: o}
+
+	var evaluator string
+
+	cmd := &cobra.Command{
+		Use:   "create NAME --x X --y Y",
+		Short: "Create a Calculator",
+		Example: `  # Create a Calculator adding 1 and 2 and wait for its result
+  kubectl calc create sum --x 1 --y 2 --wait
+
+  # Evaluate an expression locally, without a cluster
+  kubectl calc create --local --x 2 --y 10 --expression "x ^ y"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if evaluator != "" {
+				c.spec.EvaluatorRef = &calcv1alpha1.EvaluatorReference{Name: evaluator}
+			}
+
+			if c.local {
+				return c.runLocal(cmd.Context())
+			}
+
+			if len(args) == 0 {
+				return errors.New("a name is required") //nolint:goerr113 // Usage error
+			}
+
+			return c.run(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().IntVar(&c.spec.X, "x", 0, "The x variable of the expression.")
+	cmd.Flags().IntVar(&c.spec.Y, "y", 0, "The y variable of the expression.")
+	cmd.Flags().StringVar(&c.spec.Expression, "expression", "",
+		fmt.Sprintf("The expression, defaults to %q.", service.DefaultExpression))
+	cmd.Flags().StringVar((*string)(&c.spec.Mode), "mode", "", "The number system, Integer, Rational, Real or Complex.")
+	cmd.Flags().StringVar(&evaluator, "evaluator", "", "The external evaluator of the operator configuration.")
+	cmd.Flags().BoolVar(&c.wait, "wait", false, "Wait for the result.")
+	cmd.Flags().DurationVar(&c.timeout, "timeout", DefaultWaitTimeout, "The maximum duration to wait for the result.")
+	cmd.Flags().BoolVar(&c.local, "local", false, "Evaluate locally with the builtin operations, without a cluster.")
+
+	return cmd
+}
+
+func (c *createOptions) runLocal(ctx context.Context) error {
+	if c.spec.EvaluatorRef != nil {
+		return errors.New("external evaluators are not available locally") //nolint:goerr113 // Usage error
+	}
+
+	calc := &calcv1alpha1.Calculator{Spec: c.spec}
+
+	calculatorService := service.CalculatorService{Limits: service.DefaultLimits(), Registry: c.registry()}
+	if err := calculatorService.ProcessCalculator(ctx, calc); err != nil {
+		return fmt.Errorf("failed to evaluate %q: %w", service.Expression(calc), err)
+	}
+
+	fmt.Fprintln(c.Out, calc.Status.Value)
+
+	return nil
+}
+
+func (c *createOptions) run(ctx context.Context, name string) error {
+	cl, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	namespace, err := c.namespace()
+	if err != nil {
+		return err
+	}
+
+	calc := &calcv1alpha1.Calculator{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       c.spec,
+	}
+
+	if err = cl.Create(ctx, calc); err != nil {
+		return fmt.Errorf("failed to create calculator: %w", err)
+	}
+
+	fmt.Fprintf(c.Out, "calculator.%s/%s created\n", calcv1alpha1.GroupVersion.Group, name)
+
+	if !c.wait {
+		return nil
+	}
+
+	calc, err = waitForResult(ctx, cl, client.ObjectKeyFromObject(calc), c.timeout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "%s = %s\n", service.Expression(calc), calc.Status.Value)
+
+	return nil
+}
+
+// waitForResult waits until the Calculator is processed, or fails.
+func waitForResult(ctx context.Context, cl client.Client, key client.ObjectKey, timeout time.Duration,
+) (*calcv1alpha1.Calculator, error) {
+	calc := &calcv1alpha1.Calculator{}
+
+	var failure *metav1.Condition
+
+	err := wait.PollImmediate(PollInterval, timeout, func() (bool, error) {
+		if err := cl.Get(ctx, key, calc); err != nil {
+			return false, fmt.Errorf("failed to get calculator: %w", err)
+		}
+
+		for _, conditionType := range []string{calcv1alpha1.ConditionInvalid, calcv1alpha1.ConditionLimitExceeded} {
+			if condition := meta.FindStatusCondition(calc.Status.Conditions, conditionType); condition != nil &&
+				condition.Status == metav1.ConditionTrue {
+				failure = condition
+
+				return true, nil
+			}
+		}
+
+		return calc.Status.Processed, nil
+	})
+
+	switch {
+	case errors.Is(err, wait.ErrWaitTimeout):
+		return nil, fmt.Errorf("%w within %s", ErrNotProcessed, timeout)
+	case err != nil:
+		return nil, err //nolint:wrapcheck // Wrapped by the condition
+	case failure != nil:
+		return nil, fmt.Errorf("%w: %s: %s", ErrNotProcessed, failure.Type, failure.Message)
+	}
+
+	return calc, nil
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mykysha/kubCalculator/pkg/encryption"
+)
+
+type decryptOptions struct {
+	*Options
+
+	keyringFile string
+	keySecret   string
+	key         string
+}
+
+func newDecryptCommand(o *Options) *cobra.Command {
+	d := &decryptOptions{Options: o}
+
+	cmd := &cobra.Command{
+		Use:   "decrypt NAME (--keyring-file FILE | --key-secret NAME)",
+		Short: "Decrypt the encrypted result secret of a Calculator",
+		Long: `Decrypt the result secret of a Calculator with spec.output.encryption.
+
+The keyring is read from a local file, such as the keyring file of the operator, or from the key Secret of the
+namespace referenced by the Calculator. Every value of the secret is printed as KEY=VALUE, or only the raw value
+of --key.`,
+		Example: `  # Decrypt with the key Secret of the Calculator
+  kubectl calc decrypt replicas --key-secret calc-keys
+
+  # Print the raw result
+  kubectl calc decrypt replicas --keyring-file keyring.yaml --key result`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return d.run(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&d.keyringFile, "keyring-file", "", "Keyring file.")
+	cmd.Flags().StringVar(&d.keySecret, "key-secret", "", "Secret of the namespace holding the keyring.")
+	cmd.Flags().StringVar(&d.key, "key", "", "Only print the raw value of this key.")
+
+	return cmd
+}
+
+func (d *decryptOptions) run(ctx context.Context, name string) error {
+	if (d.keyringFile == "") == (d.keySecret == "") {
+		return fmt.Errorf("one of --keyring-file and --key-secret is required") //nolint:goerr113 // Usage error
+	}
+
+	cl, err := d.client()
+	if err != nil {
+		return err
+	}
+
+	namespace, err := d.namespace()
+	if err != nil {
+		return err
+	}
+
+	keyring, err := d.keyring(ctx, cl, namespace)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{}
+	if err = cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	data, err := keyring.DecryptSecret(secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+	}
+
+	if d.key != "" {
+		value, ok := data[d.key]
+		if !ok {
+			return fmt.Errorf("secret %s has no key %q", name, d.key) //nolint:goerr113 // Usage error
+		}
+
+		fmt.Fprintln(d.Out, string(value))
+
+		return nil
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(d.Out, "%s=%s\n", key, data[key])
+	}
+
+	return nil
+}
+
+func (d *decryptOptions) keyring(ctx context.Context, cl client.Client, namespace string,
+) (*encryption.Keyring, error) {
+	if d.keyringFile != "" {
+		keyring, err := encryption.LoadKeyring(d.keyringFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keyring: %w", err)
+		}
+
+		return keyring, nil
+	}
+
+	keySecret := &corev1.Secret{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: d.keySecret}, keySecret); err != nil {
+		return nil, fmt.Errorf("failed to get key secret: %w", err)
+	}
+
+	keyring, err := encryption.ParseKeyring(keySecret.Data[encryption.KeyringKey])
+	if err != nil {
+		return nil, fmt.Errorf("key secret %s: %w", d.keySecret, err)
+	}
+
+	return keyring, nil
+}
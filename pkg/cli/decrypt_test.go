@@ -0,0 +1,49 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mykysha/kubCalculator/pkg/encryption"
+)
+
+const testKeyring = "primary: k1\nkeys:\n  k1: MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"
+
+func TestDecrypt(t *testing.T) {
+	t.Parallel()
+
+	keyring, err := encryption.ParseKeyring([]byte(testKeyring))
+	require.NoError(t, err)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sum", Namespace: "default"},
+		StringData: map[string]string{"result": "3", "unit": "m"},
+	}
+	require.NoError(t, keyring.EncryptSecret(secret, nil))
+
+	cl := newClient(t, secret, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "calc-keys", Namespace: "default"},
+		Data:       map[string][]byte{encryption.KeyringKey: []byte(testKeyring)},
+	})
+
+	out, err := run(t, cl, "decrypt", "sum", "--key-secret", "calc-keys")
+	assert.NoError(t, err)
+	assert.Equal(t, "result=3\nunit=m\n", out)
+
+	out, err = run(t, cl, "decrypt", "sum", "--key-secret", "calc-keys", "--key", "result")
+	assert.NoError(t, err)
+	assert.Equal(t, "3\n", out)
+
+	_, err = run(t, cl, "decrypt", "sum", "--key-secret", "calc-keys", "--key", "missing")
+	assert.ErrorContains(t, err, `secret sum has no key "missing"`)
+
+	_, err = run(t, cl, "decrypt", "sum")
+	assert.ErrorContains(t, err, "one of --keyring-file and --key-secret is required")
+
+	_, err = run(t, cl, "decrypt", "calc-keys", "--key-secret", "calc-keys")
+	assert.ErrorIs(t, err, encryption.ErrDecrypt)
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+func newExplainCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain OPERATION",
+		Short: "Describe an operation of expressions, by name or by symbol",
+		Example: `  # Describe the add operation
+  kubectl calc explain add
+
+  # Describe the operations bound to the ^ operator
+  kubectl calc explain ^`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return explain(o.Out, o.registry(), args[0])
+		},
+	}
+}
+
+func explain(out io.Writer, registry *service.Registry, operation string) error {
+	var found []service.Evaluator
+
+	if evaluator, ok := registry.Lookup(operation); ok {
+		found = append(found, evaluator)
+	} else {
+		for _, evaluator := range registry.Evaluators() {
+			if evaluator.Symbol != "" && evaluator.Symbol == operation {
+				found = append(found, evaluator)
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		names := make([]string, 0)
+		for _, evaluator := range registry.Evaluators() {
+			names = append(names, evaluator.Name)
+		}
+
+		return fmt.Errorf("unknown operation %q, available operations: %s", //nolint:goerr113 // Usage error
+			operation, strings.Join(names, ", "))
+	}
+
+	for i, evaluator := range found {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		fmt.Fprintf(out, "NAME:        %s\n", evaluator.Name)
+
+		if evaluator.Symbol != "" {
+			fmt.Fprintf(out, "SYMBOL:      %s\n", evaluator.Symbol)
+		}
+
+		fmt.Fprintln(out, "SIGNATURES:")
+
+		for _, signature := range evaluator.Signatures {
+			fmt.Fprintf(out, "  %s%s\n", evaluator.Name, signature)
+		}
+
+		fmt.Fprintf(out, "DESCRIPTION:\n  %s\n", evaluator.Doc)
+
+		if evaluator.Domain != "" {
+			fmt.Fprintf(out, "DOMAIN:\n  %s\n", evaluator.Domain)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+func newFunctionsCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "functions",
+		Short: "List the operations of expressions with their arity and domain",
+		Example: `  # List the operations
+  kubectl calc functions
+
+  # Describe the signatures of an operation
+  kubectl calc explain sqrt`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printFunctions(o.Out, o.registry())
+
+			return nil
+		},
+	}
+}
+
+// printFunctions lists the operations of the registry, the single catalog of the evaluator.
+func printFunctions(out io.Writer, registry *service.Registry) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0) //nolint:gomnd // kubectl padding
+	defer w.Flush()
+
+	fmt.Fprintln(w, "NAME\tSYMBOL\tARITY\tDESCRIPTION\tDOMAIN")
+
+	for _, evaluator := range registry.Evaluators() {
+		domain := evaluator.Domain
+		if domain == "" {
+			domain = "any"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", evaluator.Name, orNone(evaluator.Symbol), arity(evaluator),
+			evaluator.Doc, domain)
+	}
+}
+
+// arity returns the number of arguments of the operation, such as 1, 2+ or 1-2.
+func arity(evaluator service.Evaluator) string {
+	minArgs, maxArgs := evaluator.Arity()
+
+	switch maxArgs {
+	case -1:
+		return fmt.Sprintf("%d+", minArgs)
+	case minArgs:
+		return fmt.Sprint(minArgs)
+	default:
+		return fmt.Sprintf("%d-%d", minArgs, maxArgs)
+	}
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+)
+
+// Output formats of get.
+const (
+	OutputTable = ""
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+type getOptions struct {
+	*Options
+
+	output string
+}
+
+func newGetCommand(o *Options) *cobra.Command {
+	g := &getOptions{Options: o}
+
+	cmd := &cobra.Command{
+		Use:   "get [NAME]",
+		Short: "Show the results and the conditions of Calculators",
+		Example: `  # List the Calculators of the namespace
+  kubectl calc get
+
+  # Show a Calculator as YAML
+  kubectl calc get sum -o yaml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return g.run(cmd.Context(), args)
+		},
+	}
+
+	cmd.Flags().StringVarP(&g.output, "output", "o", OutputTable, "Output format, one of json or yaml.")
+
+	return cmd
+}
+
+func (g *getOptions) run(ctx context.Context, args []string) error {
+	if g.output != OutputTable && g.output != OutputJSON && g.output != OutputYAML {
+		return fmt.Errorf("unsupported output format %q", g.output) //nolint:goerr113 // Usage error
+	}
+
+	cl, err := g.client()
+	if err != nil {
+		return err
+	}
+
+	namespace, err := g.namespace()
+	if err != nil {
+		return err
+	}
+
+	list := &calcv1alpha1.CalculatorList{}
+
+	if len(args) == 1 {
+		calc := calcv1alpha1.Calculator{}
+		if err = cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: args[0]}, &calc); err != nil {
+			return fmt.Errorf("failed to get calculator: %w", err)
+		}
+
+		list.Items = append(list.Items, calc)
+	} else if err = cl.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list calculators: %w", err)
+	}
+
+	return g.print(list, len(args) == 1)
+}
+
+func (g *getOptions) print(list *calcv1alpha1.CalculatorList, single bool) error {
+	var obj interface{} = list
+	if single {
+		obj = &list.Items[0]
+	}
+
+	switch g.output {
+	case OutputJSON:
+		content, err := json.MarshalIndent(obj, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to encode calculators: %w", err)
+		}
+
+		fmt.Fprintln(g.Out, string(content))
+	case OutputYAML:
+		content, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to encode calculators: %w", err)
+		}
+
+		fmt.Fprint(g.Out, string(content))
+	default:
+		printTable(g.Out, list.Items)
+	}
+
+	return nil
+}
+
+func printTable(out io.Writer, calcs []calcv1alpha1.Calculator) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0) //nolint:gomnd // kubectl padding
+	defer w.Flush()
+
+	fmt.Fprintln(w, "NAME\tX\tY\tEXPRESSION\tVALUE\tCONDITIONS")
+
+	for _, calc := range calcs {
+		value := orNone(calc.Status.Value)
+		if calc.Status.Checksum != "" {
+			// The result of an encrypted calculator is only in its secret.
+			value = "<encrypted>"
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", calc.Name, calc.Spec.X, calc.Spec.Y,
+			orNone(calc.Spec.Expression), value, conditions(calc.Status.Conditions))
+	}
+}
+
+// conditions returns the types of the true conditions.
+func conditions(conditions []metav1.Condition) string {
+	var types []string
+
+	for _, condition := range conditions {
+		if condition.Status == metav1.ConditionTrue {
+			types = append(types, condition.Type)
+		}
+	}
+
+	return orNone(strings.Join(types, ","))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+
+	return s
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+)
+
+func newHistoryCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "history NAME",
+		Short: "Show the results and the failures recorded for a Calculator",
+		Long: `Show the results and the failures recorded for a Calculator, oldest first.
+
+The history is read from the events of the operator, it is as long as the event retention of the cluster.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return history(cmd.Context(), o, args[0])
+		},
+	}
+}
+
+func history(ctx context.Context, o *Options, name string) error {
+	cl, err := o.client()
+	if err != nil {
+		return err
+	}
+
+	namespace, err := o.namespace()
+	if err != nil {
+		return err
+	}
+
+	events := &corev1.EventList{}
+
+	err = cl.List(ctx, events, client.InNamespace(namespace), client.MatchingFieldsSelector{
+		Selector: fields.SelectorFromSet(fields.Set{
+			"involvedObject.kind": "Calculator",
+			"involvedObject.name": name,
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+
+	var recorded []corev1.Event
+
+	// The selector is also applied here, for the API servers ignoring it.
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind == "Calculator" && event.InvolvedObject.Name == name &&
+			event.InvolvedObject.APIVersion == calcv1alpha1.GroupVersion.String() {
+			recorded = append(recorded, event)
+		}
+	}
+
+	sort.SliceStable(recorded, func(i, j int) bool {
+		return lastSeen(recorded[i]).Before(lastSeen(recorded[j]))
+	})
+
+	if len(recorded) == 0 {
+		fmt.Fprintf(o.Out, "No history found for calculator %s/%s.\n", namespace, name)
+
+		return nil
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 3, ' ', 0) //nolint:gomnd // kubectl padding
+	defer w.Flush()
+
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tCOUNT\tMESSAGE")
+
+	for _, event := range recorded {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", lastSeen(event).UTC().Format(time.RFC3339), event.Type, event.Reason,
+			event.Count, event.Message)
+	}
+
+	return nil
+}
+
+func lastSeen(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
@@ -0,0 +1,427 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/webhook"
+)
+
+// ExpectedValueAnnotation asserts the result of a Calculator manifest in validate. The operator ignores it.
+const ExpectedValueAnnotation = "calc.example.com/expected-value"
+
+// Output formats of validate.
+const (
+	OutputText  = "text"
+	OutputJUnit = "junit"
+)
+
+// Statuses of a validated Calculator.
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// ErrValidation is returned when a Calculator fails the validation.
+var ErrValidation = errors.New("validation failed")
+
+// Result is the outcome of the validation of a Calculator manifest.
+type Result struct {
+	File       string `json:"file"`
+	Document   int    `json:"document"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Value      string `json:"value,omitempty"`
+	Expected   string `json:"expected,omitempty"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+}
+
+type validateOptions struct {
+	*Options
+
+	filenames  []string
+	recursive  bool
+	output     string
+	evaluators []string
+	// allowedTargets are the kinds of the targets, as Kind.group.
+	allowedTargets []string
+}
+
+func newValidateCommand(o *Options) *cobra.Command {
+	v := &validateOptions{Options: o}
+
+	cmd := &cobra.Command{
+		Use:   "validate -f FILENAME",
+		Short: "Validate and evaluate Calculator manifests offline",
+		Long: `Validate and evaluate Calculator manifests offline, without a cluster.
+
+The documents are decoded with the scheme of the operator and validated like the admission webhook does. The
+Calculators are then evaluated with the builtin operations, and their result compared with their
+` + ExpectedValueAnnotation + ` annotation when set. Documents of other kinds are ignored. Calculators
+selecting one of the declared external evaluators are validated but not evaluated.
+
+The command fails when a Calculator fails.`,
+		Example: `  # Validate the manifests of a directory
+  kubectl calc validate -f manifests/ -R
+
+  # Write a JUnit report for CI
+  kubectl calc validate -f calculators.yaml -o junit > report.xml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return v.run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&v.filenames, "filename", "f", nil,
+		"Files or directories of manifests, - reads the standard input.")
+	cmd.Flags().BoolVarP(&v.recursive, "recursive", "R", false, "Read the directories recursively.")
+	cmd.Flags().StringVarP(&v.output, "output", "o", OutputText, "Output format, one of text, json or junit.")
+	cmd.Flags().StringSliceVar(&v.evaluators, "evaluators", nil, "Names of the external evaluators.")
+	cmd.Flags().StringSliceVar(&v.allowedTargets, "allowed-targets", nil,
+		"Kinds the Calculators may apply their results to, as Kind.group, such as Deployment.apps or ConfigMap.")
+
+	_ = cmd.MarkFlagRequired("filename")
+
+	return cmd
+}
+
+func (v *validateOptions) run(ctx context.Context) error {
+	if v.output != OutputText && v.output != OutputJSON && v.output != OutputJUnit {
+		return fmt.Errorf("unsupported output format %q", v.output) //nolint:goerr113 // Usage error
+	}
+
+	files, err := v.files()
+	if err != nil {
+		return err
+	}
+
+	var results []Result
+
+	for _, file := range files {
+		fileResults, err := v.validateFile(ctx, file)
+		if err != nil {
+			return err
+		}
+
+		results = append(results, fileResults...)
+	}
+
+	if err = v.print(results); err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, result := range results {
+		if result.Status == StatusFailed {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d calculators failed", ErrValidation, failed, len(results))
+	}
+
+	return nil
+}
+
+// files expands the directories of the filenames.
+func (v *validateOptions) files() ([]string, error) {
+	var files []string
+
+	for _, filename := range v.filenames {
+		if filename == "-" {
+			files = append(files, filename)
+
+			continue
+		}
+
+		err := filepath.WalkDir(filename, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() {
+				if path != filename && !v.recursive {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			switch filepath.Ext(path) {
+			case ".yaml", ".yml", ".json":
+				files = append(files, path)
+			default:
+				if path == filename {
+					files = append(files, path)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+	}
+
+	return files, nil
+}
+
+func (v *validateOptions) validateFile(ctx context.Context, file string) ([]Result, error) {
+	var in io.Reader = v.In
+
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		defer f.Close()
+
+		in = f
+	}
+
+	decoder := serializer.NewCodecFactory(scheme, serializer.EnableStrict).UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+
+	var results []Result
+
+	for document := 1; ; document++ {
+		content, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		if empty(content) {
+			continue
+		}
+
+		result := Result{File: file, Document: document}
+
+		obj, _, err := decoder.Decode(content, nil, nil)
+		switch {
+		case runtime.IsNotRegisteredError(err):
+			continue
+		case err != nil:
+			result.Status, result.Message = StatusFailed, err.Error()
+		default:
+			calc, isCalculator := obj.(*calcv1alpha1.Calculator)
+			if !isCalculator {
+				continue
+			}
+
+			v.validate(ctx, calc, &result)
+		}
+
+		results = append(results, result)
+	}
+}
+
+// empty reports whether the document holds nothing but comments.
+func empty(content []byte) bool {
+	var obj map[string]interface{}
+
+	return yaml.Unmarshal(content, &obj) == nil && len(obj) == 0
+}
+
+// validate validates and evaluates the calculator like the operator does.
+func (v *validateOptions) validate(ctx context.Context, calc *calcv1alpha1.Calculator, result *Result) {
+	calculatorService := service.CalculatorService{Limits: service.DefaultLimits(), Registry: v.registry()}
+
+	validator := &webhook.CalculatorValidator{
+		Validator:  calculatorService,
+		Evaluators: make(map[string]webhook.Validator),
+	}
+
+	for _, allowed := range v.allowedTargets {
+		validator.AllowedTargets = append(validator.AllowedTargets, schema.ParseGroupKind(allowed))
+	}
+
+	for _, evaluator := range v.evaluators {
+		validator.Evaluators[evaluator] = external{}
+	}
+
+	result.Namespace, result.Name = calc.Namespace, calc.Name
+	result.Expression = service.Expression(calc)
+	result.Expected = calc.Annotations[ExpectedValueAnnotation]
+
+	if err := validator.ValidateCreate(ctx, calc); err != nil {
+		result.Status, result.Message = StatusFailed, err.Error()
+
+		return
+	}
+
+	if calc.Spec.EvaluatorRef != nil {
+		result.Status = StatusSkipped
+		result.Message = fmt.Sprintf("evaluated by the external evaluator %s", calc.Spec.EvaluatorRef.Name)
+
+		return
+	}
+
+	if err := calculatorService.ProcessCalculator(ctx, calc); err != nil {
+		result.Status, result.Message = StatusFailed, err.Error()
+
+		return
+	}
+
+	result.Value = calc.Status.Value
+
+	if result.Expected != "" && result.Expected != result.Value {
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("expected %s, got %s", result.Expected, result.Value)
+
+		return
+	}
+
+	result.Status = StatusPassed
+}
+
+// external stands in for the external evaluators, which validate their calculations themselves.
+type external struct{}
+
+func (external) ValidateCalculator(_ *calcv1alpha1.Calculator) field.ErrorList {
+	return nil
+}
+
+func (v *validateOptions) print(results []Result) error {
+	switch v.output {
+	case OutputJSON:
+		if results == nil {
+			results = []Result{}
+		}
+
+		content, err := json.MarshalIndent(results, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to encode results: %w", err)
+		}
+
+		fmt.Fprintln(v.Out, string(content))
+	case OutputJUnit:
+		content, err := xml.MarshalIndent(junitReport(results), "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to encode results: %w", err)
+		}
+
+		fmt.Fprintln(v.Out, xml.Header+string(content))
+	default:
+		for _, result := range results {
+			fmt.Fprintf(v.Out, "%s\t%s\n", strings.ToUpper(result.Status), result.summary())
+		}
+	}
+
+	return nil
+}
+
+// id identifies the calculator in the reports.
+func (r Result) id() string {
+	name := r.Name
+	if name == "" {
+		name = fmt.Sprintf("document %d", r.Document)
+	}
+
+	if r.Namespace != "" {
+		name = r.Namespace + "/" + name
+	}
+
+	return fmt.Sprintf("%s %s", r.File, name)
+}
+
+func (r Result) summary() string {
+	switch {
+	case r.Message != "":
+		return fmt.Sprintf("%s: %s", r.id(), r.Message)
+	default:
+		return fmt.Sprintf("%s: %s = %s", r.id(), r.Expression, r.Value)
+	}
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// junitReport groups the results by file, one test suite per file.
+func junitReport(results []Result) junitTestSuites {
+	report := junitTestSuites{}
+	suites := make(map[string]int)
+
+	for _, result := range results {
+		i, ok := suites[result.File]
+		if !ok {
+			i = len(report.Suites)
+			suites[result.File] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: result.File})
+		}
+
+		suite := &report.Suites[i]
+		testCase := junitTestCase{Name: strings.TrimPrefix(result.id(), result.File+" "), ClassName: result.File}
+
+		switch result.Status {
+		case StatusFailed:
+			testCase.Failure = &junitMessage{Message: result.Message}
+			suite.Failures++
+			report.Failures++
+		case StatusSkipped:
+			testCase.Skipped = &junitMessage{Message: result.Message}
+			suite.Skipped++
+			report.Skipped++
+		default:
+			testCase.SystemOut = fmt.Sprintf("%s = %s", result.Expression, result.Value)
+		}
+
+		suite.Tests++
+		report.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	return report
+}
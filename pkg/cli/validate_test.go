@@ -0,0 +1,102 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mykysha/kubCalculator/pkg/cli"
+)
+
+func TestValidateText(t *testing.T) {
+	t.Parallel()
+
+	out, err := run(t, nil, "validate", "-f", "testdata/valid.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "PASSED\ttestdata/valid.yaml square: x ^ 2 = 144\n", out)
+
+	out, err = run(t, nil, "validate", "-f", "testdata/calculators.yaml", "--evaluators", "finance")
+	assert.ErrorIs(t, err, cli.ErrValidation)
+	assert.EqualError(t, err, "validation failed: 2 of 4 calculators failed")
+	assert.Equal(t, `PASSED	testdata/calculators.yaml default/sum: x + y = 3
+FAILED	testdata/calculators.yaml wrong: expected 4, got 6
+FAILED	testdata/calculators.yaml syntax: Calculator.calc.example.com "syntax" is invalid: `+
+		`spec.expression: Invalid value: "x +": unexpected end of expression
+SKIPPED	testdata/calculators.yaml remote: evaluated by the external evaluator finance
+`, out)
+}
+
+func TestValidateUnknownEvaluator(t *testing.T) {
+	t.Parallel()
+
+	out, err := run(t, nil, "validate", "-f", "testdata", "-o", "json")
+	assert.ErrorIs(t, err, cli.ErrValidation)
+
+	var results []cli.Result
+
+	require.NoError(t, json.Unmarshal([]byte(out), &results))
+	require.Len(t, results, 5)
+
+	assert.Equal(t, cli.Result{
+		File: "testdata/calculators.yaml", Document: 1, Namespace: "default", Name: "sum", Expression: "x + y",
+		Value: "3", Expected: "3", Status: cli.StatusPassed,
+	}, results[0])
+	assert.Equal(t, cli.StatusFailed, results[3].Status)
+	assert.Contains(t, results[3].Message, `spec.evaluatorRef.name: Not found: "finance"`)
+	assert.Equal(t, "square", results[4].Name)
+}
+
+func TestValidateJUnit(t *testing.T) {
+	t.Parallel()
+
+	out, err := run(t, nil, "validate", "-f", "testdata/calculators.yaml", "--evaluators", "finance", "-o", "junit")
+	assert.Error(t, err)
+	assert.Contains(t, out, `<testsuites tests="4" failures="2" skipped="1">`)
+	assert.Contains(t, out, `<testsuite name="testdata/calculators.yaml" tests="4" failures="2" skipped="1">`)
+	assert.Contains(t, out, `<testcase name="wrong" classname="testdata/calculators.yaml">`)
+	assert.Contains(t, out, `<failure message="expected 4, got 6"></failure>`)
+	assert.Contains(t, out, `<system-out>x + y = 3</system-out>`)
+}
+
+func TestValidateInvalidDocument(t *testing.T) {
+	t.Parallel()
+
+	out, err := runWithInput(t, `apiVersion: calc.example.com/v1alpha1
+kind: Calculator
+metadata:
+  name: typo
+spec:
+  z: 1
+`, "validate", "-f", "-")
+	assert.Error(t, err)
+	assert.Contains(t, out, `FAILED	- document 1: strict decoding error: unknown field "spec.z"`)
+}
+
+func TestValidateAllowedTargets(t *testing.T) {
+	t.Parallel()
+
+	manifest := `apiVersion: calc.example.com/v1alpha1
+kind: Calculator
+metadata:
+  name: replicas
+spec:
+  x: 2
+  "y": 3
+  targets:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: web
+      path: .spec.replicas
+      type: Integer
+`
+
+	out, err := runWithInput(t, manifest, "validate", "-f", "-")
+	assert.ErrorIs(t, err, cli.ErrValidation)
+	assert.Contains(t, out, "spec.targets[0].kind: Forbidden: Deployment.apps is not allowed")
+
+	out, err = runWithInput(t, manifest, "validate", "-f", "-", "--allowed-targets", "Deployment.apps")
+	assert.NoError(t, err)
+	assert.Equal(t, "PASSED\t- replicas: x + y = 5\n", out)
+}
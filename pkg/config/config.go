@@ -0,0 +1,764 @@
+// Package config loads the operator configuration file.
+//
+// The effective configuration is resolved with the following precedence, highest first:
+// explicitly set command line flags, the configuration file, built-in defaults.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
+	componentconfigv1alpha1 "k8s.io/component-base/config/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	configv1alpha1 "github.com/mykysha/kubCalculator/api/config/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/external"
+	"github.com/mykysha/kubCalculator/pkg/httpapi"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/sharding"
+	"github.com/mykysha/kubCalculator/pkg/targets"
+	"github.com/mykysha/kubCalculator/pkg/wasm"
+)
+
+// Command line flags that override the configuration file.
+const (
+	FlagManagerPort = "manager-port"
+	FlagMetricsAddr = "metrics-bind-address"
+	FlagProbeAddr   = "health-probe-bind-address"
+	FlagLeaderElect = "leader-elect"
+	FlagZapDevel    = "zap-devel"
+	// FlagWatchNamespaces is a comma-separated list of namespaces, it replaces the watchNamespaces of the file.
+	FlagWatchNamespaces = "watch-namespaces"
+	// FlagShardID sets the sharding.shardID of the file, so that the replicas can share a configuration file.
+	FlagShardID = "shard-id"
+)
+
+// Built-in defaults, used when neither a flag nor the configuration file sets a value.
+const (
+	DefaultManagerPort      = 9443
+	DefaultMetricsAddr      = ":8080"
+	DefaultProbeAddr        = ":8081"
+	DefaultLeaderElectionID = "e7da643b.example.com"
+	DefaultDevelopment      = true
+
+	// DefaultMaxConcurrentReconciles is the number of workers of the controllers of controller-runtime.
+	DefaultMaxConcurrentReconciles = 1
+	// The default rate limiter settings are those of the controllers of controller-runtime.
+	DefaultRateLimiterBaseDelay = 5 * time.Millisecond
+	DefaultRateLimiterMaxDelay  = 1000 * time.Second
+	DefaultRateLimiterQPS       = 10
+	DefaultRateLimiterBurst     = 100
+)
+
+const (
+	maxPort = 65535
+	// maxWasmPages is the 4Gi address space of a 32-bit WebAssembly memory.
+	maxWasmPages = 65536
+)
+
+// Feature is the name of an optional operator feature.
+type Feature string
+
+// defaultFeatureGates lists the known features and whether they are enabled by default.
+var defaultFeatureGates = map[Feature]bool{}
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(configv1alpha1.AddToScheme(scheme))
+}
+
+// Load reads the configuration file at path, applies the explicitly set flags of fs on top of it,
+// fills in the defaults and validates the result. An empty path skips the file.
+func Load(path string, fs *flag.FlagSet) (*configv1alpha1.OperatorConfig, error) {
+	cfg := &configv1alpha1.OperatorConfig{}
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		cfg, err = Decode(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fs != nil {
+		if err := ApplyFlags(fs, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	Default(cfg)
+
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Decode strictly decodes a configuration file, rejecting unknown fields.
+func Decode(content []byte) (*configv1alpha1.OperatorConfig, error) {
+	codecs := serializer.NewCodecFactory(scheme, serializer.EnableStrict)
+	cfg := &configv1alpha1.OperatorConfig{}
+
+	if err := runtime.DecodeInto(codecs.UniversalDecoder(), content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config file: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// ApplyFlags copies the flags explicitly set on the command line into the configuration.
+func ApplyFlags(fs *flag.FlagSet, cfg *configv1alpha1.OperatorConfig) error {
+	var err error
+
+	fs.Visit(func(f *flag.Flag) {
+		getter, ok := f.Value.(flag.Getter)
+		if !ok {
+			return
+		}
+
+		switch f.Name {
+		case FlagManagerPort:
+			port, isInt := getter.Get().(int)
+			if !isInt {
+				err = fmt.Errorf("flag %s is not an int", f.Name)
+
+				return
+			}
+
+			cfg.Webhook.Port = &port
+		case FlagMetricsAddr:
+			cfg.Metrics.BindAddress = f.Value.String()
+		case FlagProbeAddr:
+			cfg.Health.HealthProbeBindAddress = f.Value.String()
+		case FlagLeaderElect:
+			leaderElect, isBool := getter.Get().(bool)
+			if !isBool {
+				err = fmt.Errorf("flag %s is not a bool", f.Name)
+
+				return
+			}
+
+			if cfg.LeaderElection == nil {
+				cfg.LeaderElection = &componentconfigv1alpha1.LeaderElectionConfiguration{}
+			}
+
+			cfg.LeaderElection.LeaderElect = &leaderElect
+		case FlagZapDevel:
+			development, isBool := getter.Get().(bool)
+			if !isBool {
+				err = fmt.Errorf("flag %s is not a bool", f.Name)
+
+				return
+			}
+
+			cfg.Logging.Development = &development
+		case FlagShardID:
+			shardID, isInt := getter.Get().(int)
+			if !isInt {
+				err = fmt.Errorf("flag %s is not an int", f.Name)
+
+				return
+			}
+
+			if cfg.Sharding == nil {
+				cfg.Sharding = &configv1alpha1.ShardingConfig{}
+			}
+
+			cfg.Sharding.ShardID = shardID
+		case FlagWatchNamespaces:
+			cfg.WatchNamespaces = nil
+
+			for _, namespace := range strings.Split(f.Value.String(), ",") {
+				if namespace = strings.TrimSpace(namespace); namespace != "" {
+					cfg.WatchNamespaces = append(cfg.WatchNamespaces, namespace)
+				}
+			}
+		}
+	})
+
+	return err
+}
+
+// Default fills in the unset fields of the configuration.
+func Default(cfg *configv1alpha1.OperatorConfig) {
+	if cfg.Webhook.Port == nil {
+		port := DefaultManagerPort
+		cfg.Webhook.Port = &port
+	}
+
+	if cfg.Metrics.BindAddress == "" {
+		cfg.Metrics.BindAddress = DefaultMetricsAddr
+	}
+
+	if cfg.Health.HealthProbeBindAddress == "" {
+		cfg.Health.HealthProbeBindAddress = DefaultProbeAddr
+	}
+
+	if cfg.LeaderElection == nil {
+		cfg.LeaderElection = &componentconfigv1alpha1.LeaderElectionConfiguration{}
+	}
+
+	if cfg.LeaderElection.LeaderElect == nil {
+		leaderElect := false
+		cfg.LeaderElection.LeaderElect = &leaderElect
+	}
+
+	if cfg.LeaderElection.ResourceName == "" {
+		cfg.LeaderElection.ResourceName = DefaultLeaderElectionID
+	}
+
+	if cfg.Logging.Development == nil {
+		development := DefaultDevelopment
+		cfg.Logging.Development = &development
+	}
+
+	if cfg.Output.ManagedBy == "" {
+		cfg.Output.ManagedBy = service.DefaultManagedBy
+	}
+
+	if cfg.Output.ResultKey == "" {
+		cfg.Output.ResultKey = service.DefaultResultKey
+	}
+
+	defaultLimits(&cfg.Limits)
+	defaultReconcile(&cfg.Reconcile)
+
+	for i := range cfg.Evaluators {
+		defaultEvaluator(&cfg.Evaluators[i])
+	}
+
+	if cfg.Plugins != nil {
+		defaultPlugins(cfg.Plugins)
+	}
+
+	if cfg.API != nil && cfg.API.BindAddress == "" {
+		cfg.API.BindAddress = httpapi.DefaultBindAddress
+	}
+}
+
+func defaultPlugins(plugins *configv1alpha1.PluginsConfig) {
+	if plugins.MaxMemory == nil {
+		plugins.MaxMemory = resource.NewQuantity(wasm.DefaultMaxMemoryPages*wasm.PageSize, resource.BinarySI)
+	}
+}
+
+func defaultEvaluator(evaluator *configv1alpha1.EvaluatorConfig) {
+	if evaluator.Timeout == nil {
+		evaluator.Timeout = &metav1.Duration{Duration: external.DefaultTimeout}
+	}
+
+	if evaluator.Retries == nil {
+		retries := external.DefaultRetries
+		evaluator.Retries = &retries
+	}
+}
+
+func defaultLimits(limits *configv1alpha1.LimitsConfig) {
+	defaults := service.DefaultLimits()
+
+	if limits.EvaluationTimeout == nil {
+		limits.EvaluationTimeout = &metav1.Duration{Duration: defaults.Timeout}
+	}
+
+	if limits.MaxDigits == nil {
+		limits.MaxDigits = &defaults.MaxDigits
+	}
+
+	if limits.MaxDepth == nil {
+		limits.MaxDepth = &defaults.MaxDepth
+	}
+
+	if limits.MaxOperands == nil {
+		limits.MaxOperands = &defaults.MaxOperands
+	}
+}
+
+func defaultReconcile(reconcile *configv1alpha1.ReconcileConfig) {
+	if reconcile.MaxConcurrentReconciles == nil {
+		workers := DefaultMaxConcurrentReconciles
+		reconcile.MaxConcurrentReconciles = &workers
+	}
+
+	rateLimiter := &reconcile.RateLimiter
+
+	if rateLimiter.BaseDelay == nil {
+		rateLimiter.BaseDelay = &metav1.Duration{Duration: DefaultRateLimiterBaseDelay}
+	}
+
+	if rateLimiter.MaxDelay == nil {
+		rateLimiter.MaxDelay = &metav1.Duration{Duration: DefaultRateLimiterMaxDelay}
+	}
+
+	if rateLimiter.QPS == nil {
+		qps := DefaultRateLimiterQPS
+		rateLimiter.QPS = &qps
+	}
+
+	if rateLimiter.Burst == nil {
+		burst := DefaultRateLimiterBurst
+		rateLimiter.Burst = &burst
+	}
+}
+
+// Limits converts the configured limits for the calculator service.
+func Limits(cfg *configv1alpha1.OperatorConfig) service.Limits {
+	limits := service.DefaultLimits()
+
+	if cfg.Limits.EvaluationTimeout != nil {
+		limits.Timeout = cfg.Limits.EvaluationTimeout.Duration
+	}
+
+	if cfg.Limits.MaxDigits != nil {
+		limits.MaxDigits = *cfg.Limits.MaxDigits
+	}
+
+	if cfg.Limits.MaxDepth != nil {
+		limits.MaxDepth = *cfg.Limits.MaxDepth
+	}
+
+	if cfg.Limits.MaxOperands != nil {
+		limits.MaxOperands = *cfg.Limits.MaxOperands
+	}
+
+	return limits
+}
+
+// Evaluators converts the configured external evaluators for the external package.
+func Evaluators(cfg *configv1alpha1.OperatorConfig) []external.Options {
+	options := make([]external.Options, 0, len(cfg.Evaluators))
+
+	for _, evaluator := range cfg.Evaluators {
+		opts := external.Options{
+			Name:    evaluator.Name,
+			Address: evaluator.Address,
+			Timeout: external.DefaultTimeout,
+			Retries: external.DefaultRetries,
+			Output: service.OutputOptions{
+				ManagedBy: cfg.Output.ManagedBy,
+				ResultKey: cfg.Output.ResultKey,
+			},
+			MaxDigits: Limits(cfg).MaxDigits,
+		}
+
+		if evaluator.Timeout != nil {
+			opts.Timeout = evaluator.Timeout.Duration
+		}
+
+		if evaluator.Retries != nil {
+			opts.Retries = *evaluator.Retries
+		}
+
+		if tls := evaluator.TLS; tls != nil {
+			opts.TLS = &external.TLSOptions{
+				CAFile:             tls.CAFile,
+				CertFile:           tls.CertFile,
+				KeyFile:            tls.KeyFile,
+				ServerName:         tls.ServerName,
+				InsecureSkipVerify: tls.InsecureSkipVerify,
+			}
+		}
+
+		options = append(options, opts)
+	}
+
+	return options
+}
+
+// ControllerOptions converts the configured workers and rate limiter for the Calculator controller.
+func ControllerOptions(cfg *configv1alpha1.OperatorConfig) controller.Options {
+	reconcile := cfg.Reconcile.DeepCopy()
+	defaultReconcile(reconcile)
+
+	rateLimiter := reconcile.RateLimiter
+
+	return controller.Options{
+		MaxConcurrentReconciles: *reconcile.MaxConcurrentReconciles,
+		RateLimiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(rateLimiter.BaseDelay.Duration, rateLimiter.MaxDelay.Duration),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(*rateLimiter.QPS), *rateLimiter.Burst)},
+		),
+	}
+}
+
+// Plugins converts the configured plugin limits for the wasm package.
+func Plugins(cfg *configv1alpha1.OperatorConfig) wasm.Options {
+	opts := wasm.Options{MaxMemoryPages: wasm.DefaultMaxMemoryPages}
+
+	if cfg.Plugins == nil {
+		return opts
+	}
+
+	if maxMemory := cfg.Plugins.MaxMemory; maxMemory != nil {
+		opts.MaxMemoryPages = uint32((maxMemory.Value() + wasm.PageSize - 1) / wasm.PageSize)
+	}
+
+	if cfg.Plugins.Fuel != nil {
+		opts.Fuel = *cfg.Plugins.Fuel
+	}
+
+	return opts
+}
+
+// Validate checks a defaulted configuration.
+func Validate(cfg *configv1alpha1.OperatorConfig) error {
+	var errs field.ErrorList
+
+	if port := cfg.Webhook.Port; port != nil && (*port < 1 || *port > maxPort) {
+		errs = append(errs, field.Invalid(field.NewPath("webhook", "port"), *port, "must be between 1 and 65535"))
+	}
+
+	errs = append(errs, validateBindAddress(field.NewPath("metrics", "bindAddress"), cfg.Metrics.BindAddress)...)
+	errs = append(errs, validateBindAddress(field.NewPath("health", "healthProbeBindAddress"),
+		cfg.Health.HealthProbeBindAddress)...)
+
+	if cfg.LeaderElection != nil && cfg.LeaderElection.ResourceName == "" {
+		errs = append(errs, field.Required(field.NewPath("leaderElection", "resourceName"), ""))
+	}
+
+	for _, msg := range validation.IsConfigMapKey(cfg.Output.ResultKey) {
+		errs = append(errs, field.Invalid(field.NewPath("output", "resultKey"), cfg.Output.ResultKey, msg))
+	}
+
+	if timeout := cfg.Limits.EvaluationTimeout; timeout != nil && timeout.Duration < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("limits", "evaluationTimeout"), timeout.Duration.String(),
+			"must not be negative"))
+	}
+
+	for _, limit := range []struct {
+		name  string
+		value *int
+	}{
+		{name: "maxDigits", value: cfg.Limits.MaxDigits},
+		{name: "maxDepth", value: cfg.Limits.MaxDepth},
+		{name: "maxOperands", value: cfg.Limits.MaxOperands},
+	} {
+		if limit.value != nil && *limit.value < 0 {
+			errs = append(errs, field.Invalid(field.NewPath("limits", limit.name), *limit.value, "must not be negative"))
+		}
+	}
+
+	errs = append(errs, validateReconcile(&cfg.Reconcile)...)
+
+	if cfg.Sharding != nil {
+		errs = append(errs, validateSharding(cfg)...)
+	}
+
+	seen := make(map[string]bool)
+
+	for i, namespace := range cfg.WatchNamespaces {
+		path := field.NewPath("watchNamespaces").Index(i)
+
+		for _, msg := range validation.IsDNS1123Label(namespace) {
+			errs = append(errs, field.Invalid(path, namespace, msg))
+		}
+
+		if seen[namespace] {
+			errs = append(errs, field.Duplicate(path, namespace))
+		}
+
+		seen[namespace] = true
+	}
+
+	if cfg.NamespaceSelector != nil {
+		errs = append(errs, metav1validation.ValidateLabelSelector(cfg.NamespaceSelector,
+			field.NewPath("namespaceSelector"))...)
+	}
+
+	errs = append(errs, validateEvaluators(cfg)...)
+
+	if cfg.Plugins != nil {
+		errs = append(errs, validatePlugins(cfg.Plugins)...)
+	}
+
+	if cfg.API != nil {
+		errs = append(errs, validateAPI(cfg.API)...)
+	}
+
+	for i, groupKind := range cfg.Targets.Allowed {
+		if groupKind.Kind == "" {
+			errs = append(errs, field.Required(field.NewPath("targets", "allowed").Index(i).Child("kind"), ""))
+		}
+	}
+
+	for name := range cfg.FeatureGates {
+		if _, known := defaultFeatureGates[Feature(name)]; !known {
+			errs = append(errs, field.NotSupported(field.NewPath("featureGates").Key(name), name, knownFeatures()))
+		}
+	}
+
+	if err := errs.ToAggregate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	return nil
+}
+
+// FeatureEnabled reports whether the feature is enabled in the configuration or by default.
+func FeatureEnabled(cfg *configv1alpha1.OperatorConfig, feature Feature) bool {
+	if enabled, ok := cfg.FeatureGates[string(feature)]; ok {
+		return enabled
+	}
+
+	return defaultFeatureGates[feature]
+}
+
+// APIEnabled reports whether the REST API is served.
+func APIEnabled(cfg *configv1alpha1.OperatorConfig) bool {
+	return cfg.API != nil && cfg.API.BindAddress != "0"
+}
+
+// AllowedTargets returns the kinds Calculators may apply their results to.
+func AllowedTargets(cfg *configv1alpha1.OperatorConfig) targets.Allowlist {
+	allowlist := make(targets.Allowlist, 0, len(cfg.Targets.Allowed))
+
+	for _, groupKind := range cfg.Targets.Allowed {
+		allowlist = append(allowlist, schema.GroupKind{Group: groupKind.Group, Kind: groupKind.Kind})
+	}
+
+	return allowlist
+}
+
+// KeyringFile returns the keyring file encrypting the result secrets, empty when none is configured.
+func KeyringFile(cfg *configv1alpha1.OperatorConfig) string {
+	if cfg.Encryption == nil {
+		return ""
+	}
+
+	return cfg.Encryption.KeyringFile
+}
+
+// Shard returns the shard run by the replica, nil when sharding is disabled. Its Reader is left to the caller.
+func Shard(cfg *configv1alpha1.OperatorConfig) *sharding.Shard {
+	if cfg.Sharding == nil {
+		return nil
+	}
+
+	return &sharding.Shard{
+		ID:             cfg.Sharding.ShardID,
+		Count:          cfg.Sharding.ShardCount,
+		LeaseNamespace: cfg.LeaderElection.ResourceNamespace,
+		LeasePrefix:    cfg.LeaderElection.ResourceName,
+	}
+}
+
+func validateEvaluators(cfg *configv1alpha1.OperatorConfig) field.ErrorList {
+	var errs field.ErrorList
+
+	names := make(map[string]bool)
+
+	for i, evaluator := range cfg.Evaluators {
+		path := field.NewPath("evaluators").Index(i)
+
+		for _, msg := range validation.IsDNS1123Subdomain(evaluator.Name) {
+			errs = append(errs, field.Invalid(path.Child("name"), evaluator.Name, msg))
+		}
+
+		if names[evaluator.Name] {
+			errs = append(errs, field.Duplicate(path.Child("name"), evaluator.Name))
+		}
+
+		names[evaluator.Name] = true
+
+		if evaluator.Address == "" {
+			errs = append(errs, field.Required(path.Child("address"), ""))
+		}
+
+		if timeout := evaluator.Timeout; timeout != nil && timeout.Duration < 0 {
+			errs = append(errs, field.Invalid(path.Child("timeout"), timeout.Duration.String(), "must not be negative"))
+		}
+
+		if retries := evaluator.Retries; retries != nil && *retries < 0 {
+			errs = append(errs, field.Invalid(path.Child("retries"), *retries, "must not be negative"))
+		}
+
+		if tls := evaluator.TLS; tls != nil && (tls.CertFile == "") != (tls.KeyFile == "") {
+			errs = append(errs, field.Invalid(path.Child("tls"), "", "certFile and keyFile must be set together"))
+		}
+	}
+
+	if cfg.DefaultEvaluator != "" && !names[cfg.DefaultEvaluator] {
+		errs = append(errs, field.NotFound(field.NewPath("defaultEvaluator"), cfg.DefaultEvaluator))
+	}
+
+	return errs
+}
+
+func validateReconcile(reconcile *configv1alpha1.ReconcileConfig) field.ErrorList {
+	var errs field.ErrorList
+
+	path := field.NewPath("reconcile")
+
+	if workers := reconcile.MaxConcurrentReconciles; workers != nil && *workers < 1 {
+		errs = append(errs, field.Invalid(path.Child("maxConcurrentReconciles"), *workers, "must be at least 1"))
+	}
+
+	rateLimiter := reconcile.RateLimiter
+	path = path.Child("rateLimiter")
+
+	for _, delay := range []struct {
+		name  string
+		value *metav1.Duration
+	}{
+		{name: "baseDelay", value: rateLimiter.BaseDelay},
+		{name: "maxDelay", value: rateLimiter.MaxDelay},
+	} {
+		if delay.value != nil && delay.value.Duration <= 0 {
+			errs = append(errs, field.Invalid(path.Child(delay.name), delay.value.Duration.String(), "must be positive"))
+		}
+	}
+
+	baseDelay, maxDelay := rateLimiter.BaseDelay, rateLimiter.MaxDelay
+	if baseDelay != nil && maxDelay != nil && maxDelay.Duration < baseDelay.Duration {
+		errs = append(errs, field.Invalid(path.Child("maxDelay"), maxDelay.Duration.String(),
+			"must not be lower than baseDelay"))
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{name: "qps", value: rateLimiter.QPS},
+		{name: "burst", value: rateLimiter.Burst},
+	} {
+		if setting.value != nil && *setting.value < 1 {
+			errs = append(errs, field.Invalid(path.Child(setting.name), *setting.value, "must be at least 1"))
+		}
+	}
+
+	return errs
+}
+
+func validateSharding(cfg *configv1alpha1.OperatorConfig) field.ErrorList {
+	var errs field.ErrorList
+
+	path := field.NewPath("sharding")
+	shards := cfg.Sharding
+
+	if shards.ShardCount < 1 {
+		errs = append(errs, field.Invalid(path.Child("shardCount"), shards.ShardCount, "must be at least 1"))
+	} else if shards.ShardID < 0 || shards.ShardID >= shards.ShardCount {
+		errs = append(errs, field.Invalid(path.Child("shardID"), shards.ShardID,
+			fmt.Sprintf("must be between 0 and %d", shards.ShardCount-1)))
+	}
+
+	// The shards take over the Calculators of the shards whose lease expired.
+	if cfg.LeaderElection == nil || cfg.LeaderElection.LeaderElect == nil || !*cfg.LeaderElection.LeaderElect {
+		errs = append(errs, field.Invalid(field.NewPath("leaderElection", "leaderElect"), false,
+			"must be enabled with sharding"))
+	}
+
+	if cfg.LeaderElection == nil || cfg.LeaderElection.ResourceNamespace == "" {
+		errs = append(errs, field.Required(field.NewPath("leaderElection", "resourceNamespace"),
+			"the leases of the shards are read from it"))
+	}
+
+	if cfg.LeaderElection == nil {
+		return errs
+	}
+
+	// The shards read the leases of one another, which the other locks do not hold.
+	if lock := cfg.LeaderElection.ResourceLock; lock != "" && lock != resourcelock.LeasesResourceLock {
+		errs = append(errs, field.NotSupported(field.NewPath("leaderElection", "resourceLock"), lock,
+			[]string{resourcelock.LeasesResourceLock}))
+	}
+
+	// Every replica holds the lease of its shard, named after the resource name.
+	if shard := Shard(cfg); shard.Count > 0 {
+		for _, msg := range validation.IsDNS1123Subdomain(shard.LeaseName(shard.Count - 1)) {
+			errs = append(errs, field.Invalid(field.NewPath("leaderElection", "resourceName"),
+				cfg.LeaderElection.ResourceName, msg))
+		}
+	}
+
+	return errs
+}
+
+func validatePlugins(plugins *configv1alpha1.PluginsConfig) field.ErrorList {
+	var errs field.ErrorList
+
+	path := field.NewPath("plugins")
+
+	if (plugins.Path == "") == (plugins.ConfigMap == nil) {
+		errs = append(errs, field.Invalid(path, "", "exactly one of path and configMap must be set"))
+	}
+
+	if ref := plugins.ConfigMap; ref != nil {
+		for _, msg := range validation.IsDNS1123Label(ref.Namespace) {
+			errs = append(errs, field.Invalid(path.Child("configMap", "namespace"), ref.Namespace, msg))
+		}
+
+		for _, msg := range validation.IsDNS1123Subdomain(ref.Name) {
+			errs = append(errs, field.Invalid(path.Child("configMap", "name"), ref.Name, msg))
+		}
+	}
+
+	if maxMemory := plugins.MaxMemory; maxMemory != nil &&
+		(maxMemory.Sign() <= 0 || maxMemory.Value() > maxWasmPages*wasm.PageSize) {
+		errs = append(errs, field.Invalid(path.Child("maxMemory"), maxMemory.String(), "must be between 1 and 4Gi"))
+	}
+
+	if fuel := plugins.Fuel; fuel != nil && *fuel < 0 {
+		errs = append(errs, field.Invalid(path.Child("fuel"), *fuel, "must not be negative"))
+	}
+
+	return errs
+}
+
+func validateAPI(api *configv1alpha1.APIConfig) field.ErrorList {
+	path := field.NewPath("api")
+	errs := validateBindAddress(path.Child("bindAddress"), api.BindAddress)
+
+	if (api.CertFile == "") != (api.KeyFile == "") {
+		errs = append(errs, field.Invalid(path, "", "certFile and keyFile must be set together"))
+	}
+
+	return errs
+}
+
+func validateBindAddress(path *field.Path, address string) field.ErrorList {
+	// "0" disables the endpoint.
+	if address == "0" {
+		return nil
+	}
+
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return field.ErrorList{field.Invalid(path, address, err.Error())}
+	}
+
+	return nil
+}
+
+func knownFeatures() []string {
+	features := make([]string, 0, len(defaultFeatureGates))
+
+	for feature := range defaultFeatureGates {
+		features = append(features, string(feature))
+	}
+
+	sort.Strings(features)
+
+	return features
+}
@@ -0,0 +1,484 @@
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configv1alpha1 "github.com/mykysha/kubCalculator/api/config/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/config"
+	"github.com/mykysha/kubCalculator/pkg/external"
+	"github.com/mykysha/kubCalculator/pkg/httpapi"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/targets"
+	"github.com/mykysha/kubCalculator/pkg/wasm"
+)
+
+const testConfig = `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+metrics:
+  bindAddress: ":9090"
+health:
+  healthProbeBindAddress: ":9091"
+leaderElection:
+  leaderElect: true
+  resourceName: calc.example.com
+  resourceNamespace: calc-system
+logging:
+  development: false
+output:
+  managedBy: tenant-operator
+  resultKey: sum
+limits:
+  evaluationTimeout: 2s
+  maxDigits: 0
+  maxDepth: 8
+watchNamespaces:
+  - team-a
+  - team-b
+evaluators:
+  - name: remote
+    address: evaluator.calc-system.svc:9090
+    timeout: 3s
+    tls:
+      serverName: evaluator.calc-system.svc
+  - name: local
+    address: localhost:9090
+defaultEvaluator: remote
+plugins:
+  path: /etc/calc/plugins
+  maxMemory: 1M
+api:
+  certFile: /etc/calc/tls/tls.crt
+  keyFile: /etc/calc/tls/tls.key
+targets:
+  allowed:
+    - group: apps
+      kind: Deployment
+    - kind: ConfigMap
+encryption:
+  keyringFile: /etc/calc/keys/keyring.yaml
+reconcile:
+  maxConcurrentReconciles: 4
+  rateLimiter:
+    maxDelay: 1m
+  fairQueuing: true
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func newFlagSet(t *testing.T, args ...string) *flag.FlagSet {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int(config.FlagManagerPort, config.DefaultManagerPort, "")
+	fs.String(config.FlagMetricsAddr, config.DefaultMetricsAddr, "")
+	fs.String(config.FlagProbeAddr, config.DefaultProbeAddr, "")
+	fs.Bool(config.FlagLeaderElect, false, "")
+	fs.Bool(config.FlagZapDevel, config.DefaultDevelopment, "")
+	fs.String(config.FlagWatchNamespaces, "", "")
+	fs.Int(config.FlagShardID, 0, "")
+	require.NoError(t, fs.Parse(args))
+
+	return fs
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Load("", newFlagSet(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, config.DefaultManagerPort, *cfg.Webhook.Port)
+	assert.Equal(t, config.DefaultMetricsAddr, cfg.Metrics.BindAddress)
+	assert.Equal(t, config.DefaultProbeAddr, cfg.Health.HealthProbeBindAddress)
+	assert.False(t, *cfg.LeaderElection.LeaderElect)
+	assert.Equal(t, config.DefaultLeaderElectionID, cfg.LeaderElection.ResourceName)
+	assert.True(t, *cfg.Logging.Development)
+	assert.Equal(t, "calc-operator", cfg.Output.ManagedBy)
+	assert.Equal(t, "result", cfg.Output.ResultKey)
+	assert.Equal(t, service.DefaultLimits(), config.Limits(cfg))
+	assert.Empty(t, cfg.WatchNamespaces)
+	assert.False(t, config.APIEnabled(cfg))
+	assert.Empty(t, config.AllowedTargets(cfg))
+	assert.Empty(t, config.KeyringFile(cfg))
+	assert.Equal(t, config.DefaultMaxConcurrentReconciles, config.ControllerOptions(cfg).MaxConcurrentReconciles)
+	assert.Equal(t, config.DefaultRateLimiterBaseDelay, cfg.Reconcile.RateLimiter.BaseDelay.Duration)
+	assert.False(t, cfg.Reconcile.FairQueuing)
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Load(writeConfig(t, testConfig), newFlagSet(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, config.DefaultManagerPort, *cfg.Webhook.Port)
+	assert.Equal(t, ":9090", cfg.Metrics.BindAddress)
+	assert.Equal(t, ":9091", cfg.Health.HealthProbeBindAddress)
+	assert.True(t, *cfg.LeaderElection.LeaderElect)
+	assert.Equal(t, "calc.example.com", cfg.LeaderElection.ResourceName)
+	assert.False(t, *cfg.Logging.Development)
+	assert.Equal(t, "tenant-operator", cfg.Output.ManagedBy)
+	assert.Equal(t, "sum", cfg.Output.ResultKey)
+	assert.Equal(t, service.Limits{
+		Timeout:     2 * time.Second,
+		MaxDigits:   0,
+		MaxDepth:    8,
+		MaxOperands: service.DefaultMaxOperands,
+	}, config.Limits(cfg))
+	assert.Equal(t, []string{"team-a", "team-b"}, cfg.WatchNamespaces)
+	assert.Equal(t, "remote", cfg.DefaultEvaluator)
+	assert.Equal(t, []external.Options{
+		{
+			Name:      "remote",
+			Address:   "evaluator.calc-system.svc:9090",
+			TLS:       &external.TLSOptions{ServerName: "evaluator.calc-system.svc"},
+			Timeout:   3 * time.Second,
+			Retries:   external.DefaultRetries,
+			Output:    service.OutputOptions{ManagedBy: "tenant-operator", ResultKey: "sum"},
+			MaxDigits: 0,
+		},
+		{
+			Name:      "local",
+			Address:   "localhost:9090",
+			Timeout:   external.DefaultTimeout,
+			Retries:   external.DefaultRetries,
+			Output:    service.OutputOptions{ManagedBy: "tenant-operator", ResultKey: "sum"},
+			MaxDigits: 0,
+		},
+	}, config.Evaluators(cfg))
+	assert.Equal(t, wasm.Options{MaxMemoryPages: 16}, config.Plugins(cfg))
+	assert.True(t, config.APIEnabled(cfg))
+	assert.Equal(t, httpapi.DefaultBindAddress, cfg.API.BindAddress)
+	assert.Equal(t, targets.Allowlist{{Group: "apps", Kind: "Deployment"}, {Kind: "ConfigMap"}},
+		config.AllowedTargets(cfg))
+	assert.Equal(t, "/etc/calc/keys/keyring.yaml", config.KeyringFile(cfg))
+	assert.True(t, cfg.Reconcile.FairQueuing)
+
+	options := config.ControllerOptions(cfg)
+	assert.Equal(t, 4, options.MaxConcurrentReconciles)
+
+	// The retries of a request back off exponentially up to the maximum delay.
+	for i := 0; i < 20; i++ {
+		options.RateLimiter.When("request")
+	}
+
+	assert.Equal(t, time.Minute, options.RateLimiter.When("request"))
+}
+
+func TestLoadFlagsOverrideFile(t *testing.T) {
+	t.Parallel()
+
+	fs := newFlagSet(t,
+		"--"+config.FlagManagerPort+"=9444",
+		"--"+config.FlagMetricsAddr+"=:7070",
+		"--"+config.FlagLeaderElect+"=false",
+		"--"+config.FlagZapDevel+"=true",
+		"--"+config.FlagWatchNamespaces+"=team-c, team-d",
+	)
+
+	cfg, err := config.Load(writeConfig(t, testConfig), fs)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9444, *cfg.Webhook.Port)
+	assert.Equal(t, ":7070", cfg.Metrics.BindAddress)
+	assert.Equal(t, ":9091", cfg.Health.HealthProbeBindAddress)
+	assert.False(t, *cfg.LeaderElection.LeaderElect)
+	assert.Equal(t, "calc.example.com", cfg.LeaderElection.ResourceName)
+	assert.True(t, *cfg.Logging.Development)
+	assert.Equal(t, []string{"team-c", "team-d"}, cfg.WatchNamespaces)
+}
+
+func TestLoadSharding(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, config.Shard(&configv1alpha1.OperatorConfig{}))
+
+	cfg, err := config.Load(writeConfig(t, testConfig+`sharding:
+  shardCount: 3
+`), newFlagSet(t, "--"+config.FlagShardID+"=2"))
+	require.NoError(t, err)
+
+	shard := config.Shard(cfg)
+	require.NotNil(t, shard)
+	assert.Equal(t, 2, shard.ID)
+	assert.Equal(t, 3, shard.Count)
+	assert.Equal(t, "calc-system", shard.LeaseNamespace)
+	assert.Equal(t, "calc.example.com-shard-2", shard.LeaseName(shard.ID))
+}
+
+func TestLoadInvalid(t *testing.T) { //nolint:funlen // Test function
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "Wrong kind",
+			content: "apiVersion: config.calc.example.com/v1alpha1\nkind: Calculator\n",
+		},
+		{
+			name: "Unknown field",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+unknown: true
+`,
+		},
+		{
+			name: "Port out of range",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+webhook:
+  port: 70000
+`,
+		},
+		{
+			name: "Bad bind address",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+metrics:
+  bindAddress: "8080"
+`,
+		},
+		{
+			name: "Bad result key",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+output:
+  resultKey: "a/b"
+`,
+		},
+		{
+			name: "Negative timeout",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+limits:
+  evaluationTimeout: -1s
+`,
+		},
+		{
+			name: "Negative limit",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+limits:
+  maxDepth: -1
+`,
+		},
+		{
+			name: "No workers",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+reconcile:
+  maxConcurrentReconciles: 0
+`,
+		},
+		{
+			name: "Max delay below base delay",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+reconcile:
+  rateLimiter:
+    baseDelay: 1s
+    maxDelay: 10ms
+`,
+		},
+		{
+			name: "Zero QPS",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+reconcile:
+  rateLimiter:
+    qps: 0
+`,
+		},
+		{
+			name: "Shard out of range",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+leaderElection:
+  leaderElect: true
+  resourceNamespace: calc-system
+sharding:
+  shardID: 3
+  shardCount: 3
+`,
+		},
+		{
+			name: "Sharding with a ConfigMap lock",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+leaderElection:
+  leaderElect: true
+  resourceLock: configmaps
+  resourceNamespace: calc-system
+sharding:
+  shardCount: 3
+`,
+		},
+		{
+			name: "Sharding with an invalid lease name",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+leaderElection:
+  leaderElect: true
+  resourceName: Calc_Operator
+  resourceNamespace: calc-system
+sharding:
+  shardCount: 3
+`,
+		},
+		{
+			name: "Sharding without leader election",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+sharding:
+  shardCount: 3
+`,
+		},
+		{
+			name: "Duplicate evaluator",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+evaluators:
+  - {name: remote, address: "a:1"}
+  - {name: remote, address: "b:1"}
+`,
+		},
+		{
+			name: "Evaluator without address",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+evaluators:
+  - name: remote
+`,
+		},
+		{
+			name: "Evaluator with cert but no key",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+evaluators:
+  - name: remote
+    address: "a:1"
+    tls:
+      certFile: client.pem
+`,
+		},
+		{
+			name: "Unknown default evaluator",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+defaultEvaluator: remote
+`,
+		},
+		{
+			name: "Plugins without source",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+plugins:
+  fuel: 10
+`,
+		},
+		{
+			name: "Plugins with two sources",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+plugins:
+  path: /etc/calc/plugins
+  configMap: {namespace: calc-system, name: plugins}
+`,
+		},
+		{
+			name: "Plugins memory over 4Gi",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+plugins:
+  path: /etc/calc/plugins
+  maxMemory: 5Gi
+`,
+		},
+		{
+			name: "API with invalid address",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+api:
+  bindAddress: "8082"
+`,
+		},
+		{
+			name: "API with key but no cert",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+api:
+  keyFile: tls.key
+`,
+		},
+		{
+			name: "Target without kind",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+targets:
+  allowed:
+    - group: apps
+`,
+		},
+		{
+			name: "Duplicate namespace",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+watchNamespaces: [team-a, team-a]
+`,
+		},
+		{
+			name: "Invalid namespace",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+watchNamespaces: [Team_A]
+`,
+		},
+		{
+			name: "Invalid namespace selector",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+namespaceSelector:
+  matchExpressions:
+    - key: tenant
+      operator: In
+`,
+		},
+		{
+			name: "Unknown feature gate",
+			content: `apiVersion: config.calc.example.com/v1alpha1
+kind: OperatorConfig
+featureGates:
+  Unknown: true
+`,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := config.Load(writeConfig(t, tt.content), nil)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv1alpha1 "github.com/mykysha/kubCalculator/api/config/v1alpha1"
+)
+
+// ErrNoNamespaces is returned when a namespace selector is set but no namespace is watched.
+var ErrNoNamespaces = errors.New("no namespace to watch")
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// WatchedNamespaces returns the sorted namespaces the operator is restricted to: the listed
+// namespaces plus the ones matching the namespace selector at call time.
+// An empty result means all namespaces are watched.
+func WatchedNamespaces(ctx context.Context, reader client.Reader, cfg *configv1alpha1.OperatorConfig,
+) ([]string, error) {
+	set := make(map[string]bool)
+
+	for _, namespace := range cfg.WatchNamespaces {
+		set[namespace] = true
+	}
+
+	if cfg.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(cfg.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse namespace selector: %w", err)
+		}
+
+		namespaces := &corev1.NamespaceList{}
+
+		err = reader.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: selector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+
+		for _, namespace := range namespaces.Items {
+			set[namespace.Name] = true
+		}
+
+		if len(set) == 0 {
+			return nil, fmt.Errorf("namespace selector %q: %w", selector.String(), ErrNoNamespaces)
+		}
+	}
+
+	watched := make([]string, 0, len(set))
+
+	for namespace := range set {
+		watched = append(watched, namespace)
+	}
+
+	sort.Strings(watched)
+
+	return watched, nil
+}
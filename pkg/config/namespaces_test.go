@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv1alpha1 "github.com/mykysha/kubCalculator/api/config/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/config"
+)
+
+func TestWatchedNamespaces(t *testing.T) { //nolint:funlen // Test function
+	t.Parallel()
+
+	reader := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tenant": "a"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"tenant": "b"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+	).Build()
+
+	// Test table
+	tests := []struct {
+		name    string
+		cfg     *configv1alpha1.OperatorConfig
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "All namespaces",
+			cfg:  &configv1alpha1.OperatorConfig{},
+			want: []string{},
+		},
+		{
+			name: "Listed namespaces",
+			cfg:  &configv1alpha1.OperatorConfig{WatchNamespaces: []string{"team-b", "team-a"}},
+			want: []string{"team-a", "team-b"},
+		},
+		{
+			name: "Selected namespaces",
+			cfg: &configv1alpha1.OperatorConfig{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "tenant", Operator: metav1.LabelSelectorOpExists},
+					},
+				},
+			},
+			want: []string{"team-a", "team-b"},
+		},
+		{
+			name: "Listed and selected namespaces",
+			cfg: &configv1alpha1.OperatorConfig{
+				WatchNamespaces:   []string{"other", "team-a"},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+			},
+			want: []string{"other", "team-a"},
+		},
+		{
+			name: "Selector matches nothing",
+			cfg: &configv1alpha1.OperatorConfig{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "c"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := config.WatchedNamespaces(context.Background(), reader, tt.cfg)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, config.ErrNoNamespaces)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Package encryption encrypts the results written to the output secrets with envelope encryption: every value is
+// encrypted with AES-GCM under a fresh data key, itself encrypted with the primary key of a keyring. The keyring
+// is read from a key Secret or from a file, a stand-in for a KMS.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// KeyringKey is the data key of the keyring in the key Secrets.
+	KeyringKey = "keyring.yaml"
+	// KeyIDAnnotation is the annotation of the output secrets holding the ID of the key of their result.
+	KeyIDAnnotation = "calc.example.com/encryption-key-id"
+
+	dataKeySize = 32
+)
+
+// ErrDecrypt is returned when a value cannot be decrypted, because of a wrong or a missing key or of a tampered
+// value.
+var ErrDecrypt = errors.New("failed to decrypt")
+
+// Keyring holds the key encryption keys by ID. Values are encrypted with the primary key and decrypted with the
+// key they were encrypted with, so the primary key can be rotated while the old keys remain available.
+type Keyring struct {
+	// Primary is the ID of the key new values are encrypted with.
+	Primary string `json:"primary"`
+	// Keys are the AES keys of 16, 24 or 32 bytes, base64 encoded.
+	Keys map[string][]byte `json:"keys"`
+}
+
+// Envelope is an encrypted value.
+type Envelope struct {
+	// KeyID is the ID of the key encrypting the data key.
+	KeyID string `json:"keyID"`
+	// DataKey is the encrypted data key.
+	DataKey []byte `json:"dataKey"`
+	// Ciphertext is the encrypted value.
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// ParseKeyring parses and validates a YAML or JSON keyring.
+func ParseKeyring(content []byte) (*Keyring, error) {
+	keyring := &Keyring{}
+	if err := yaml.UnmarshalStrict(content, keyring); err != nil {
+		return nil, fmt.Errorf("invalid keyring: %w", err)
+	}
+
+	if _, ok := keyring.Keys[keyring.Primary]; !ok {
+		return nil, fmt.Errorf("invalid keyring: primary key %q not found", keyring.Primary)
+	}
+
+	for id, key := range keyring.Keys {
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("invalid keyring: key %q: %w", id, err)
+		}
+	}
+
+	return keyring, nil
+}
+
+// LoadKeyring reads the keyring of a file.
+func LoadKeyring(path string) (*Keyring, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	return ParseKeyring(content)
+}
+
+// Encrypt encrypts the value with a fresh data key, itself encrypted with the primary key. The associated data,
+// such as the namespace and the name of the secret, is authenticated: decrypting requires the same.
+func (k *Keyring) Encrypt(value, associatedData []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	encryptedKey, err := seal(k.Keys[k.Primary], dataKey, []byte(k.Primary))
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(dataKey, value, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := json.Marshal(Envelope{KeyID: k.Primary, DataKey: encryptedKey, Ciphertext: ciphertext})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode envelope: %w", err)
+	}
+
+	return envelope, nil
+}
+
+// Decrypt decrypts an envelope, and returns the value and the ID of its key.
+func (k *Keyring) Decrypt(envelope, associatedData []byte) ([]byte, string, error) {
+	decoded := Envelope{}
+	if err := json.Unmarshal(envelope, &decoded); err != nil {
+		return nil, "", fmt.Errorf("%w: invalid envelope: %v", ErrDecrypt, err) //nolint:errorlint // Single wrap
+	}
+
+	key, ok := k.Keys[decoded.KeyID]
+	if !ok {
+		return nil, decoded.KeyID, fmt.Errorf("%w: key %q not found", ErrDecrypt, decoded.KeyID)
+	}
+
+	dataKey, err := open(key, decoded.DataKey, []byte(decoded.KeyID))
+	if err != nil {
+		return nil, decoded.KeyID, err
+	}
+
+	value, err := open(dataKey, decoded.Ciphertext, associatedData)
+	if err != nil {
+		return nil, decoded.KeyID, err
+	}
+
+	return value, decoded.KeyID, nil
+}
+
+// Checksum returns the HMAC-SHA256 of the value with the primary key, hex encoded, which only changes with the
+// value, the associated data or the primary key. Unlike a plain hash, it cannot be reversed by hashing the likely
+// values, such as small integers, without the key.
+func (k *Keyring) Checksum(value, associatedData []byte) string {
+	mac := hmac.New(sha256.New, k.Keys[k.Primary])
+	mac.Write(associatedData)
+	mac.Write([]byte{0})
+	mac.Write(value)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// seal encrypts the plaintext with AES-GCM, the nonce prefixing the ciphertext.
+func seal(key, plaintext, associatedData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// open decrypts a ciphertext of seal.
+func open(key, ciphertext, associatedData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrDecrypt)
+	}
+
+	plaintext, err := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err) //nolint:errorlint // Single wrap
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES-GCM: %w", err)
+	}
+
+	return aead, nil
+}
+
+// EncryptSecret encrypts every data entry of the secret and records the primary key in the KeyIDAnnotation. The
+// entries of the existing secret, when not nil, are kept when they decrypt to the same value with the primary
+// key: the secret only changes with its values or with the primary key.
+func (k *Keyring) EncryptSecret(secret, existing *corev1.Secret) error {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+
+	for key, value := range secret.StringData {
+		data[key] = []byte(value)
+	}
+
+	encrypted := make(map[string][]byte, len(data))
+
+	for key, value := range data {
+		associatedData := AssociatedData(secret.Namespace, secret.Name, key)
+
+		if existing != nil {
+			if decrypted, keyID, err := k.Decrypt(existing.Data[key], associatedData); err == nil &&
+				keyID == k.Primary && bytes.Equal(decrypted, value) {
+				encrypted[key] = existing.Data[key]
+
+				continue
+			}
+		}
+
+		envelope, err := k.Encrypt(value, associatedData)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", key, err)
+		}
+
+		encrypted[key] = envelope
+	}
+
+	secret.Data, secret.StringData = encrypted, nil
+
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+
+	secret.Annotations[KeyIDAnnotation] = k.Primary
+
+	return nil
+}
+
+// DecryptSecret decrypts the data entries of a secret encrypted by EncryptSecret.
+func (k *Keyring) DecryptSecret(secret *corev1.Secret) (map[string][]byte, error) {
+	data := make(map[string][]byte, len(secret.Data))
+
+	for key, envelope := range secret.Data {
+		value, _, err := k.Decrypt(envelope, AssociatedData(secret.Namespace, secret.Name, key))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		data[key] = value
+	}
+
+	return data, nil
+}
+
+// AssociatedData returns the associated data of an entry of an output secret, binding the value to the entry.
+func AssociatedData(namespace, name, key string) []byte {
+	return []byte(namespace + "/" + name + "/" + key)
+}
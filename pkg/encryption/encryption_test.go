@@ -0,0 +1,169 @@
+package encryption_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mykysha/kubCalculator/pkg/encryption"
+)
+
+// Base64 encoded keys of 32 and 16 bytes.
+const (
+	key1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	key2 = "ZmVkY2JhOTg3NjU0MzIxMA=="
+)
+
+func keyring(t *testing.T, primary string) *encryption.Keyring {
+	t.Helper()
+
+	keyring, err := encryption.ParseKeyring([]byte("primary: " + primary + "\nkeys:\n  k1: " + key1 + "\n  k2: " +
+		key2 + "\n"))
+	require.NoError(t, err)
+
+	return keyring
+}
+
+func TestParseKeyring(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "Valid", content: "primary: k1\nkeys:\n  k1: " + key1},
+		{name: "JSON", content: `{"primary": "k1", "keys": {"k1": "` + key1 + `"}}`},
+		{name: "Missing primary", content: "primary: k2\nkeys:\n  k1: " + key1, wantErr: `primary key "k2" not found`},
+		{name: "Short key", content: "primary: k1\nkeys:\n  k1: a2V5", wantErr: `key "k1": crypto/aes: invalid key size 3`},
+		{name: "Unknown field", content: "primary: k1\nkey: {}", wantErr: "unknown field"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := encryption.ParseKeyring([]byte(tt.content))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "keyring.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("primary: k1\nkeys:\n  k1: "+key1), 0o600))
+
+	keyring, err := encryption.LoadKeyring(path)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", keyring.Primary)
+
+	_, err = encryption.LoadKeyring(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestEncrypt(t *testing.T) {
+	t.Parallel()
+
+	old, rotated := keyring(t, "k1"), keyring(t, "k2")
+	associatedData := encryption.AssociatedData("default", "sum", "result")
+
+	envelope, err := old.Encrypt([]byte("42"), associatedData)
+	require.NoError(t, err)
+	assert.NotContains(t, string(envelope), "42")
+
+	// The old key still decrypts after the rotation.
+	value, keyID, err := rotated.Decrypt(envelope, associatedData)
+	require.NoError(t, err)
+	assert.Equal(t, "42", string(value))
+	assert.Equal(t, "k1", keyID)
+
+	// The value is bound to its secret.
+	_, _, err = rotated.Decrypt(envelope, encryption.AssociatedData("default", "other", "result"))
+	assert.ErrorIs(t, err, encryption.ErrDecrypt)
+
+	tampered := bytes.Replace(envelope, []byte(`"ciphertext":"`), []byte(`"ciphertext":"AAAA`), 1)
+	_, _, err = rotated.Decrypt(tampered, associatedData)
+	assert.ErrorIs(t, err, encryption.ErrDecrypt)
+
+	unknown := &encryption.Keyring{Primary: "k3", Keys: map[string][]byte{"k3": []byte("0123456789abcdef")}}
+	_, _, err = unknown.Decrypt(envelope, associatedData)
+	assert.ErrorIs(t, err, encryption.ErrDecrypt)
+}
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	old, rotated := keyring(t, "k1"), keyring(t, "k2")
+	associatedData := encryption.AssociatedData("default", "sum", "status")
+
+	checksum := old.Checksum([]byte("42"), associatedData)
+	assert.Len(t, checksum, 64)
+	assert.Equal(t, checksum, old.Checksum([]byte("42"), associatedData))
+
+	// The checksum changes with the value, the associated data and the primary key.
+	assert.NotEqual(t, checksum, old.Checksum([]byte("43"), associatedData))
+	assert.NotEqual(t, checksum, old.Checksum([]byte("42"), encryption.AssociatedData("default", "other", "status")))
+	assert.NotEqual(t, checksum, rotated.Checksum([]byte("42"), associatedData))
+}
+
+func TestEncryptSecret(t *testing.T) {
+	t.Parallel()
+
+	newSecret := func(value string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sum", Namespace: "default"},
+			Data:       map[string][]byte{},
+			StringData: map[string]string{"result": value},
+		}
+	}
+
+	old := keyring(t, "k1")
+
+	existing := newSecret("42")
+	require.NoError(t, old.EncryptSecret(existing, nil))
+	assert.Nil(t, existing.StringData)
+	assert.Equal(t, "k1", existing.Annotations[encryption.KeyIDAnnotation])
+
+	data, err := old.DecryptSecret(existing)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"result": []byte("42")}, data)
+
+	// An unchanged value keeps its ciphertext.
+	unchanged := newSecret("42")
+	require.NoError(t, old.EncryptSecret(unchanged, existing))
+	assert.Equal(t, existing.Data, unchanged.Data)
+
+	// A changed value is encrypted again.
+	changed := newSecret("43")
+	require.NoError(t, old.EncryptSecret(changed, existing))
+	assert.NotEqual(t, existing.Data, changed.Data)
+
+	// A rotated primary key re-encrypts the unchanged value.
+	rotated := keyring(t, "k2")
+	reencrypted := newSecret("42")
+	require.NoError(t, rotated.EncryptSecret(reencrypted, existing))
+	assert.NotEqual(t, existing.Data, reencrypted.Data)
+	assert.Equal(t, "k2", reencrypted.Annotations[encryption.KeyIDAnnotation])
+
+	data, err = rotated.DecryptSecret(reencrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "42", string(data["result"]))
+}
@@ -0,0 +1,306 @@
+// Package external delegates calculations to evaluators running out of process, over the gRPC
+// service defined in evaluatorpb/evaluator.proto.
+package external
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/external/evaluatorpb"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+// Defaults of the evaluator calls.
+const (
+	DefaultTimeout = 5 * time.Second
+	DefaultRetries = 2
+	DefaultBackoff = 100 * time.Millisecond
+)
+
+// ErrTLS is returned when the TLS options cannot be loaded.
+var ErrTLS = errors.New("invalid TLS options")
+
+// TLSOptions configures the TLS connection to an evaluator.
+type TLSOptions struct {
+	// CAFile is the PEM file of the certificate authorities, the system pool is used when empty.
+	CAFile string
+	// CertFile and KeyFile are the PEM files of the client certificate, for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name the evaluator certificate is verified against.
+	ServerName string
+	// InsecureSkipVerify disables the verification of the evaluator certificate.
+	InsecureSkipVerify bool
+}
+
+// Options configures a Client.
+type Options struct {
+	// Name identifies the evaluator in errors.
+	Name string
+	// Address is the gRPC target of the evaluator.
+	Address string
+	// TLS secures the connection, it is in plain text when nil.
+	TLS *TLSOptions
+	// Timeout is the maximum duration of a single call, zero disables it.
+	Timeout time.Duration
+	// Retries is the number of times a call failing with a transient error is retried.
+	Retries int
+	// Backoff is the delay before the first retry, doubled after each retry. Defaults to DefaultBackoff.
+	Backoff time.Duration
+	// Output configures the defaults of the result secrets.
+	Output service.OutputOptions
+	// MaxDigits is the maximum number of decimal digits of a result, zero disables it.
+	MaxDigits int
+	// DialOptions are appended to the options of the connection.
+	DialOptions []grpc.DialOption
+}
+
+// Client is a service.Repository delegating to an external evaluator.
+type Client struct {
+	name    string
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+	output  service.OutputOptions
+	limits  service.Limits
+	conn    *grpc.ClientConn
+	client  evaluatorpb.EvaluatorClient
+}
+
+// Dial returns a Client of the evaluator. The connection is established lazily, an unreachable
+// evaluator fails the calls and not the dial.
+func Dial(opts Options) (*Client, error) {
+	creds := insecure.NewCredentials()
+
+	if opts.TLS != nil {
+		tlsConfig, err := opts.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, opts.DialOptions...)
+
+	conn, err := grpc.Dial(opts.Address, dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial evaluator %s: %w", opts.Name, err)
+	}
+
+	backoff := opts.Backoff
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+
+	return &Client{
+		name:    opts.Name,
+		timeout: opts.Timeout,
+		retries: opts.Retries,
+		backoff: backoff,
+		output:  opts.Output,
+		limits:  service.Limits{MaxDigits: opts.MaxDigits},
+		conn:    conn,
+		client:  evaluatorpb.NewEvaluatorClient(conn),
+	}, nil
+}
+
+func (o *TLSOptions) config() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify, //nolint:gosec // Explicitly configured
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrTLS, err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificate in %s", ErrTLS, o.CAFile)
+		}
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrTLS, err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// Close closes the connection to the evaluator.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ProcessCalculator evaluates the calculator with the external evaluator. The calculators using fields of the
+// spec the evaluator does not receive are invalid.
+func (c *Client) ProcessCalculator(ctx context.Context, calc *calcv1alpha1.Calculator) error {
+	if errs := unsupportedFields(calc); len(errs) > 0 {
+		return service.Invalidf("%s", errs.ToAggregate())
+	}
+
+	req := &evaluatorpb.ProcessRequest{
+		Name:       calc.Name,
+		Namespace:  calc.Namespace,
+		X:          int64(calc.Spec.X),
+		Y:          int64(calc.Spec.Y),
+		Expression: calc.Spec.Expression,
+	}
+
+	var resp *evaluatorpb.ProcessResponse
+
+	err := c.call(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.client.Process(ctx, req)
+
+		return err //nolint:wrapcheck // Converted by call
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = c.limits.CheckDigits(resp.Value); err != nil {
+		return err
+	}
+
+	calc.Status.Result = 0
+	if i, ok := new(big.Int).SetString(resp.Value, 10); ok && i.IsInt64() { //nolint:gomnd // Decimal
+		calc.Status.Result = int(i.Int64())
+	}
+
+	calc.Status.Value = resp.Value
+	calc.Status.Processed = true
+
+	return nil
+}
+
+// DefineSecret defines the result secret with the data and the annotations of the external evaluator.
+func (c *Client) DefineSecret(ctx context.Context, name, namespace string, value string) (*corev1.Secret, error) {
+	req := &evaluatorpb.DefineOutputRequest{Name: name, Namespace: namespace, Value: value}
+
+	var resp *evaluatorpb.DefineOutputResponse
+
+	err := c.call(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.client.DefineOutput(ctx, req)
+
+		return err //nolint:wrapcheck // Converted by call
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := service.CalculatorService{Output: c.output}.DefineSecret(ctx, name, namespace, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to define secret: %w", err)
+	}
+
+	if len(resp.Data) > 0 {
+		secret.StringData = resp.Data
+	}
+
+	for key, annotation := range resp.Annotations {
+		if _, exists := secret.Annotations[key]; !exists {
+			secret.Annotations[key] = annotation
+		}
+	}
+
+	return secret, nil
+}
+
+// ValidateCalculator rejects the fields of the spec the evaluator does not receive, the external evaluator
+// validates the expressions when processing.
+func (c *Client) ValidateCalculator(calc *calcv1alpha1.Calculator) field.ErrorList {
+	return unsupportedFields(calc)
+}
+
+// unsupportedFields returns the errors of the fields of the spec that are not part of a ProcessRequest: the
+// evaluators only take the integers x and y and render their results themselves.
+func unsupportedFields(calc *calcv1alpha1.Calculator) field.ErrorList {
+	var errs field.ErrorList
+
+	spec := field.NewPath("spec")
+
+	if mode := calc.Spec.Mode; mode != "" && mode != calcv1alpha1.ModeInteger {
+		errs = append(errs, field.NotSupported(spec.Child("mode"), mode, []string{string(calcv1alpha1.ModeInteger)}))
+	}
+
+	if len(calc.Spec.Variables) > 0 {
+		errs = append(errs, field.Forbidden(spec.Child("variables"), "not supported by external evaluators"))
+	}
+
+	if output := calc.Spec.Output; output != nil {
+		path := spec.Child("output")
+
+		if output.Precision != nil {
+			errs = append(errs, field.Forbidden(path.Child("precision"), "not supported by external evaluators"))
+		}
+
+		if output.ComplexFormat != "" {
+			errs = append(errs, field.Forbidden(path.Child("complexFormat"), "not supported by external evaluators"))
+		}
+
+		if output.TimeZone != "" {
+			errs = append(errs, field.Forbidden(path.Child("timeZone"), "not supported by external evaluators"))
+		}
+	}
+
+	return errs
+}
+
+// call runs the call with the timeout, retrying transient failures with an exponential backoff.
+func (c *Client) call(ctx context.Context, call func(ctx context.Context) error) error {
+	backoff := c.backoff
+
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, call)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= c.retries || !retryable(err) {
+			return c.fromStatus(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return c.fromStatus(err)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, call func(ctx context.Context) error) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	return call(ctx)
+}
@@ -0,0 +1,273 @@
+package external_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/external"
+	"github.com/mykysha/kubCalculator/pkg/external/externaltest"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+func newClient(t *testing.T, server *externaltest.Server, opts external.Options) *external.Client {
+	t.Helper()
+
+	opts.Name = "fake"
+	opts.Address = externaltest.Address
+	opts.Backoff = time.Millisecond
+	opts.DialOptions = append(opts.DialOptions, server.DialOption())
+
+	client, err := external.Dial(opts)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, client.Close())
+	})
+
+	return client
+}
+
+func newCalculator(expression string) *calcv1alpha1.Calculator {
+	return &calcv1alpha1.Calculator{
+		ObjectMeta: metav1.ObjectMeta{Name: "calc", Namespace: "default"},
+		Spec:       calcv1alpha1.CalculatorSpec{X: 6, Y: 7, Expression: expression},
+	}
+}
+
+func TestClientProcessCalculator(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name       string
+		expression string
+		failures   int
+		retries    int
+		wantValue  string
+		wantCalls  int
+		check      func(err error) bool
+	}{
+		{
+			name:      "Default expression",
+			wantValue: "13",
+			wantCalls: 1,
+		},
+		{
+			name:       "Big result",
+			expression: "x ^ 30",
+			wantValue:  "221073919720733357899776",
+			wantCalls:  1,
+		},
+		{
+			name:       "Invalid expression",
+			expression: "x / 0",
+			retries:    2,
+			wantCalls:  1,
+			check:      service.IsInvalid,
+		},
+		{
+			name:       "Remote limit",
+			expression: "x ^ 3000",
+			retries:    2,
+			wantCalls:  1,
+			check:      service.IsLimitExceeded,
+		},
+		{
+			name:      "Retried transient failures",
+			failures:  2,
+			retries:   2,
+			wantValue: "13",
+			wantCalls: 3,
+		},
+		{
+			name:      "Retries exhausted",
+			failures:  3,
+			retries:   2,
+			wantCalls: 3,
+			check: func(err error) bool {
+				return err != nil && !service.IsInvalid(err) && !service.IsLimitExceeded(err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := externaltest.NewServer(service.CalculatorService{Limits: service.DefaultLimits()})
+			defer server.Close()
+
+			server.FailNext(tt.failures)
+
+			client := newClient(t, server, external.Options{Retries: tt.retries})
+			calc := newCalculator(tt.expression)
+
+			err := client.ProcessCalculator(context.Background(), calc)
+			assert.Equal(t, tt.wantCalls, server.Calls())
+
+			if tt.check != nil {
+				assert.True(t, tt.check(err), "unexpected error %v", err)
+				assert.False(t, calc.Status.Processed)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, calc.Status.Processed)
+			assert.Equal(t, tt.wantValue, calc.Status.Value)
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := externaltest.NewServer(service.CalculatorService{})
+	defer server.Close()
+
+	server.SetDelay(time.Second)
+
+	client := newClient(t, server, external.Options{Timeout: 20 * time.Millisecond, Retries: 2})
+
+	err := client.ProcessCalculator(context.Background(), newCalculator(""))
+
+	var limitErr *service.LimitExceededError
+
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, service.LimitTimeout, limitErr.Limit)
+	assert.Equal(t, 1, server.Calls())
+}
+
+func TestClientMaxDigits(t *testing.T) {
+	t.Parallel()
+
+	server := externaltest.NewServer(service.CalculatorService{})
+	defer server.Close()
+
+	client := newClient(t, server, external.Options{MaxDigits: 3})
+
+	assert.True(t, service.IsLimitExceeded(client.ProcessCalculator(context.Background(), newCalculator("x * 1000"))))
+}
+
+func TestClientDefineSecret(t *testing.T) {
+	t.Parallel()
+
+	server := externaltest.NewServer(service.CalculatorService{
+		Output: service.OutputOptions{ManagedBy: "remote", ResultKey: "sum"},
+	})
+	defer server.Close()
+
+	client := newClient(t, server, external.Options{
+		Output: service.OutputOptions{ManagedBy: "calc-operator"},
+	})
+
+	secret, err := client.DefineSecret(context.Background(), "calc", "default", "13")
+	require.NoError(t, err)
+
+	assert.Equal(t, "calc", secret.Name)
+	assert.Equal(t, "default", secret.Namespace)
+	assert.Equal(t, map[string]string{"sum": "13"}, secret.StringData)
+	// The operator owns the managed-by annotation.
+	assert.Equal(t, map[string]string{"managed-by": "calc-operator"}, secret.Annotations)
+}
+
+func TestClientUnsupportedFields(t *testing.T) { //nolint:funlen // Test table
+	t.Parallel()
+
+	precision := int32(2)
+
+	// Test table
+	tests := []struct {
+		name     string
+		spec     func(spec *calcv1alpha1.CalculatorSpec)
+		wantErrs []string
+	}{
+		{
+			name: "Integer mode",
+			spec: func(spec *calcv1alpha1.CalculatorSpec) { spec.Mode = calcv1alpha1.ModeInteger },
+		},
+		{
+			name: "Secret encryption",
+			spec: func(spec *calcv1alpha1.CalculatorSpec) {
+				spec.Output = &calcv1alpha1.OutputSpec{Encryption: &calcv1alpha1.EncryptionSpec{}}
+			},
+		},
+		{
+			name:     "Rational mode",
+			spec:     func(spec *calcv1alpha1.CalculatorSpec) { spec.Mode = calcv1alpha1.ModeRational },
+			wantErrs: []string{"spec.mode"},
+		},
+		{
+			name: "Variables",
+			spec: func(spec *calcv1alpha1.CalculatorSpec) {
+				spec.Variables = []calcv1alpha1.Variable{{Name: "z", Type: calcv1alpha1.VariableTypeComplex, Value: "1i"}}
+			},
+			wantErrs: []string{"spec.variables"},
+		},
+		{
+			name: "Rendering of the result",
+			spec: func(spec *calcv1alpha1.CalculatorSpec) {
+				spec.Output = &calcv1alpha1.OutputSpec{
+					Precision: &precision, ComplexFormat: calcv1alpha1.ComplexFormatPolar, TimeZone: "UTC",
+				}
+			},
+			wantErrs: []string{"spec.output.precision", "spec.output.complexFormat", "spec.output.timeZone"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := externaltest.NewServer(service.CalculatorService{})
+			defer server.Close()
+
+			client := newClient(t, server, external.Options{})
+			calc := newCalculator("")
+			tt.spec(&calc.Spec)
+
+			var fields []string
+
+			for _, err := range client.ValidateCalculator(calc) {
+				fields = append(fields, err.Field)
+			}
+
+			assert.Equal(t, tt.wantErrs, fields)
+
+			err := client.ProcessCalculator(context.Background(), calc)
+			if tt.wantErrs == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			// The invalid calculators are not sent.
+			assert.True(t, service.IsInvalid(err), "unexpected error %v", err)
+			assert.False(t, calc.Status.Processed)
+			assert.Equal(t, 0, server.Calls())
+		})
+	}
+}
+
+func TestDialInvalidTLS(t *testing.T) {
+	t.Parallel()
+
+	_, err := external.Dial(external.Options{
+		Name:    "tls",
+		Address: "localhost:9090",
+		TLS:     &external.TLSOptions{CAFile: "does-not-exist.pem"},
+	})
+
+	assert.ErrorIs(t, err, external.ErrTLS)
+}
@@ -0,0 +1,64 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+// LimitEvaluator is the limit reported when a calculation exceeds a limit of an external evaluator.
+const LimitEvaluator = "evaluator"
+
+// retryable reports whether a call failing with the error may succeed when retried.
+func retryable(err error) bool {
+	switch status.Code(err) { //nolint:exhaustive // Any other code is permanent
+	case codes.Unavailable, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// fromStatus converts the status of a failed call into the errors of the service package, so that
+// the reconciler handles remote failures like local ones.
+func (c *Client) fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("evaluator %s: %w", c.name, err)
+	}
+
+	switch st.Code() { //nolint:exhaustive // Any other code is a transient failure
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return service.Invalidf("evaluator %s: %s", c.name, st.Message())
+	case codes.ResourceExhausted:
+		return &service.LimitExceededError{Limit: LimitEvaluator, Message: fmt.Sprintf("%s: %s", c.name, st.Message())}
+	case codes.DeadlineExceeded:
+		return &service.LimitExceededError{
+			Limit:   service.LimitTimeout,
+			Message: fmt.Sprintf("evaluator %s took more than %s", c.name, c.timeout),
+		}
+	default:
+		return fmt.Errorf("evaluator %s: %w", c.name, err)
+	}
+}
+
+// toStatus converts the errors of the service package into gRPC statuses.
+func toStatus(err error) error {
+	switch {
+	case service.IsInvalid(err):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case service.IsLimitExceeded(err):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
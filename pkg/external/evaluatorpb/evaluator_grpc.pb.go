@@ -0,0 +1,145 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: pkg/external/evaluatorpb/evaluator.proto
+
+package evaluatorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// EvaluatorClient is the client API for Evaluator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EvaluatorClient interface {
+	// Process evaluates the expression of a Calculator.
+	Process(ctx context.Context, in *ProcessRequest, opts ...grpc.CallOption) (*ProcessResponse, error)
+	// DefineOutput defines the content of the result secret of a Calculator.
+	DefineOutput(ctx context.Context, in *DefineOutputRequest, opts ...grpc.CallOption) (*DefineOutputResponse, error)
+}
+
+type evaluatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEvaluatorClient(cc grpc.ClientConnInterface) EvaluatorClient {
+	return &evaluatorClient{cc}
+}
+
+func (c *evaluatorClient) Process(ctx context.Context, in *ProcessRequest, opts ...grpc.CallOption) (*ProcessResponse, error) {
+	out := new(ProcessResponse)
+	err := c.cc.Invoke(ctx, "/calc.evaluator.v1.Evaluator/Process", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *evaluatorClient) DefineOutput(ctx context.Context, in *DefineOutputRequest, opts ...grpc.CallOption) (*DefineOutputResponse, error) {
+	out := new(DefineOutputResponse)
+	err := c.cc.Invoke(ctx, "/calc.evaluator.v1.Evaluator/DefineOutput", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EvaluatorServer is the server API for Evaluator service.
+// All implementations must embed UnimplementedEvaluatorServer
+// for forward compatibility
+type EvaluatorServer interface {
+	// Process evaluates the expression of a Calculator.
+	Process(context.Context, *ProcessRequest) (*ProcessResponse, error)
+	// DefineOutput defines the content of the result secret of a Calculator.
+	DefineOutput(context.Context, *DefineOutputRequest) (*DefineOutputResponse, error)
+	mustEmbedUnimplementedEvaluatorServer()
+}
+
+// UnimplementedEvaluatorServer must be embedded to have forward compatible implementations.
+type UnimplementedEvaluatorServer struct {
+}
+
+func (UnimplementedEvaluatorServer) Process(context.Context, *ProcessRequest) (*ProcessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Process not implemented")
+}
+func (UnimplementedEvaluatorServer) DefineOutput(context.Context, *DefineOutputRequest) (*DefineOutputResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DefineOutput not implemented")
+}
+func (UnimplementedEvaluatorServer) mustEmbedUnimplementedEvaluatorServer() {}
+
+// UnsafeEvaluatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EvaluatorServer will
+// result in compilation errors.
+type UnsafeEvaluatorServer interface {
+	mustEmbedUnimplementedEvaluatorServer()
+}
+
+func RegisterEvaluatorServer(s grpc.ServiceRegistrar, srv EvaluatorServer) {
+	s.RegisterService(&Evaluator_ServiceDesc, srv)
+}
+
+func _Evaluator_Process_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvaluatorServer).Process(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calc.evaluator.v1.Evaluator/Process",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvaluatorServer).Process(ctx, req.(*ProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Evaluator_DefineOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DefineOutputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvaluatorServer).DefineOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calc.evaluator.v1.Evaluator/DefineOutput",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvaluatorServer).DefineOutput(ctx, req.(*DefineOutputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Evaluator_ServiceDesc is the grpc.ServiceDesc for Evaluator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Evaluator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "calc.evaluator.v1.Evaluator",
+	HandlerType: (*EvaluatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Process",
+			Handler:    _Evaluator_Process_Handler,
+		},
+		{
+			MethodName: "DefineOutput",
+			Handler:    _Evaluator_DefineOutput_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/external/evaluatorpb/evaluator.proto",
+}
@@ -0,0 +1,134 @@
+// Package externaltest runs an in-process external evaluator for tests.
+package externaltest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/mykysha/kubCalculator/pkg/external"
+	"github.com/mykysha/kubCalculator/pkg/external/evaluatorpb"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+const bufferSize = 1 << 20
+
+// Address is the address to dial the server at, with the dial option of the server.
+const Address = "bufnet"
+
+// Server is an external evaluator served over an in-memory connection. It evaluates calculations
+// with a CalculatorService and can inject failures and delays.
+type Server struct {
+	evaluatorpb.UnimplementedEvaluatorServer
+
+	evaluator external.Server
+	listener  *bufconn.Listener
+	server    *grpc.Server
+
+	mu       sync.Mutex
+	calls    int
+	failures int
+	delay    time.Duration
+}
+
+// NewServer starts a server evaluating calculations with the service.
+func NewServer(calculatorService service.CalculatorService) *Server {
+	s := &Server{
+		evaluator: external.Server{Repository: calculatorService},
+		listener:  bufconn.Listen(bufferSize),
+		server:    grpc.NewServer(),
+	}
+
+	evaluatorpb.RegisterEvaluatorServer(s.server, s)
+
+	go func() {
+		_ = s.server.Serve(s.listener)
+	}()
+
+	return s
+}
+
+// DialOption connects a client to the server, dial Address with it.
+func (s *Server) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.listener.DialContext(ctx)
+	})
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.server.Stop()
+}
+
+// FailNext makes the next calls fail with UNAVAILABLE.
+func (s *Server) FailNext(calls int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = calls
+}
+
+// SetDelay delays every call.
+func (s *Server) SetDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delay = delay
+}
+
+// Calls returns the number of calls received.
+func (s *Server) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+// Process evaluates the calculation.
+func (s *Server) Process(ctx context.Context, req *evaluatorpb.ProcessRequest) (*evaluatorpb.ProcessResponse, error) {
+	if err := s.intercept(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.evaluator.Process(ctx, req) //nolint:wrapcheck // gRPC status
+}
+
+// DefineOutput defines the secret of the calculation.
+func (s *Server) DefineOutput(ctx context.Context, req *evaluatorpb.DefineOutputRequest,
+) (*evaluatorpb.DefineOutputResponse, error) {
+	if err := s.intercept(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.evaluator.DefineOutput(ctx, req) //nolint:wrapcheck // gRPC status
+}
+
+func (s *Server) intercept(ctx context.Context) error {
+	s.mu.Lock()
+	s.calls++
+
+	fail := s.failures > 0
+	if fail {
+		s.failures--
+	}
+
+	delay := s.delay
+	s.mu.Unlock()
+
+	if fail {
+		return status.Error(codes.Unavailable, "injected failure")
+	}
+
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
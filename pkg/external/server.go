@@ -0,0 +1,58 @@
+package external
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/external/evaluatorpb"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+// Server serves a service.Repository as an external evaluator, to implement evaluators in Go.
+type Server struct {
+	evaluatorpb.UnimplementedEvaluatorServer
+
+	// Repository processes the calculations.
+	Repository service.Repository
+}
+
+// Process evaluates the calculation with the repository.
+func (s *Server) Process(ctx context.Context, req *evaluatorpb.ProcessRequest) (*evaluatorpb.ProcessResponse, error) {
+	calc := &calcv1alpha1.Calculator{
+		ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace},
+		Spec: calcv1alpha1.CalculatorSpec{
+			X:          int(req.X),
+			Y:          int(req.Y),
+			Expression: req.Expression,
+		},
+	}
+
+	if err := s.Repository.ProcessCalculator(ctx, calc); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &evaluatorpb.ProcessResponse{Value: calc.Status.Value}, nil
+}
+
+// DefineOutput defines the secret data and annotations with the repository.
+func (s *Server) DefineOutput(ctx context.Context, req *evaluatorpb.DefineOutputRequest,
+) (*evaluatorpb.DefineOutputResponse, error) {
+	secret, err := s.Repository.DefineSecret(ctx, req.Name, req.Namespace, req.Value)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	data := make(map[string]string, len(secret.StringData)+len(secret.Data))
+
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+
+	for key, value := range secret.StringData {
+		data[key] = value
+	}
+
+	return &evaluatorpb.DefineOutputResponse{Data: data, Annotations: secret.Annotations}, nil
+}
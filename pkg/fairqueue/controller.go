@@ -0,0 +1,283 @@
+package fairqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const defaultCacheSyncTimeout = 2 * time.Minute
+
+var errStarted = errors.New("controller was started more than once")
+
+// Controller is a controller handing its requests out with a Queue, the namespaces being its tenants. It runs
+// the watches and the workers as the controllers of controller-runtime, which do not let their queue be replaced,
+// and records the same controller_runtime_reconcile metrics.
+type Controller struct {
+	name         string
+	reconcile    reconcile.Reconciler
+	workers      int
+	timeout      time.Duration
+	recoverPanic bool
+	queue        *Queue
+	setFields    func(interface{}) error
+	logger       logr.Logger
+
+	mu      sync.Mutex
+	started bool
+	ctx     context.Context //nolint:containedctx // Starts the watches added after the controller
+	watches []watch
+}
+
+type watch struct {
+	src        source.Source
+	handler    handler.EventHandler
+	predicates []predicate.Predicate
+}
+
+var _ controller.Controller = &Controller{}
+
+// New returns a controller added to the manager. It uses the MaxConcurrentReconciles, RateLimiter,
+// CacheSyncTimeout and RecoverPanic options, with the defaults of controller-runtime.
+func New(name string, mgr manager.Manager, options controller.Options) (*Controller, error) {
+	if options.Reconciler == nil {
+		return nil, fmt.Errorf("controller %s has no reconciler", name) //nolint:goerr113 // Usage error
+	}
+
+	if options.MaxConcurrentReconciles <= 0 {
+		options.MaxConcurrentReconciles = 1
+	}
+
+	if options.CacheSyncTimeout == 0 {
+		options.CacheSyncTimeout = defaultCacheSyncTimeout
+	}
+
+	if options.RateLimiter == nil {
+		options.RateLimiter = workqueue.DefaultControllerRateLimiter()
+	}
+
+	if err := mgr.SetFields(options.Reconciler); err != nil {
+		return nil, fmt.Errorf("failed to inject the reconciler: %w", err)
+	}
+
+	c := &Controller{
+		name:         name,
+		reconcile:    options.Reconciler,
+		workers:      options.MaxConcurrentReconciles,
+		timeout:      options.CacheSyncTimeout,
+		recoverPanic: options.RecoverPanic,
+		queue:        NewQueue(options.RateLimiter, Namespace),
+		setFields:    mgr.SetFields,
+		logger:       mgr.GetLogger().WithValues("controller", name),
+	}
+
+	if err := mgr.Add(c); err != nil {
+		return nil, fmt.Errorf("failed to add controller %s: %w", name, err)
+	}
+
+	return c, nil
+}
+
+// Reconcile reconciles a request with the reconciler of the controller. A panic of the reconciler is returned as
+// an error with the RecoverPanic option.
+func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (_ reconcile.Result, err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		if !c.recoverPanic {
+			log.FromContext(ctx).Info(fmt.Sprintf("Observed a panic in reconciler: %v", r))
+			panic(r)
+		}
+
+		for _, handle := range utilruntime.PanicHandlers {
+			handle(r)
+		}
+
+		err = fmt.Errorf("panic: %v [recovered]", r) //nolint:goerr113 // Panic of the reconciler
+	}()
+
+	return c.reconcile.Reconcile(ctx, req) //nolint:wrapcheck // Errors of the reconciler
+}
+
+// Watch enqueues the requests of the handler for the events of the source.
+func (c *Controller) Watch(src source.Source, eventHandler handler.EventHandler,
+	predicates ...predicate.Predicate,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, injected := range append([]interface{}{src, eventHandler}, toInterfaces(predicates)...) {
+		if err := c.setFields(injected); err != nil {
+			return fmt.Errorf("failed to inject the watch: %w", err)
+		}
+	}
+
+	if !c.started {
+		c.watches = append(c.watches, watch{src: src, handler: eventHandler, predicates: predicates})
+
+		return nil
+	}
+
+	return src.Start(c.ctx, eventHandler, c.queue, predicates...) //nolint:wrapcheck // Errors of the source
+}
+
+// Start starts the watches and the workers, and blocks until the context is done.
+func (c *Controller) Start(ctx context.Context) error {
+	c.mu.Lock()
+
+	if c.started {
+		c.mu.Unlock()
+
+		return errStarted
+	}
+
+	c.initMetrics()
+
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+
+	err := c.startWatches(ctx)
+	if err != nil {
+		c.mu.Unlock()
+
+		return err
+	}
+
+	c.logger.Info("Starting workers", "worker count", c.workers)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(c.workers)
+
+	for i := 0; i < c.workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for c.processNextItem(ctx) {
+			}
+		}()
+	}
+
+	c.started = true
+	c.mu.Unlock()
+
+	<-ctx.Done()
+	c.logger.Info("Shutdown signal received, waiting for all workers to finish")
+	wg.Wait()
+
+	return nil
+}
+
+// GetLogger returns the logger of the controller.
+func (c *Controller) GetLogger() logr.Logger {
+	return c.logger
+}
+
+func (c *Controller) startWatches(ctx context.Context) error {
+	c.ctx = ctx
+
+	for _, w := range c.watches {
+		c.logger.Info("Starting EventSource", "source", fmt.Sprintf("%s", w.src))
+
+		if err := w.src.Start(ctx, w.handler, c.queue, w.predicates...); err != nil {
+			return fmt.Errorf("failed to start the watch of %s: %w", w.src, err)
+		}
+	}
+
+	for _, w := range c.watches {
+		syncing, ok := w.src.(source.SyncingSource)
+		if !ok {
+			continue
+		}
+
+		syncCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		err := syncing.WaitForSync(syncCtx)
+
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("failed to wait for %s caches to sync: %w", c.name, err)
+		}
+	}
+
+	c.watches = nil
+
+	return nil
+}
+
+// processNextItem reconciles the next request, it returns false once the queue is shut down.
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	item, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+
+	defer c.queue.Done(item)
+
+	activeWorkers.WithLabelValues(c.name).Add(1)
+	defer activeWorkers.WithLabelValues(c.name).Add(-1)
+
+	start := time.Now()
+	defer func() {
+		reconcileTime.WithLabelValues(c.name).Observe(time.Since(start).Seconds())
+	}()
+
+	req, ok := item.(reconcile.Request)
+	if !ok {
+		c.queue.Forget(item)
+		c.logger.Error(nil, "Queue item was not a Request", "type", fmt.Sprintf("%T", item))
+
+		return true
+	}
+
+	logger := c.logger.WithValues("namespace", req.Namespace, "name", req.Name, "reconcileID", uuid.NewUUID())
+
+	result, err := c.Reconcile(log.IntoContext(ctx, logger), req)
+
+	switch {
+	case err != nil:
+		c.queue.AddRateLimited(req)
+		reconcileErrors.WithLabelValues(c.name).Inc()
+		reconcileTotal.WithLabelValues(c.name, labelError).Inc()
+		logger.Error(err, "Reconciler error")
+	case result.RequeueAfter > 0:
+		c.queue.Forget(req)
+		c.queue.AddAfter(req, result.RequeueAfter)
+		reconcileTotal.WithLabelValues(c.name, labelRequeueAfter).Inc()
+	case result.Requeue:
+		c.queue.AddRateLimited(req)
+		reconcileTotal.WithLabelValues(c.name, labelRequeue).Inc()
+	default:
+		c.queue.Forget(req)
+		reconcileTotal.WithLabelValues(c.name, labelSuccess).Inc()
+	}
+
+	return true
+}
+
+func toInterfaces(predicates []predicate.Predicate) []interface{} {
+	values := make([]interface{}, 0, len(predicates))
+	for _, p := range predicates {
+		values = append(values, p)
+	}
+
+	return values
+}
@@ -0,0 +1,134 @@
+package fairqueue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/mykysha/kubCalculator/pkg/fairqueue"
+)
+
+// newManager returns a manager which does not connect to a cluster.
+func newManager(t *testing.T) manager.Manager {
+	t.Helper()
+
+	mgr, err := manager.New(&rest.Config{Host: "https://localhost:0"}, manager.Options{
+		MetricsBindAddress:     "0",
+		HealthProbeBindAddress: "0",
+		MapperProvider: func(*rest.Config) (meta.RESTMapper, error) {
+			return meta.NewDefaultRESTMapper(nil), nil
+		},
+	})
+	require.NoError(t, err)
+
+	return mgr
+}
+
+// metricValue returns the value of the counter of the controller with the label values.
+func metricValue(t *testing.T, name, controllerName string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			values := map[string]string{}
+			for _, pair := range metric.GetLabel() {
+				values[pair.GetName()] = pair.GetValue()
+			}
+
+			if values["controller"] != controllerName {
+				continue
+			}
+
+			matches := true
+
+			for label, value := range labels {
+				matches = matches && values[label] == value
+			}
+
+			if matches {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestControllerRecoverPanic(t *testing.T) {
+	t.Parallel()
+
+	const name = "test-recover-panic"
+
+	done := make(chan struct{})
+	calls := 0
+
+	mgr := newManager(t)
+	c, err := fairqueue.New(name, mgr, controller.Options{
+		RecoverPanic: true,
+		RateLimiter:  workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond),
+		Reconciler: reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			calls++
+			if calls == 1 {
+				panic("first call")
+			}
+
+			close(done)
+
+			return reconcile.Result{}, nil
+		}),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Watch(source.Func(func(_ context.Context, _ handler.EventHandler,
+		queue workqueue.RateLimitingInterface, _ ...predicate.Predicate,
+	) error {
+		queue.Add(request("team-a", 1))
+
+		return nil
+	}), &handler.EnqueueRequestForObject{}))
+
+	// The metrics are global, a baseline keeps the test repeatable.
+	errorsBefore := metricValue(t, "controller_runtime_reconcile_errors_total", name, nil)
+	successesBefore := metricValue(t, "controller_runtime_reconcile_total", name, map[string]string{"result": "success"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		assert.NoError(t, c.Start(ctx))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request was not reconciled again after the panic")
+	}
+
+	cancel()
+
+	assert.Equal(t, errorsBefore+1, metricValue(t, "controller_runtime_reconcile_errors_total", name, nil))
+	assert.Eventually(t, func() bool {
+		return metricValue(t, "controller_runtime_reconcile_total", name,
+			map[string]string{"result": "success"}) == successesBefore+1
+	}, 5*time.Second, time.Millisecond)
+}
@@ -0,0 +1,80 @@
+package fairqueue
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Labels of the results of the reconciliations.
+const (
+	labelError        = "error"
+	labelRequeueAfter = "requeue_after"
+	labelRequeue      = "requeue"
+	labelSuccess      = "success"
+)
+
+// The metrics of the controllers of controller-runtime, which are registered by its internal package. Registering
+// the same metrics again returns them, so that the fair queue controller is observed the same way.
+var (
+	reconcileTotal = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_reconcile_total",
+		Help: "Total number of reconciliations per controller",
+	}, []string{"controller", "result"}))
+
+	reconcileErrors = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_reconcile_errors_total",
+		Help: "Total number of reconciliation errors per controller",
+	}, []string{"controller"}))
+
+	reconcileTime = register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "controller_runtime_reconcile_time_seconds",
+		Help: "Length of time per reconciliation per controller",
+		Buckets: []float64{
+			0.005, 0.01, 0.025, 0.05, 0.1, 0.15, 0.2, 0.25, 0.3, 0.35, 0.4, 0.45, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0,
+			1.25, 1.5, 1.75, 2.0, 2.5, 3.0, 3.5, 4.0, 4.5, 5, 6, 7, 8, 9, 10, 15, 20, 25, 30, 40, 50, 60,
+		},
+	}, []string{"controller"}))
+
+	workerCount = register(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "controller_runtime_max_concurrent_reconciles",
+		Help: "Maximum number of concurrent reconciles per controller",
+	}, []string{"controller"}))
+
+	activeWorkers = register(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "controller_runtime_active_workers",
+		Help: "Number of currently used workers per controller",
+	}, []string{"controller"}))
+)
+
+// register registers the collector with the metrics of controller-runtime, and returns the collector registered
+// first with the same description.
+func register[T prometheus.Collector](collector T) T {
+	err := metrics.Registry.Register(collector)
+
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(T); ok {
+			return existing
+		}
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return collector
+}
+
+// initMetrics sets the metrics of the controller, so that they are exported before its first reconciliation.
+func (c *Controller) initMetrics() {
+	activeWorkers.WithLabelValues(c.name).Set(0)
+	reconcileErrors.WithLabelValues(c.name).Add(0)
+
+	for _, result := range []string{labelError, labelRequeueAfter, labelRequeue, labelSuccess} {
+		reconcileTotal.WithLabelValues(c.name, result).Add(0)
+	}
+
+	workerCount.WithLabelValues(c.name).Set(float64(c.workers))
+}
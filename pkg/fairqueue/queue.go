@@ -0,0 +1,244 @@
+// Package fairqueue provides a work queue sharing the workers of a controller fairly between tenants, and a
+// controller using it.
+//
+// A controller-runtime controller hands out its requests first in, first out: a tenant creating a burst of
+// objects delays the reconciliation of every other tenant until the burst is processed. The Queue keeps a
+// queue per tenant and takes the items of the tenants in turn, a tenant waits for at most one item of each
+// other tenant.
+package fairqueue
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TenantFunc returns the tenant an item of the queue belongs to.
+type TenantFunc func(item interface{}) string
+
+// Namespace is the TenantFunc of the reconcile requests, the tenants being the namespaces.
+func Namespace(item interface{}) string {
+	req, ok := item.(reconcile.Request)
+	if !ok {
+		return ""
+	}
+
+	return req.Namespace
+}
+
+// Queue is a rate limiting work queue handing out the items of its tenants in turn. As the client-go queues, it
+// holds an item once: an item added while queued is ignored, an item added while processed is queued again when
+// it is done.
+type Queue struct {
+	cond        *sync.Cond
+	rateLimiter workqueue.RateLimiter
+	tenant      TenantFunc
+
+	// tenants are the tenants with queued items in their turn order, next is the index of the next one.
+	tenants []string
+	next    int
+	queues  map[string][]interface{}
+	length  int
+
+	// dirty are the items to process, queued or waiting for their processing to be done.
+	dirty      map[interface{}]bool
+	processing map[interface{}]bool
+	timers     map[*time.Timer]bool
+
+	shuttingDown bool
+}
+
+var _ workqueue.RateLimitingInterface = &Queue{}
+
+// NewQueue returns a queue delaying the rate limited items with the rate limiter.
+func NewQueue(rateLimiter workqueue.RateLimiter, tenant TenantFunc) *Queue {
+	return &Queue{
+		cond:        sync.NewCond(&sync.Mutex{}),
+		rateLimiter: rateLimiter,
+		tenant:      tenant,
+		queues:      make(map[string][]interface{}),
+		dirty:       make(map[interface{}]bool),
+		processing:  make(map[interface{}]bool),
+		timers:      make(map[*time.Timer]bool),
+	}
+}
+
+// Add queues the item at the end of the queue of its tenant.
+func (q *Queue) Add(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.shuttingDown || q.dirty[item] {
+		return
+	}
+
+	q.dirty[item] = true
+
+	if q.processing[item] {
+		return
+	}
+
+	q.push(item)
+}
+
+// Len returns the number of queued items.
+func (q *Queue) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.length
+}
+
+// Get blocks until an item is queued and returns the next item of the next tenant, shutdown is true once the
+// queue is shut down.
+func (q *Queue) Get() (interface{}, bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for q.length == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+
+	if q.length == 0 {
+		return nil, true
+	}
+
+	item := q.pop()
+	q.processing[item] = true
+	delete(q.dirty, item)
+
+	return item, false
+}
+
+// Done marks the item as processed, queueing it again if it was added meanwhile.
+func (q *Queue) Done(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	delete(q.processing, item)
+
+	if q.dirty[item] {
+		q.push(item)
+	}
+
+	// Wakes up ShutDownWithDrain.
+	q.cond.Broadcast()
+}
+
+// ShutDown stops handing out items, Get returns immediately once the queue is shut down.
+func (q *Queue) ShutDown() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.shuttingDown = true
+
+	for timer := range q.timers {
+		timer.Stop()
+	}
+
+	q.timers = make(map[*time.Timer]bool)
+
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain shuts the queue down and waits for the items being processed to be done.
+func (q *Queue) ShutDownWithDrain() {
+	q.ShutDown()
+
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for len(q.processing) > 0 {
+		q.cond.Wait()
+	}
+}
+
+// ShuttingDown reports whether the queue is shut down.
+func (q *Queue) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.shuttingDown
+}
+
+// AddAfter adds the item once the duration has passed.
+func (q *Queue) AddAfter(item interface{}, duration time.Duration) {
+	if duration <= 0 {
+		q.Add(item)
+
+		return
+	}
+
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.shuttingDown {
+		return
+	}
+
+	var timer *time.Timer
+
+	timer = time.AfterFunc(duration, func() {
+		q.cond.L.Lock()
+		delete(q.timers, timer)
+		q.cond.L.Unlock()
+
+		q.Add(item)
+	})
+
+	q.timers[timer] = true
+}
+
+// AddRateLimited adds the item once the rate limiter allows it.
+func (q *Queue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+// Forget resets the rate limiting of the item.
+func (q *Queue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+// NumRequeues returns the number of times the item was rate limited since it was last forgotten.
+func (q *Queue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
+
+// push queues the item, its tenant taking its turn after the others if it had no queued items.
+func (q *Queue) push(item interface{}) {
+	tenant := q.tenant(item)
+
+	if len(q.queues[tenant]) == 0 {
+		q.tenants = append(q.tenants, tenant)
+	}
+
+	q.queues[tenant] = append(q.queues[tenant], item)
+	q.length++
+
+	q.cond.Signal()
+}
+
+// pop removes the first item of the next tenant, which is dropped from the turns once it has no queued items.
+func (q *Queue) pop() interface{} {
+	tenant := q.tenants[q.next]
+	item := q.queues[tenant][0]
+
+	if len(q.queues[tenant]) == 1 {
+		delete(q.queues, tenant)
+		q.tenants = append(q.tenants[:q.next], q.tenants[q.next+1:]...)
+	} else {
+		q.queues[tenant][0] = nil
+		q.queues[tenant] = q.queues[tenant][1:]
+		q.next++
+	}
+
+	if q.next >= len(q.tenants) {
+		q.next = 0
+	}
+
+	q.length--
+
+	return item
+}
@@ -0,0 +1,184 @@
+package fairqueue_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/mykysha/kubCalculator/pkg/fairqueue"
+)
+
+func request(namespace string, i int) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: fmt.Sprint(i)}}
+}
+
+func newQueue() *fairqueue.Queue {
+	return fairqueue.NewQueue(workqueue.DefaultControllerRateLimiter(), fairqueue.Namespace)
+}
+
+// drain gets and marks done every queued item, returning them in order.
+func drain(t *testing.T, queue workqueue.Interface) []interface{} {
+	t.Helper()
+
+	var items []interface{}
+
+	for queue.Len() > 0 {
+		item, shutdown := queue.Get()
+		require.False(t, shutdown)
+
+		queue.Done(item)
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func TestQueueRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	queue := newQueue()
+
+	for i := 0; i < 3; i++ {
+		queue.Add(request("noisy", i))
+	}
+
+	queue.Add(request("quiet", 0))
+	queue.Add(request("other", 0))
+	queue.Add(request("quiet", 1))
+
+	assert.Equal(t, []interface{}{
+		request("noisy", 0), request("quiet", 0), request("other", 0),
+		request("noisy", 1), request("quiet", 1),
+		request("noisy", 2),
+	}, drain(t, queue))
+}
+
+func TestQueueDeduplication(t *testing.T) {
+	t.Parallel()
+
+	queue := newQueue()
+
+	queue.Add(request("a", 0))
+	queue.Add(request("a", 0))
+	assert.Equal(t, 1, queue.Len())
+
+	// An item added while processed is queued again once done.
+	item, _ := queue.Get()
+	queue.Add(item)
+	assert.Equal(t, 0, queue.Len())
+
+	queue.Done(item)
+	assert.Equal(t, []interface{}{request("a", 0)}, drain(t, queue))
+}
+
+func TestQueueAddAfter(t *testing.T) {
+	t.Parallel()
+
+	queue := newQueue()
+
+	queue.AddAfter(request("a", 0), time.Millisecond)
+	assert.Equal(t, 0, queue.Len())
+
+	item, shutdown := queue.Get()
+	assert.False(t, shutdown)
+	assert.Equal(t, request("a", 0), item)
+	queue.Done(item)
+
+	queue.AddRateLimited(item)
+	assert.Equal(t, 1, queue.NumRequeues(item))
+
+	queue.Forget(item)
+	assert.Equal(t, 0, queue.NumRequeues(item))
+}
+
+func TestQueueShutDown(t *testing.T) {
+	t.Parallel()
+
+	queue := newQueue()
+
+	queue.Add(request("a", 0))
+	item, _ := queue.Get()
+
+	drained := make(chan struct{})
+
+	go func() {
+		defer close(drained)
+
+		queue.ShutDownWithDrain()
+	}()
+
+	assert.Eventually(t, queue.ShuttingDown, time.Second, time.Millisecond)
+
+	// Nothing is added once shut down.
+	queue.Add(request("b", 0))
+	assert.Equal(t, 0, queue.Len())
+
+	_, shutdown := queue.Get()
+	assert.True(t, shutdown)
+
+	select {
+	case <-drained:
+		t.Fatal("the queue was drained before the item was done")
+	default:
+	}
+
+	queue.Done(item)
+	<-drained
+}
+
+// BenchmarkFairness measures the delay of a tenant queueing a few requests right after a burst of another tenant,
+// as the number of requests processed before its last one.
+func BenchmarkFairness(b *testing.B) {
+	const (
+		burst = 1000
+		quiet = 10
+	)
+
+	for _, bb := range []struct {
+		name  string
+		queue func() workqueue.RateLimitingInterface
+	}{
+		{name: "FIFO", queue: func() workqueue.RateLimitingInterface {
+			return workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		}},
+		{name: "Fair", queue: func() workqueue.RateLimitingInterface { return newQueue() }},
+	} {
+		bb := bb
+
+		b.Run(bb.name, func(b *testing.B) {
+			var waited int
+
+			for n := 0; n < b.N; n++ {
+				queue := bb.queue()
+
+				for i := 0; i < burst; i++ {
+					queue.Add(request("noisy", i))
+				}
+
+				for i := 0; i < quiet; i++ {
+					queue.Add(request("quiet", i))
+				}
+
+				for processed, remaining := 1, quiet; remaining > 0; processed++ {
+					item, _ := queue.Get()
+					queue.Done(item)
+
+					if item.(reconcile.Request).Namespace == "quiet" { //nolint:forcetypeassert // Requests only
+						remaining--
+						waited = processed
+					}
+				}
+
+				queue.ShutDown()
+			}
+
+			b.ReportMetric(float64(waited), "requests-before-quiet-tenant")
+		})
+	}
+}
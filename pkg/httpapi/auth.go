@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Authenticator authenticates the bearer tokens of the requests.
+type Authenticator interface {
+	// Authenticate returns the user of the token, false when the token is not valid.
+	Authenticate(ctx context.Context, token string) (authenticationv1.UserInfo, bool, error)
+}
+
+// Authorizer authorizes the authenticated users.
+type Authorizer interface {
+	// Authorize reports whether the user may perform the action, with the reason of the decision.
+	Authorize(ctx context.Context, user authenticationv1.UserInfo, attributes authorizationv1.ResourceAttributes,
+	) (bool, string, error)
+}
+
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// KubernetesAuth delegates the authentication to TokenReviews and the authorization to
+// SubjectAccessReviews, the requests are allowed exactly as they would be by the API server.
+type KubernetesAuth struct {
+	Client client.Client
+	// Audiences the tokens must be issued for. The API server audiences are used when empty.
+	Audiences []string
+}
+
+// Authenticate reviews the token with a TokenReview.
+func (a *KubernetesAuth) Authenticate(ctx context.Context, token string) (authenticationv1.UserInfo, bool, error) {
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: a.Audiences,
+		},
+	}
+
+	if err := a.Client.Create(ctx, review); err != nil {
+		return authenticationv1.UserInfo{}, false, fmt.Errorf("failed to create token review: %w", err)
+	}
+
+	if review.Status.Error != "" {
+		return authenticationv1.UserInfo{}, false, nil
+	}
+
+	return review.Status.User, review.Status.Authenticated, nil
+}
+
+// Authorize reviews the access with a SubjectAccessReview.
+func (a *KubernetesAuth) Authorize(ctx context.Context, user authenticationv1.UserInfo,
+	attributes authorizationv1.ResourceAttributes,
+) (bool, string, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+		},
+	}
+
+	if err := a.Client.Create(ctx, review); err != nil {
+		return false, "", fmt.Errorf("failed to create subject access review: %w", err)
+	}
+
+	return review.Status.Allowed && !review.Status.Denied, review.Status.Reason, nil
+}
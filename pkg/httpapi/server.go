@@ -0,0 +1,486 @@
+// Package httpapi serves calculations over a REST/JSON API, without creating Calculators.
+//
+// The API has the following endpoints:
+//
+//	POST /v1/evaluate                                 evaluates {"x", "y", "expression", "variables", "mode",
+//	                                                  "output", "evaluator"}
+//	GET  /v1/calculators                              lists the Calculators of all namespaces
+//	GET  /v1/namespaces/{namespace}/calculators       lists the Calculators of a namespace
+//	GET  /v1/namespaces/{namespace}/calculators/{name} gets a Calculator
+//
+// The Calculators are read from the manager cache. Every request is authenticated with a bearer token
+// and authorized against the calculators resource, evaluations against its evaluate subresource.
+// Failures are reported as metav1.Status objects.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+const (
+	// DefaultBindAddress is the address the API listens on when the configuration sets none.
+	DefaultBindAddress = ":8082"
+	// MaxRequestBytes bounds the size of a request body.
+	MaxRequestBytes = 64 << 10
+	// MaxExpressionLength is the maximum length of an expression, as for spec.expression.
+	MaxExpressionLength = 4096
+	// SubresourceEvaluate is the subresource of calculators the evaluations are authorized against.
+	SubresourceEvaluate = "evaluate"
+
+	// StatusReasonLimitExceeded is the reason of the calculations exceeding an operator limit.
+	StatusReasonLimitExceeded metav1.StatusReason = "LimitExceeded"
+
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+var calculatorsResource = calcv1alpha1.GroupVersion.WithResource("calculators")
+
+// EvaluateRequest is the body of POST /v1/evaluate.
+type EvaluateRequest struct {
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Expression string `json:"expression,omitempty"`
+	// Variables are the typed operands of the expression besides x and y, as spec.variables.
+	Variables []calcv1alpha1.Variable `json:"variables,omitempty"`
+	// Mode is the number system of x, y and the number literals, as spec.mode.
+	Mode calcv1alpha1.Mode `json:"mode,omitempty"`
+	// Output configures the rendering of the result.
+	Output *Output `json:"output,omitempty"`
+	// Evaluator is the name of an external evaluator, as spec.evaluatorRef.name.
+	Evaluator string `json:"evaluator,omitempty"`
+}
+
+// Output configures the rendering of the result, as the fields of spec.output of the same names.
+type Output struct {
+	Precision     *int32                     `json:"precision,omitempty"`
+	ComplexFormat calcv1alpha1.ComplexFormat `json:"complexFormat,omitempty"`
+	TimeZone      string                     `json:"timeZone,omitempty"`
+}
+
+// EvaluateResponse is the result of POST /v1/evaluate.
+type EvaluateResponse struct {
+	// Value is the canonical form of the result.
+	Value string `json:"value"`
+	// Decimal is the decimal form of a rational result, at the precision of the output.
+	Decimal string `json:"decimal,omitempty"`
+}
+
+// Calculation is a Calculator and its result.
+type Calculation struct {
+	Namespace  string             `json:"namespace"`
+	Name       string             `json:"name"`
+	X          int                `json:"x"`
+	Y          int                `json:"y"`
+	Expression string             `json:"expression,omitempty"`
+	Evaluator  string             `json:"evaluator,omitempty"`
+	Mode       calcv1alpha1.Mode  `json:"mode,omitempty"`
+	Processed  bool               `json:"processed"`
+	Value      string             `json:"value,omitempty"`
+	Decimal    string             `json:"decimal,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// CalculationList is the result of the list endpoints, sorted by namespace and name.
+type CalculationList struct {
+	Items []Calculation `json:"items"`
+}
+
+// Server is the REST API, a manager.Runnable.
+type Server struct {
+	// BindAddress is the address the server listens on.
+	BindAddress string
+	// CertFile and KeyFile serve the API over TLS. It is served in plain text when empty.
+	CertFile string
+	KeyFile  string
+
+	// Repository evaluates the requests without an evaluator.
+	Repository service.Repository
+	// Evaluators evaluate the requests selecting them by name.
+	Evaluators map[string]service.Repository
+	// Reader reads the Calculators, the manager cache.
+	Reader client.Reader
+
+	Authenticator Authenticator
+	Authorizer    Authorizer
+}
+
+// NeedLeaderElection is false, every replica serves the API.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the API until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("httpapi")
+
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		logger.Info("Serving the REST API", "address", s.BindAddress, "tls", s.CertFile != "")
+
+		if s.CertFile != "" {
+			errCh <- server.ListenAndServeTLS(s.CertFile, s.KeyFile)
+		} else {
+			errCh <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed to serve the REST API: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil { //nolint:contextcheck // The context is done
+		return fmt.Errorf("failed to shut down the REST API: %w", err)
+	}
+
+	return nil
+}
+
+// Handler returns the handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/evaluate", s.evaluate)
+	mux.HandleFunc("/v1/calculators", s.listAll)
+	mux.HandleFunc("/v1/namespaces/", s.namespaced)
+
+	return mux
+}
+
+func (s *Server) evaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, apierrors.NewMethodNotSupported(calculatorsResource.GroupResource(), r.Method))
+
+		return
+	}
+
+	if !s.authorize(w, r, authorizationv1.ResourceAttributes{Verb: "create", Subresource: SubresourceEvaluate}) {
+		return
+	}
+
+	var request EvaluateRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, apierrors.NewBadRequest(fmt.Sprintf("failed to decode request: %v", err)))
+
+		return
+	}
+
+	calc := &calcv1alpha1.Calculator{
+		Spec: calcv1alpha1.CalculatorSpec{
+			X:          request.X,
+			Y:          request.Y,
+			Expression: request.Expression,
+			Variables:  request.Variables,
+			Mode:       request.Mode,
+		},
+	}
+
+	if output := request.Output; output != nil {
+		calc.Spec.Output = &calcv1alpha1.OutputSpec{
+			Precision:     output.Precision,
+			ComplexFormat: output.ComplexFormat,
+			TimeZone:      output.TimeZone,
+		}
+	}
+
+	if err := validateRequest(calc); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	repository := s.Repository
+
+	if request.Evaluator != "" {
+		calc.Spec.EvaluatorRef = &calcv1alpha1.EvaluatorReference{Name: request.Evaluator}
+
+		var ok bool
+		if repository, ok = s.Evaluators[request.Evaluator]; !ok {
+			writeError(w, invalid(fmt.Sprintf("evaluator %q is not configured", request.Evaluator)))
+
+			return
+		}
+	}
+
+	err := repository.ProcessCalculator(r.Context(), calc)
+
+	switch {
+	case service.IsLimitExceeded(err):
+		writeError(w, &apierrors.StatusError{ErrStatus: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnprocessableEntity,
+			Reason:  StatusReasonLimitExceeded,
+			Message: err.Error(),
+		}})
+	case service.IsInvalid(err):
+		writeError(w, invalid(err.Error()))
+	case err != nil:
+		log.FromContext(r.Context()).Error(err, "Failed to evaluate")
+		writeError(w, apierrors.NewInternalError(err))
+	default:
+		writeJSON(w, http.StatusOK, EvaluateResponse{Value: calc.Status.Value, Decimal: calc.Status.Decimal})
+	}
+}
+
+func (s *Server) listAll(w http.ResponseWriter, r *http.Request) {
+	s.list(w, r, "")
+}
+
+// namespaced serves /v1/namespaces/{namespace}/calculators[/{name}].
+func (s *Server) namespaced(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/namespaces/"), "/")
+
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] == calculatorsResource.Resource:
+		s.list(w, r, parts[0])
+	case len(parts) == 3 && parts[0] != "" && parts[1] == calculatorsResource.Resource && parts[2] != "":
+		s.get(w, r, types.NamespacedName{Namespace: parts[0], Name: parts[2]})
+	default:
+		writeError(w, apierrors.NewGenericServerResponse(http.StatusNotFound, r.Method,
+			calculatorsResource.GroupResource(), "", fmt.Sprintf("path %s not found", r.URL.Path), 0, false))
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, namespace string) {
+	if r.Method != http.MethodGet {
+		writeError(w, apierrors.NewMethodNotSupported(calculatorsResource.GroupResource(), r.Method))
+
+		return
+	}
+
+	if !s.authorize(w, r, authorizationv1.ResourceAttributes{Verb: "list", Namespace: namespace}) {
+		return
+	}
+
+	calculators := &calcv1alpha1.CalculatorList{}
+	if err := s.Reader.List(r.Context(), calculators, client.InNamespace(namespace)); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	items := make([]Calculation, 0, len(calculators.Items))
+	for i := range calculators.Items {
+		items = append(items, calculation(&calculators.Items[i]))
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+
+		return items[i].Name < items[j].Name
+	})
+
+	writeJSON(w, http.StatusOK, CalculationList{Items: items})
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, key types.NamespacedName) {
+	if r.Method != http.MethodGet {
+		writeError(w, apierrors.NewMethodNotSupported(calculatorsResource.GroupResource(), r.Method))
+
+		return
+	}
+
+	if !s.authorize(w, r, authorizationv1.ResourceAttributes{
+		Verb:      "get",
+		Namespace: key.Namespace,
+		Name:      key.Name,
+	}) {
+		return
+	}
+
+	calc := &calcv1alpha1.Calculator{}
+	if err := s.Reader.Get(r.Context(), key, calc); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, calculation(calc))
+}
+
+// authorize authenticates the request and authorizes it on the calculators resource. The request is
+// answered when it is not authorized.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, attributes authorizationv1.ResourceAttributes,
+) bool {
+	token, ok := bearerToken(r)
+	if !ok {
+		writeError(w, apierrors.NewUnauthorized("a bearer token is required"))
+
+		return false
+	}
+
+	user, authenticated, err := s.Authenticator.Authenticate(r.Context(), token)
+	if err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to authenticate")
+		writeError(w, apierrors.NewInternalError(err))
+
+		return false
+	}
+
+	if !authenticated {
+		writeError(w, apierrors.NewUnauthorized("the bearer token is not valid"))
+
+		return false
+	}
+
+	attributes.Group = calculatorsResource.Group
+	attributes.Version = calculatorsResource.Version
+	attributes.Resource = calculatorsResource.Resource
+
+	allowed, reason, err := s.Authorizer.Authorize(r.Context(), user, attributes)
+	if err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to authorize")
+		writeError(w, apierrors.NewInternalError(err))
+
+		return false
+	}
+
+	if !allowed {
+		writeError(w, forbidden(user, attributes, reason))
+
+		return false
+	}
+
+	return true
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "bearer "
+
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(header[len(prefix):])
+
+	return token, token != ""
+}
+
+// validateRequest checks the fields of the calculator of a request which are bounded by the Calculator schema.
+func validateRequest(calc *calcv1alpha1.Calculator) error {
+	for name, value := range map[string]int{"x": calc.Spec.X, "y": calc.Spec.Y} {
+		if value < math.MinInt32 || value > math.MaxInt32 {
+			return invalid(fmt.Sprintf("%s must be a 32-bit integer", name))
+		}
+	}
+
+	if len(calc.Spec.Expression) > MaxExpressionLength {
+		return invalid(fmt.Sprintf("expression must be at most %d characters", MaxExpressionLength))
+	}
+
+	if errs := service.ValidateSchema(&calc.Spec); len(errs) > 0 {
+		return invalid(errs.ToAggregate().Error())
+	}
+
+	return nil
+}
+
+func calculation(calc *calcv1alpha1.Calculator) Calculation {
+	result := Calculation{
+		Namespace:  calc.Namespace,
+		Name:       calc.Name,
+		X:          calc.Spec.X,
+		Y:          calc.Spec.Y,
+		Expression: calc.Spec.Expression,
+		Mode:       calc.Spec.Mode,
+		Processed:  calc.Status.Processed,
+		Value:      calc.Status.Value,
+		Decimal:    calc.Status.Decimal,
+		Conditions: calc.Status.Conditions,
+	}
+
+	if calc.Spec.EvaluatorRef != nil {
+		result.Evaluator = calc.Spec.EvaluatorRef.Name
+	}
+
+	return result
+}
+
+func invalid(message string) *apierrors.StatusError {
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusUnprocessableEntity,
+		Reason:  metav1.StatusReasonInvalid,
+		Message: message,
+	}}
+}
+
+func forbidden(user authenticationv1.UserInfo, attributes authorizationv1.ResourceAttributes, reason string,
+) *apierrors.StatusError {
+	resource := schema.GroupResource{Group: attributes.Group, Resource: attributes.Resource}
+	if attributes.Subresource != "" {
+		resource.Resource += "/" + attributes.Subresource
+	}
+
+	message := fmt.Sprintf("user %q cannot %s", user.Username, attributes.Verb)
+	if attributes.Namespace != "" {
+		message += fmt.Sprintf(" in the namespace %q", attributes.Namespace)
+	}
+
+	if reason != "" {
+		message += ": " + reason
+	}
+
+	return apierrors.NewForbidden(resource, attributes.Name, errors.New(message))
+}
+
+// writeError writes the status of an API error, other errors are internal errors.
+func writeError(w http.ResponseWriter, err error) {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		status = apierrors.NewInternalError(err)
+	}
+
+	body := status.Status()
+	body.Kind, body.APIVersion = "Status", "v1"
+
+	writeJSON(w, int(body.Code), body)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(body) //nolint:errchkjson // The client is gone
+}
@@ -0,0 +1,352 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/httpapi"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+const token = "valid-token"
+
+// auth authenticates the valid token as alice, who may do everything in the default namespace.
+type auth struct{}
+
+func (auth) Authenticate(_ context.Context, token string) (authenticationv1.UserInfo, bool, error) {
+	return authenticationv1.UserInfo{Username: "alice"}, token == "valid-token", nil
+}
+
+func (auth) Authorize(_ context.Context, user authenticationv1.UserInfo,
+	attributes authorizationv1.ResourceAttributes,
+) (bool, string, error) {
+	if attributes.Group != "calc.example.com" || attributes.Resource != "calculators" {
+		return false, "unexpected resource", nil
+	}
+
+	if attributes.Subresource == httpapi.SubresourceEvaluate {
+		return attributes.Verb == "create", "", nil
+	}
+
+	return user.Username == "alice" && attributes.Namespace == "default", "only the default namespace", nil
+}
+
+func newServer(t *testing.T) *httpapi.Server {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, calcv1alpha1.AddToScheme(scheme))
+
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "sum", Namespace: "default"},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 2, Y: 3},
+			Status:     calcv1alpha1.CalculatorStatus{Processed: true, Result: 5, Value: "5"},
+		},
+		&calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "product", Namespace: "default"},
+			Spec:       calcv1alpha1.CalculatorSpec{X: 2, Y: 3, Expression: "x * y"},
+		},
+		&calcv1alpha1.Calculator{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+		},
+	).Build()
+
+	return &httpapi.Server{
+		Repository:    &service.CalculatorService{Limits: service.Limits{MaxDigits: 10}},
+		Reader:        reader,
+		Authenticator: auth{},
+		Authorizer:    auth{},
+	}
+}
+
+func TestServer(t *testing.T) { //nolint:funlen // Test function
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		token    string
+		wantCode int
+		want     string
+	}{
+		{
+			name:     "Evaluate",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"x": 7, "y": 3, "expression": "x * y + 1"}`,
+			token:    token,
+			wantCode: http.StatusOK,
+			want:     `{"value":"22"}`,
+		},
+		{
+			name:     "Evaluate the default expression",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"x": 7, "y": 3}`,
+			token:    token,
+			wantCode: http.StatusOK,
+			want:     `{"value":"10"}`,
+		},
+		{
+			name:   "Evaluate in Rational mode",
+			method: http.MethodPost,
+			path:   "/v1/evaluate",
+			body: `{"x": 1, "y": 3, "expression": "x / y + 1", "mode": "Rational",` +
+				` "output": {"precision": 4}}`,
+			token:    token,
+			wantCode: http.StatusOK,
+			want:     `{"value":"4/3","decimal":"1.3333"}`,
+		},
+		{
+			name:   "Evaluate with variables",
+			method: http.MethodPost,
+			path:   "/v1/evaluate",
+			body: `{"expression": "z * 2", "variables": [{"name": "z", "type": "Complex", "value": "3+4i"}],` +
+				` "output": {"complexFormat": "Polar"}}`,
+			token:    token,
+			wantCode: http.StatusOK,
+			want:     `{"value":"10∠0.9272952180016122"}`,
+		},
+		{
+			name:     "Unsupported mode",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"mode": "Octal"}`,
+			token:    token,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Precision out of range",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"mode": "Rational", "output": {"precision": 1000000}}`,
+			token:    token,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Invalid variable",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"expression": "z", "variables": [{"name": "z", "type": "Complex", "value": "i3"}]}`,
+			token:    token,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Invalid expression",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"x": 7, "y": 3, "expression": "x +"}`,
+			token:    token,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Limit exceeded",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"x": 10, "y": 20, "expression": "x ^ y"}`,
+			token:    token,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Operand out of range",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"x": 4294967296}`,
+			token:    token,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Unknown evaluator",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"evaluator": "remote"}`,
+			token:    token,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Unknown field",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"z": 1}`,
+			token:    token,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Evaluate with GET",
+			method:   http.MethodGet,
+			path:     "/v1/evaluate",
+			token:    token,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "No token",
+			method:   http.MethodPost,
+			path:     "/v1/evaluate",
+			body:     `{"x": 7, "y": 3}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Invalid token",
+			method:   http.MethodGet,
+			path:     "/v1/namespaces/default/calculators",
+			token:    "invalid",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "List a namespace",
+			method:   http.MethodGet,
+			path:     "/v1/namespaces/default/calculators",
+			token:    token,
+			wantCode: http.StatusOK,
+			want: `{"items":[` +
+				`{"namespace":"default","name":"product","x":2,"y":3,"expression":"x * y","processed":false},` +
+				`{"namespace":"default","name":"sum","x":2,"y":3,"processed":true,"value":"5"}]}`,
+		},
+		{
+			name:     "List all namespaces is forbidden",
+			method:   http.MethodGet,
+			path:     "/v1/calculators",
+			token:    token,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "List another namespace is forbidden",
+			method:   http.MethodGet,
+			path:     "/v1/namespaces/other/calculators",
+			token:    token,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Get",
+			method:   http.MethodGet,
+			path:     "/v1/namespaces/default/calculators/sum",
+			token:    token,
+			wantCode: http.StatusOK,
+			want:     `{"namespace":"default","name":"sum","x":2,"y":3,"processed":true,"value":"5"}`,
+		},
+		{
+			name:     "Get a missing calculator",
+			method:   http.MethodGet,
+			path:     "/v1/namespaces/default/calculators/missing",
+			token:    token,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Unknown path",
+			method:   http.MethodGet,
+			path:     "/v1/namespaces/default/secrets",
+			token:    token,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			recorder := httptest.NewRecorder()
+			newServer(t).Handler().ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.wantCode, recorder.Code, recorder.Body.String())
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+			if tt.want != "" {
+				assert.JSONEq(t, tt.want, recorder.Body.String())
+
+				return
+			}
+
+			// Failures are statuses.
+			status := &metav1.Status{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), status))
+			assert.Equal(t, "Status", status.Kind)
+			assert.Equal(t, int32(tt.wantCode), status.Code)
+		})
+	}
+}
+
+// reviewer answers the TokenReviews and SubjectAccessReviews created through it.
+type reviewer struct {
+	client.Client
+	sar *authorizationv1.SubjectAccessReview
+}
+
+func (r *reviewer) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		if review.Spec.Token == token {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{
+				Username: "alice",
+				Groups:   []string{"calculators"},
+				Extra:    map[string]authenticationv1.ExtraValue{"scope": {"calc"}},
+			}
+		}
+	case *authorizationv1.SubjectAccessReview:
+		r.sar = review
+		review.Status.Allowed = review.Spec.User == "alice"
+		review.Status.Reason = "reviewed"
+	}
+
+	return nil
+}
+
+func TestKubernetesAuth(t *testing.T) {
+	t.Parallel()
+
+	reviews := &reviewer{Client: fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()}
+	auth := &httpapi.KubernetesAuth{Client: reviews}
+	ctx := context.Background()
+
+	// Authenticate
+	_, authenticated, err := auth.Authenticate(ctx, "invalid")
+	require.NoError(t, err)
+	assert.False(t, authenticated)
+
+	user, authenticated, err := auth.Authenticate(ctx, token)
+	require.NoError(t, err)
+	assert.True(t, authenticated)
+	assert.Equal(t, "alice", user.Username)
+
+	// Authorize
+	attributes := authorizationv1.ResourceAttributes{Verb: "list", Resource: "calculators", Namespace: "default"}
+
+	allowed, reason, err := auth.Authorize(ctx, user, attributes)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, "reviewed", reason)
+	assert.Equal(t, &attributes, reviews.sar.Spec.ResourceAttributes)
+	assert.Equal(t, []string{"calculators"}, reviews.sar.Spec.Groups)
+	assert.Equal(t, authorizationv1.ExtraValue{"calc"}, reviews.sar.Spec.Extra["scope"])
+
+	allowed, _, err = auth.Authorize(ctx, authenticationv1.UserInfo{Username: "bob"}, attributes)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,209 @@
+// Package quota computes the usage of the Calculators of a namespace and enforces the CalculatorQuotas on it.
+package quota
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/targets"
+)
+
+// Names of the limits of a CalculatorQuota.
+const (
+	LimitCalculators    = "calculators"
+	LimitEvaluationCost = "evaluationCost"
+	LimitOutputs        = "outputs"
+)
+
+// ExceededError reports Calculators exceeding a CalculatorQuota.
+type ExceededError struct {
+	// Quota is the name of the exceeded quota.
+	Quota string
+	// Limits describe the exceeded limits.
+	Limits []string
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("exceeded quota %s: %s", e.Quota, strings.Join(e.Limits, ", "))
+}
+
+// Cost returns the evaluation cost of the calculator: the number of operations and operands of its expression.
+// The expressions the operator cannot parse, such as those of external evaluators, cost their length.
+func Cost(calc *calcv1alpha1.Calculator) int64 {
+	node, err := service.Parse(service.Expression(calc))
+	if err != nil {
+		return int64(len(service.Expression(calc)))
+	}
+
+	return int64(nodes(node))
+}
+
+func nodes(node service.Node) int {
+	call, ok := node.(*service.Call)
+	if !ok {
+		return 1
+	}
+
+	count := 1
+
+	for _, arg := range call.Args {
+		count += nodes(arg)
+	}
+
+	return count
+}
+
+// Outputs returns the number of objects written by the calculator: its secret and the objects of its targets.
+func Outputs(calc *calcv1alpha1.Calculator) int64 {
+	objects := make(map[string]bool)
+
+	for _, target := range calc.Spec.Targets {
+		groupKind, err := targets.GroupKind(target)
+		if err != nil {
+			groupKind = schema.GroupKind{Kind: target.Kind}
+		}
+
+		objects[groupKind.String()+"/"+target.Name] = true
+	}
+
+	return 1 + int64(len(objects))
+}
+
+// Usage returns the usage of a single calculator.
+func Usage(calc *calcv1alpha1.Calculator) calcv1alpha1.CalculatorQuotaUsage {
+	return calcv1alpha1.CalculatorQuotaUsage{Calculators: 1, EvaluationCost: Cost(calc), Outputs: Outputs(calc)}
+}
+
+// Add returns the sum of the usages.
+func Add(a, b calcv1alpha1.CalculatorQuotaUsage) calcv1alpha1.CalculatorQuotaUsage {
+	return calcv1alpha1.CalculatorQuotaUsage{
+		Calculators:    a.Calculators + b.Calculators,
+		EvaluationCost: a.EvaluationCost + b.EvaluationCost,
+		Outputs:        a.Outputs + b.Outputs,
+	}
+}
+
+// Total returns the usage of the calculators.
+func Total(calcs []calcv1alpha1.Calculator) calcv1alpha1.CalculatorQuotaUsage {
+	total := calcv1alpha1.CalculatorQuotaUsage{}
+
+	for i := range calcs {
+		total = Add(total, Usage(&calcs[i]))
+	}
+
+	return total
+}
+
+// Exceeded returns the limits of the quota exceeded by the usage, by name.
+func Exceeded(hard calcv1alpha1.CalculatorQuotaLimits, used calcv1alpha1.CalculatorQuotaUsage) map[string]string {
+	exceeded := make(map[string]string)
+
+	for _, limit := range []struct {
+		name  string
+		hard  *int64
+		value int64
+	}{
+		{name: LimitCalculators, hard: hard.Calculators, value: used.Calculators},
+		{name: LimitEvaluationCost, hard: hard.EvaluationCost, value: used.EvaluationCost},
+		{name: LimitOutputs, hard: hard.Outputs, value: used.Outputs},
+	} {
+		if limit.hard != nil && limit.value > *limit.hard {
+			exceeded[limit.name] = fmt.Sprintf("%s %d > %d", limit.name, limit.value, *limit.hard)
+		}
+	}
+
+	return exceeded
+}
+
+// Admit checks a created or updated calculator against the quotas, the others being the other calculators of the
+// namespace and old the calculator before the update, nil on creation. An update is only rejected for the
+// exceeded limits it increases the usage of, so calculators can be shrunk below a quota created after them.
+func Admit(quotas []calcv1alpha1.CalculatorQuota, others []calcv1alpha1.Calculator,
+	calc, old *calcv1alpha1.Calculator,
+) error {
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	usage := Usage(calc)
+	used := Add(Total(others), usage)
+
+	var previous map[string]int64
+
+	if old != nil {
+		oldUsage := Usage(old)
+		previous = map[string]int64{
+			LimitCalculators:    oldUsage.Calculators,
+			LimitEvaluationCost: oldUsage.EvaluationCost,
+			LimitOutputs:        oldUsage.Outputs,
+		}
+	}
+
+	current := map[string]int64{
+		LimitCalculators:    usage.Calculators,
+		LimitEvaluationCost: usage.EvaluationCost,
+		LimitOutputs:        usage.Outputs,
+	}
+
+	for _, quota := range quotas {
+		var limits []string
+
+		for name, message := range Exceeded(quota.Spec.Hard, used) {
+			if old != nil && current[name] <= previous[name] {
+				continue
+			}
+
+			limits = append(limits, message)
+		}
+
+		if len(limits) > 0 {
+			sort.Strings(limits)
+
+			return &ExceededError{Quota: quota.Name, Limits: limits}
+		}
+	}
+
+	return nil
+}
+
+// OverQuota returns the calculators of a namespace exceeding its quotas, by name. The calculators are admitted
+// oldest first, a calculator exceeding a quota being left out of the usage of the next ones.
+func OverQuota(quotas []calcv1alpha1.CalculatorQuota, calcs []calcv1alpha1.Calculator) map[string]error {
+	over := make(map[string]error)
+
+	if len(quotas) == 0 {
+		return over
+	}
+
+	sorted := make([]*calcv1alpha1.Calculator, 0, len(calcs))
+	for i := range calcs {
+		sorted = append(sorted, &calcs[i])
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+			return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+		}
+
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	var admitted []calcv1alpha1.Calculator
+
+	for _, calc := range sorted {
+		if err := Admit(quotas, admitted, calc, nil); err != nil {
+			over[calc.Name] = err
+
+			continue
+		}
+
+		admitted = append(admitted, *calc)
+	}
+
+	return over
+}
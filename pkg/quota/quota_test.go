@@ -0,0 +1,184 @@
+package quota_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/quota"
+)
+
+func limit(value int64) *int64 {
+	return &value
+}
+
+func calculator(name, expression string, targets ...string) calcv1alpha1.Calculator {
+	calc := calcv1alpha1.Calculator{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       calcv1alpha1.CalculatorSpec{Expression: expression},
+	}
+
+	for _, target := range targets {
+		calc.Spec.Targets = append(calc.Spec.Targets, calcv1alpha1.Target{
+			APIVersion: "v1", Kind: "ConfigMap", Name: target, Path: ".data.result",
+		})
+	}
+
+	return calc
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name string
+		calc calcv1alpha1.Calculator
+		want calcv1alpha1.CalculatorQuotaUsage
+	}{
+		{
+			name: "Default expression",
+			calc: calculator("sum", ""),
+			want: calcv1alpha1.CalculatorQuotaUsage{Calculators: 1, EvaluationCost: 3, Outputs: 1},
+		},
+		{
+			name: "Nested expression",
+			calc: calculator("nested", "pow(x, 2) + y * 3"),
+			want: calcv1alpha1.CalculatorQuotaUsage{Calculators: 1, EvaluationCost: 7, Outputs: 1},
+		},
+		{
+			name: "Unparsable expression",
+			calc: calculator("remote", "max(x,"),
+			want: calcv1alpha1.CalculatorQuotaUsage{Calculators: 1, EvaluationCost: 6, Outputs: 1},
+		},
+		{
+			name: "Targets of two objects",
+			calc: calculator("targets", "", "a", "b", "a"),
+			want: calcv1alpha1.CalculatorQuotaUsage{Calculators: 1, EvaluationCost: 3, Outputs: 3},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, quota.Usage(&tt.calc))
+		})
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	t.Parallel()
+
+	quotas := []calcv1alpha1.CalculatorQuota{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "count"},
+			Spec:       calcv1alpha1.CalculatorQuotaSpec{Hard: calcv1alpha1.CalculatorQuotaLimits{Calculators: limit(2)}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "cost"},
+			Spec: calcv1alpha1.CalculatorQuotaSpec{Hard: calcv1alpha1.CalculatorQuotaLimits{
+				EvaluationCost: limit(10),
+				Outputs:        limit(3),
+			}},
+		},
+	}
+
+	existing := []calcv1alpha1.Calculator{calculator("sum", "")}
+
+	// Test table
+	tests := []struct {
+		name    string
+		others  []calcv1alpha1.Calculator
+		calc    calcv1alpha1.Calculator
+		old     *calcv1alpha1.Calculator
+		noQuota bool
+		wantErr string
+	}{
+		{name: "Within quotas", others: existing, calc: calculator("other", "x * y")},
+		{
+			name:    "Too many calculators",
+			others:  append(existing, calculator("second", "")),
+			calc:    calculator("third", ""),
+			wantErr: "exceeded quota count: calculators 3 > 2",
+		},
+		{
+			name:    "Too costly",
+			others:  existing,
+			calc:    calculator("other", "x * y * x * y * x"),
+			wantErr: "exceeded quota cost: evaluationCost 12 > 10",
+		},
+		{
+			name:    "Too many outputs",
+			others:  existing,
+			calc:    calculator("other", "", "a", "b"),
+			wantErr: "exceeded quota cost: outputs 4 > 3",
+		},
+		{
+			name:   "Shrinking update",
+			others: []calcv1alpha1.Calculator{calculator("big", "x * y * x * y * x")},
+			calc:   calculator("other", "x * y * x"),
+			old: func() *calcv1alpha1.Calculator {
+				calc := calculator("other", "x * y * x * y")
+
+				return &calc
+			}(),
+		},
+		{name: "No quotas", others: existing, calc: calculator("other", "", "a", "b", "c"), noQuota: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			quotas := quotas
+			if tt.noQuota {
+				quotas = nil
+			}
+
+			err := quota.Admit(quotas, tt.others, &tt.calc, tt.old)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			var exceeded *quota.ExceededError
+
+			require.ErrorAs(t, err, &exceeded)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestOverQuota(t *testing.T) {
+	t.Parallel()
+
+	quotas := []calcv1alpha1.CalculatorQuota{{
+		ObjectMeta: metav1.ObjectMeta{Name: "count"},
+		Spec:       calcv1alpha1.CalculatorQuotaSpec{Hard: calcv1alpha1.CalculatorQuotaLimits{Calculators: limit(2)}},
+	}}
+
+	created := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	calcs := []calcv1alpha1.Calculator{calculator("c", ""), calculator("a", ""), calculator("b", "")}
+	calcs[0].CreationTimestamp = metav1.NewTime(created)
+	calcs[1].CreationTimestamp = metav1.NewTime(created.Add(time.Minute))
+	calcs[2].CreationTimestamp = metav1.NewTime(created.Add(time.Minute))
+
+	over := quota.OverQuota(quotas, calcs)
+	require.Len(t, over, 1)
+	assert.EqualError(t, over["b"], "exceeded quota count: calculators 3 > 2")
+
+	assert.Empty(t, quota.OverQuota(nil, calcs))
+}
@@ -0,0 +1,123 @@
+// Package rollout rolls out the workloads consuming the result secrets of the Calculators: their pod templates
+// are annotated with a checksum of the secret data, so a changed result changes the templates.
+package rollout
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationPrefix prefixes the checksum annotations, one per Calculator.
+	AnnotationPrefix = "checksum.calc.example.com/"
+
+	// maxNameLength is the maximum length of the name part of an annotation key.
+	maxNameLength = 63
+	hashLength    = 8
+)
+
+// Annotation returns the key of the checksum annotation of the Calculator.
+func Annotation(calcName string) string {
+	if len(calcName) <= maxNameLength {
+		return AnnotationPrefix + calcName
+	}
+
+	sum := sha256.Sum256([]byte(calcName))
+
+	return AnnotationPrefix + calcName[:maxNameLength-hashLength-1] + "-" + hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// Checksum returns the checksum of the data of the secret, the string data overriding the data like the API
+// server does.
+func Checksum(secret *corev1.Secret) string {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+
+	for key, value := range secret.StringData {
+		data[key] = []byte(value)
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	hash := sha256.New()
+
+	for _, key := range keys {
+		// The lengths delimit the keys and the values.
+		fmt.Fprintf(hash, "%d:%s%d:", len(key), key, len(data[key]))
+		hash.Write(data[key])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Workloads lists the kinds of the consumers.
+func Workloads() []client.ObjectList {
+	return []client.ObjectList{&appsv1.DeploymentList{}, &appsv1.StatefulSetList{}, &appsv1.DaemonSetList{}}
+}
+
+// Items returns the workloads of the list.
+func Items(list client.ObjectList) []client.Object {
+	var items []client.Object
+
+	switch list := list.(type) {
+	case *appsv1.DeploymentList:
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
+		}
+	case *appsv1.StatefulSetList:
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
+		}
+	case *appsv1.DaemonSetList:
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
+		}
+	}
+
+	return items
+}
+
+// PodTemplate returns the pod template of the workload, nil for other objects.
+func PodTemplate(obj client.Object) *corev1.PodTemplateSpec {
+	switch obj := obj.(type) {
+	case *appsv1.Deployment:
+		return &obj.Spec.Template
+	case *appsv1.StatefulSet:
+		return &obj.Spec.Template
+	case *appsv1.DaemonSet:
+		return &obj.Spec.Template
+	default:
+		return nil
+	}
+}
+
+// Annotate sets the annotation of the pod template of the workload to the checksum, and reports whether it
+// changed.
+func Annotate(obj client.Object, annotation, checksum string) bool {
+	template := PodTemplate(obj)
+	if template == nil || template.Annotations[annotation] == checksum {
+		return false
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+
+	template.Annotations[annotation] = checksum
+
+	return true
+}
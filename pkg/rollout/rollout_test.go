@@ -0,0 +1,91 @@
+package rollout_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/mykysha/kubCalculator/pkg/rollout"
+)
+
+func TestAnnotation(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "checksum.calc.example.com/replicas", rollout.Annotation("replicas"))
+
+	long := rollout.Annotation(strings.Repeat("a", 100))
+	other := rollout.Annotation(strings.Repeat("a", 99) + "b")
+
+	assert.Len(t, strings.TrimPrefix(long, rollout.AnnotationPrefix), 63)
+	assert.NotEqual(t, long, other)
+}
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name  string
+		a     *corev1.Secret
+		b     *corev1.Secret
+		equal bool
+	}{
+		{
+			name:  "Data and string data",
+			a:     &corev1.Secret{Data: map[string][]byte{"result": []byte("5")}},
+			b:     &corev1.Secret{StringData: map[string]string{"result": "5"}},
+			equal: true,
+		},
+		{
+			name: "String data overrides data",
+			a: &corev1.Secret{
+				Data:       map[string][]byte{"result": []byte("4")},
+				StringData: map[string]string{"result": "5"},
+			},
+			b:     &corev1.Secret{Data: map[string][]byte{"result": []byte("5")}},
+			equal: true,
+		},
+		{
+			name: "Different values",
+			a:    &corev1.Secret{StringData: map[string]string{"result": "5"}},
+			b:    &corev1.Secret{StringData: map[string]string{"result": "6"}},
+		},
+		{
+			name: "Ambiguous concatenation",
+			a:    &corev1.Secret{StringData: map[string]string{"a": "1b2", "c": ""}},
+			b:    &corev1.Secret{StringData: map[string]string{"a": "1", "b2c": ""}},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.equal {
+				assert.Equal(t, rollout.Checksum(tt.a), rollout.Checksum(tt.b))
+			} else {
+				assert.NotEqual(t, rollout.Checksum(tt.a), rollout.Checksum(tt.b))
+			}
+		})
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	t.Parallel()
+
+	list := &appsv1.StatefulSetList{Items: []appsv1.StatefulSet{{}}}
+	items := rollout.Items(list)
+
+	assert.Len(t, items, 1)
+	assert.True(t, rollout.Annotate(items[0], "checksum.calc.example.com/replicas", "abc"))
+	assert.False(t, rollout.Annotate(items[0], "checksum.calc.example.com/replicas", "abc"))
+	assert.Equal(t, "abc", list.Items[0].Spec.Template.Annotations["checksum.calc.example.com/replicas"])
+
+	assert.False(t, rollout.Annotate(&corev1.Pod{}, "checksum.calc.example.com/replicas", "abc"))
+}
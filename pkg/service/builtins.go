@@ -0,0 +1,455 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Names of the builtin operations bound to infix operators.
+const (
+	OpAdd = "add"
+	OpSub = "sub"
+	OpMul = "mul"
+	OpDiv = "div"
+	OpMod = "mod"
+	OpPow = "pow"
+	OpNeg = "neg"
+)
+
+// Names of the builtin operations on complex numbers.
+const (
+	OpAbs  = "abs"
+	OpArg  = "arg"
+	OpConj = "conj"
+)
+
+var (
+	log2Of10   = math.Log2(10) //nolint:gomnd // Decimal base
+	errDivZero = Invalidf("division by zero")
+)
+
+// unary, binary and nAry return the signatures of the operations taking and returning the type.
+func unary(t Type) Signature {
+	return Signature{Params: []Type{t}, Result: t}
+}
+
+func binary(t Type) Signature {
+	return Signature{Params: []Type{t, t}, Result: t}
+}
+
+func nAry(t Type) Signature {
+	return Signature{Params: []Type{t, t}, Variadic: true, Result: t}
+}
+
+// builtins returns the builtin operations. Their signatures go from the narrowest to the widest type, so that
+// the arguments are promoted to the narrowest type accepting them all.
+func builtins() []Evaluator {
+	evaluators := []Evaluator{
+		{
+			Name: OpAdd, Symbol: "+", Doc: "Sum of the arguments.",
+			Signatures: []Signature{
+				nAry(TypeInt), nAry(TypeRational), nAry(TypeReal), nAry(TypeComplex), nAry(TypeQuantity),
+				{Params: []Type{TypeQuantity, TypeRational}, Result: TypeQuantity},
+				{Params: []Type{TypeRational, TypeQuantity}, Result: TypeQuantity},
+				nAry(TypeMeasure), nAry(TypeDuration),
+				{Params: []Type{TypeTimestamp, TypeDuration}, Result: TypeTimestamp},
+				{Params: []Type{TypeDuration, TypeTimestamp}, Result: TypeTimestamp},
+			},
+			Infer: inferByKind(map[Type]InferFunc{TypeQuantity: inferQuantity, TypeMeasure: inferMeasure}),
+			Eval: byKind(map[Type]EvalFunc{
+				TypeQuantity:  foldQuantity((*resource.Quantity).Add),
+				TypeMeasure:   foldMeasure(func(x, y float64) float64 { return x + y }),
+				TypeTimestamp: addTime,
+				TypeDuration:  addTime,
+			}, byType(map[Type]EvalFunc{
+				TypeInt:      foldInt((*big.Int).Add),
+				TypeRational: foldRat((*big.Rat).Add),
+				TypeReal:     foldReal(func(x, y float64) float64 { return x + y }),
+				TypeComplex:  foldComplex(func(x, y complex128) complex128 { return x + y }),
+			})),
+		},
+		{
+			Name: OpSub, Symbol: "-", Doc: "Difference of the arguments.",
+			Signatures: []Signature{
+				binary(TypeInt), binary(TypeRational), binary(TypeReal), binary(TypeComplex), binary(TypeQuantity),
+				{Params: []Type{TypeQuantity, TypeRational}, Result: TypeQuantity},
+				{Params: []Type{TypeRational, TypeQuantity}, Result: TypeQuantity},
+				binary(TypeMeasure), binary(TypeDuration),
+				{Params: []Type{TypeTimestamp, TypeDuration}, Result: TypeTimestamp},
+				{Params: []Type{TypeTimestamp, TypeTimestamp}, Result: TypeDuration},
+			},
+			Infer: inferByKind(map[Type]InferFunc{TypeQuantity: inferQuantity, TypeMeasure: inferMeasure}),
+			Eval: byKind(map[Type]EvalFunc{
+				TypeQuantity:  foldQuantity((*resource.Quantity).Sub),
+				TypeMeasure:   foldMeasure(func(x, y float64) float64 { return x - y }),
+				TypeTimestamp: subTime,
+				TypeDuration:  subTime,
+			}, byType(map[Type]EvalFunc{
+				TypeInt:      foldInt((*big.Int).Sub),
+				TypeRational: foldRat((*big.Rat).Sub),
+				TypeReal:     foldReal(func(x, y float64) float64 { return x - y }),
+				TypeComplex:  foldComplex(func(x, y complex128) complex128 { return x - y }),
+			})),
+		},
+		{
+			Name: OpMul, Symbol: "*", Doc: "Product of the arguments.",
+			Signatures: []Signature{
+				nAry(TypeInt), nAry(TypeRational), nAry(TypeReal), nAry(TypeComplex),
+				{Params: []Type{TypeQuantity, TypeRational}, Result: TypeQuantity},
+				{Params: []Type{TypeRational, TypeQuantity}, Result: TypeQuantity},
+				{Params: []Type{TypeMeasure, TypeReal}, Result: TypeMeasure},
+				{Params: []Type{TypeReal, TypeMeasure}, Result: TypeMeasure},
+				binary(TypeMeasure),
+			},
+			Infer: inferByKind(map[Type]InferFunc{TypeQuantity: inferQuantity, TypeMeasure: inferMeasureProduct}),
+			Eval: byKind(map[Type]EvalFunc{TypeQuantity: mulQuantity, TypeMeasure: mulMeasure}, byType(map[Type]EvalFunc{
+				TypeInt:      foldInt((*big.Int).Mul),
+				TypeRational: foldRat((*big.Rat).Mul),
+				TypeReal:     foldReal(func(x, y float64) float64 { return x * y }),
+				TypeComplex:  foldComplex(func(x, y complex128) complex128 { return x * y }),
+			})),
+		},
+		{
+			Name: OpDiv, Symbol: "/", Doc: "Quotient of the arguments, truncated towards zero for integers.",
+			Domain: "the divisor must not be zero",
+			Signatures: []Signature{
+				binary(TypeInt), binary(TypeRational), binary(TypeReal), binary(TypeComplex),
+				{Params: []Type{TypeQuantity, TypeRational}, Result: TypeQuantity},
+				{Params: []Type{TypeQuantity, TypeQuantity}, Result: TypeRational},
+				{Params: []Type{TypeMeasure, TypeReal}, Result: TypeMeasure},
+				{Params: []Type{TypeReal, TypeMeasure}, Result: TypeMeasure},
+				binary(TypeMeasure),
+			},
+			Infer: inferByKind(map[Type]InferFunc{TypeQuantity: inferQuantityDiv, TypeMeasure: inferMeasureQuotient}),
+			Eval: byKind(map[Type]EvalFunc{TypeQuantity: divQuantity, TypeMeasure: divMeasure}, byType(map[Type]EvalFunc{
+				TypeInt:      divInt((*big.Int).Quo),
+				TypeRational: divRat,
+				TypeReal:     divReal,
+				TypeComplex:  divComplex,
+			})),
+		},
+		{
+			Name: OpMod, Symbol: "%", Doc: "Remainder of the truncated division, with the sign of the dividend.",
+			Domain:     "the divisor must not be zero",
+			Signatures: []Signature{binary(TypeInt)},
+			Eval:       divInt((*big.Int).Rem),
+		},
+		{
+			Name: OpPow, Symbol: "^",
+			Doc: "First argument raised to the power of the second argument.",
+			Domain: "the exponent must be non-negative for integers and integral for rationals, " +
+				"the base non-zero for a negative exponent",
+			Signatures: []Signature{binary(TypeInt), binary(TypeRational), binary(TypeReal), binary(TypeComplex)},
+			Eval: byType(map[Type]EvalFunc{
+				TypeInt:      powInt,
+				TypeRational: powRat,
+				TypeReal:     foldReal(math.Pow),
+				TypeComplex:  foldComplex(cmplx.Pow),
+			}),
+		},
+		{
+			Name: OpNeg, Symbol: "-", Doc: "Negation of the argument.",
+			Signatures: []Signature{
+				unary(TypeInt), unary(TypeRational), unary(TypeReal), unary(TypeComplex), unary(TypeQuantity),
+				unary(TypeMeasure), unary(TypeDuration),
+			},
+			Infer: inferByKind(map[Type]InferFunc{TypeQuantity: inferQuantity, TypeMeasure: inferMeasure}),
+			Eval: byKind(map[Type]EvalFunc{
+				TypeQuantity: mapQuantity((*resource.Quantity).Neg),
+				TypeMeasure:  mapMeasure(func(x float64) float64 { return -x }),
+				TypeDuration: mapDuration(negDuration),
+			}, byType(map[Type]EvalFunc{
+				TypeInt: func(_ context.Context, args []Value) (Value, error) {
+					i, err := asInt(args[0])
+					if err != nil {
+						return nil, err
+					}
+
+					return IntValue{Int: new(big.Int).Neg(i.Int)}, nil
+				},
+				TypeRational: func(_ context.Context, args []Value) (Value, error) {
+					r, err := asRat(args[0])
+					if err != nil {
+						return nil, err
+					}
+
+					return RatValue{Rat: new(big.Rat).Neg(r.Rat)}, nil
+				},
+				TypeReal:    mapReal(func(x float64) float64 { return -x }),
+				TypeComplex: mapComplex(func(x complex128) complex128 { return -x }),
+			})),
+		},
+		{
+			Name: OpAbs, Doc: "Absolute value of the argument, the modulus of a complex number.",
+			Signatures: []Signature{
+				unary(TypeInt), unary(TypeRational), unary(TypeReal),
+				{Params: []Type{TypeComplex}, Result: TypeReal}, unary(TypeQuantity), unary(TypeMeasure),
+				unary(TypeDuration),
+			},
+			Infer: inferByKind(map[Type]InferFunc{TypeQuantity: inferQuantity, TypeMeasure: inferMeasure}),
+			Eval: byKind(map[Type]EvalFunc{
+				TypeQuantity: mapQuantity(absQuantity),
+				TypeMeasure:  mapMeasure(math.Abs),
+				TypeDuration: mapDuration(absDuration),
+			}, byType(map[Type]EvalFunc{
+				TypeInt: func(_ context.Context, args []Value) (Value, error) {
+					i, err := asInt(args[0])
+					if err != nil {
+						return nil, err
+					}
+
+					return IntValue{Int: new(big.Int).Abs(i.Int)}, nil
+				},
+				TypeRational: func(_ context.Context, args []Value) (Value, error) {
+					r, err := asRat(args[0])
+					if err != nil {
+						return nil, err
+					}
+
+					return RatValue{Rat: new(big.Rat).Abs(r.Rat)}, nil
+				},
+				TypeReal:    mapReal(math.Abs),
+				TypeComplex: complexToReal(cmplx.Abs),
+			})),
+		},
+		{
+			Name: OpArg, Doc: "Argument of the complex number in radians, between -π and π.",
+			Signatures: []Signature{{Params: []Type{TypeComplex}, Result: TypeReal}},
+			Eval:       complexToReal(cmplx.Phase),
+		},
+		{
+			Name: OpConj, Doc: "Complex conjugate of the argument.",
+			Signatures: []Signature{unary(TypeComplex)},
+			Eval:       mapComplex(cmplx.Conj),
+		},
+	}
+
+	evaluators = append(evaluators, linearAlgebra()...)
+	evaluators = append(evaluators, conversion())
+	evaluators = append(evaluators, comparisons()...)
+
+	return append(evaluators, mathFunctions()...)
+}
+
+// byType dispatches the evaluation on the type of the first argument, the arguments of a signature being
+// promoted to the same type.
+func byType(evals map[Type]EvalFunc) EvalFunc {
+	return func(ctx context.Context, args []Value) (Value, error) {
+		eval, ok := evals[args[0].Type()]
+		if !ok {
+			return nil, Invalidf("unexpected %s argument", args[0].Type())
+		}
+
+		return eval(ctx, args)
+	}
+}
+
+// byKind dispatches the evaluation on the first argument of a kind of evals, such as a quantity multiplied by a
+// rational on its left. The evaluations without such an argument are dispatched to eval.
+func byKind(evals map[Type]EvalFunc, eval EvalFunc) EvalFunc {
+	return func(ctx context.Context, args []Value) (Value, error) {
+		for _, arg := range args {
+			if kindEval, ok := evals[arg.Type().Kind()]; ok {
+				return kindEval(ctx, args)
+			}
+		}
+
+		return eval(ctx, args)
+	}
+}
+
+// inferByKind dispatches the type inference on the first argument of a kind of infers. The result type of the
+// signature is used without such an argument.
+func inferByKind(infers map[Type]InferFunc) InferFunc {
+	return func(args []Type) (Type, error) {
+		for _, arg := range args {
+			if infer, ok := infers[arg.Kind()]; ok {
+				return infer(args)
+			}
+		}
+
+		return "", nil
+	}
+}
+
+// foldInt applies a binary integer operation from left to right.
+func foldInt(op func(z, x, y *big.Int) *big.Int) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		first, err := asInt(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		result := new(big.Int).Set(first.Int)
+
+		for _, arg := range args[1:] {
+			i, err := asInt(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			op(result, result, i.Int)
+		}
+
+		return IntValue{Int: result}, nil
+	}
+}
+
+func divInt(op func(z, x, y *big.Int) *big.Int) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		x, err := asInt(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := asInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if y.Int.Sign() == 0 {
+			return nil, errDivZero
+		}
+
+		return IntValue{Int: op(new(big.Int), x.Int, y.Int)}, nil
+	}
+}
+
+// foldRat applies a binary rational operation from left to right.
+func foldRat(op func(z, x, y *big.Rat) *big.Rat) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		first, err := asRat(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		result := new(big.Rat).Set(first.Rat)
+
+		for _, arg := range args[1:] {
+			r, err := asRat(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			op(result, result, r.Rat)
+		}
+
+		return RatValue{Rat: result}, nil
+	}
+}
+
+func divRat(_ context.Context, args []Value) (Value, error) {
+	x, err := asRat(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := asRat(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if y.Rat.Sign() == 0 {
+		return nil, errDivZero
+	}
+
+	return RatValue{Rat: new(big.Rat).Quo(x.Rat, y.Rat)}, nil
+}
+
+// powRat raises a fraction to an integer power, a negative power raising its inverse.
+func powRat(ctx context.Context, args []Value) (Value, error) {
+	base, err := asRat(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	exponent, err := asRat(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if !exponent.Rat.IsInt() {
+		return nil, Invalidf("non-integer exponent %s", exponent)
+	}
+
+	exp := new(big.Int).Abs(exponent.Rat.Num())
+
+	if exponent.Rat.Sign() < 0 {
+		if base.Rat.Sign() == 0 {
+			return nil, errDivZero
+		}
+
+		base = RatValue{Rat: new(big.Rat).Inv(base.Rat)}
+	}
+
+	num, err := powInt(ctx, []Value{IntValue{Int: base.Rat.Num()}, IntValue{Int: exp}})
+	if err != nil {
+		return nil, err
+	}
+
+	denom, err := powInt(ctx, []Value{IntValue{Int: base.Rat.Denom()}, IntValue{Int: exp}})
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:forcetypeassert // powInt returns integers
+	return RatValue{Rat: new(big.Rat).SetFrac(num.(IntValue).Int, denom.(IntValue).Int)}, nil
+}
+
+func powInt(ctx context.Context, args []Value) (Value, error) {
+	base, err := asInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	exponent, err := asInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if exponent.Int.Sign() < 0 {
+		return nil, Invalidf("negative exponent %s", exponent)
+	}
+
+	// Estimate the digits of the result before computing it, so that 9^9^9 is rejected immediately.
+	if limits := LimitsFromContext(ctx); limits.MaxDigits > 0 && base.Int.BitLen() > 1 {
+		exp, _ := new(big.Float).SetInt(exponent.Int).Float64()
+		digits := float64(base.Int.BitLen()-1) * exp / log2Of10
+
+		if digits > float64(limits.MaxDigits) {
+			return nil, &LimitExceededError{
+				Limit:   LimitMaxDigits,
+				Message: fmt.Sprintf("%s^%s has more than %d digits", base, exponent, limits.MaxDigits),
+			}
+		}
+	}
+
+	// The powers of 0, 1 and -1 are immediate.
+	if base.Int.BitLen() <= 1 {
+		return IntValue{Int: new(big.Int).Exp(base.Int, exponent.Int, nil)}, nil
+	}
+
+	// Squaring and multiplying rather than big.Int.Exp stops the computation once the context is done.
+	result, square := big.NewInt(1), new(big.Int).Set(base.Int)
+
+	for i := 0; i < exponent.Int.BitLen(); i++ {
+		if err = interrupted(ctx); err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			square.Mul(square, square)
+		}
+
+		if exponent.Int.Bit(i) == 1 {
+			result.Mul(result, square)
+		}
+	}
+
+	return IntValue{Int: result}, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+)
+
+// Names of the builtin comparisons.
+const (
+	OpLt = "lt"
+	OpLe = "le"
+	OpGt = "gt"
+	OpGe = "ge"
+	OpEq = "eq"
+	OpNe = "ne"
+)
+
+// comparisons returns the comparisons of numbers, timestamps and durations, bound to the infix operators
+// < <= > >= == and !=.
+func comparisons() []Evaluator {
+	return []Evaluator{
+		comparison(OpLt, "<", "Whether the first argument is less than the second.", func(c int) bool { return c < 0 }),
+		comparison(OpLe, "<=", "Whether the first argument is less than or equal to the second.",
+			func(c int) bool { return c <= 0 }),
+		comparison(OpGt, ">", "Whether the first argument is greater than the second.",
+			func(c int) bool { return c > 0 }),
+		comparison(OpGe, ">=", "Whether the first argument is greater than or equal to the second.",
+			func(c int) bool { return c >= 0 }),
+		comparison(OpEq, "==", "Whether the arguments are equal.", func(c int) bool { return c == 0 }),
+		comparison(OpNe, "!=", "Whether the arguments are different.", func(c int) bool { return c != 0 }),
+	}
+}
+
+// comparison returns a comparison true when holds is true of the sign of the difference of the arguments.
+func comparison(name, symbol, doc string, holds func(c int) bool) Evaluator {
+	var signatures []Signature
+
+	for _, t := range []Type{TypeInt, TypeRational, TypeReal, TypeTimestamp, TypeDuration} {
+		signatures = append(signatures, Signature{Params: []Type{t, t}, Result: TypeBool})
+	}
+
+	return Evaluator{
+		Name: name, Symbol: symbol, Doc: doc,
+		Signatures: signatures,
+		Eval: func(_ context.Context, args []Value) (Value, error) {
+			c, err := compare(args[0], args[1])
+			if err != nil {
+				return nil, err
+			}
+
+			return BoolValue{Bool: holds(c)}, nil
+		},
+	}
+}
+
+// compare returns the sign of the difference of two values of the same type.
+func compare(x, y Value) (int, error) {
+	if x.Type() != y.Type() {
+		return 0, Invalidf("cannot compare %s and %s", x.Type(), y.Type())
+	}
+
+	switch x := x.(type) {
+	case IntValue:
+		return x.Int.Cmp(y.(IntValue).Int), nil //nolint:forcetypeassert // Same type
+	case RatValue:
+		return x.Rat.Cmp(y.(RatValue).Rat), nil //nolint:forcetypeassert // Same type
+	case RealValue:
+		return compareOrdered(x.Float, y.(RealValue).Float), nil //nolint:forcetypeassert // Same type
+	case TimestampValue:
+		other := y.(TimestampValue).Time //nolint:forcetypeassert // Same type
+
+		switch {
+		case x.Time.Before(other):
+			return -1, nil
+		case x.Time.After(other):
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	case DurationValue:
+		return compareOrdered(x.Duration, y.(DurationValue).Duration), nil //nolint:forcetypeassert // Same type
+	default:
+		return 0, Invalidf("cannot compare %s", x.Type())
+	}
+}
+
+func compareOrdered[T ~int64 | ~float64](x, y T) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
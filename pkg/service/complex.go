@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+)
+
+// errNotFinite is returned by the floating point operations overflowing or out of their domain, such as 0/0.
+var errNotFinite = Invalidf("the result is not a finite number")
+
+// newReal returns the RealValue of f, which must be finite. A negative zero becomes zero: -0 is not a number of
+// the calculations, and -(0+0i) would otherwise be on the other side of the branch cut of sqrt.
+func newReal(f float64) (Value, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, errNotFinite
+	}
+
+	return RealValue{Float: f + 0}, nil
+}
+
+// newComplex returns the ComplexValue of c, whose parts must be finite. Negative zero parts become zero.
+func newComplex(c complex128) (Value, error) {
+	if cmplx.IsInf(c) || cmplx.IsNaN(c) {
+		return nil, errNotFinite
+	}
+
+	return ComplexValue{Complex: complex(real(c)+0, imag(c)+0)}, nil
+}
+
+func asReal(v Value) (RealValue, error) {
+	r, ok := v.(RealValue)
+	if !ok {
+		return RealValue{}, Invalidf("expected %s, got %s", TypeReal, v.Type())
+	}
+
+	return r, nil
+}
+
+func asComplex(v Value) (ComplexValue, error) {
+	c, ok := v.(ComplexValue)
+	if !ok {
+		return ComplexValue{}, Invalidf("expected %s, got %s", TypeComplex, v.Type())
+	}
+
+	return c, nil
+}
+
+// foldReal applies a binary real operation from left to right.
+func foldReal(op func(x, y float64) float64) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		first, err := asReal(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		result := first.Float
+
+		for _, arg := range args[1:] {
+			r, err := asReal(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			result = op(result, r.Float)
+		}
+
+		return newReal(result)
+	}
+}
+
+// foldComplex applies a binary complex operation from left to right.
+func foldComplex(op func(x, y complex128) complex128) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		first, err := asComplex(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		result := first.Complex
+
+		for _, arg := range args[1:] {
+			c, err := asComplex(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			result = op(result, c.Complex)
+		}
+
+		return newComplex(result)
+	}
+}
+
+// mapReal applies a unary real operation.
+func mapReal(op func(x float64) float64) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		r, err := asReal(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return newReal(op(r.Float))
+	}
+}
+
+// mapComplex applies a unary complex operation.
+func mapComplex(op func(x complex128) complex128) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		c, err := asComplex(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return newComplex(op(c.Complex))
+	}
+}
+
+// complexToReal applies a real function of a complex number.
+func complexToReal(op func(x complex128) float64) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		c, err := asComplex(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return newReal(op(c.Complex))
+	}
+}
+
+func divReal(ctx context.Context, args []Value) (Value, error) {
+	if y, err := asReal(args[1]); err == nil && y.Float == 0 {
+		return nil, errDivZero
+	}
+
+	return foldReal(func(x, y float64) float64 { return x / y })(ctx, args)
+}
+
+func divComplex(ctx context.Context, args []Value) (Value, error) {
+	if y, err := asComplex(args[1]); err == nil && y.Complex == 0 {
+		return nil, errDivZero
+	}
+
+	return foldComplex(func(x, y complex128) complex128 { return x / y })(ctx, args)
+}
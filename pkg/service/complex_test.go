@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var complexVariables = []calcv1alpha1.Variable{
+	{Name: "z", Type: calcv1alpha1.VariableTypeComplex, Value: "3+4i"},
+	{Name: "w", Type: calcv1alpha1.VariableTypeComplex, Value: "1-2i"},
+}
+
+func TestParseComplex(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]complex128{"3+4i": 3 + 4i, " -4i ": -4i, "2.5": 2.5, "1e3-0.5i": 1000 - 0.5i} {
+		got, err := service.ParseComplex(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, got.Complex, s)
+	}
+
+	for _, s := range []string{"", "3+4j", "i", "1e400i", "3 + 4i"} {
+		_, err := service.ParseComplex(s)
+		assert.True(t, service.IsInvalid(err), s)
+	}
+
+	assert.Equal(t, "3-4i", service.ComplexValue{Complex: 3 - 4i}.String())
+	assert.Equal(t, "5∠0.9272952180016122", service.ComplexValue{Complex: 3 + 4i}.Polar())
+}
+
+func TestProcessCalculatorComplex(t *testing.T) {
+	t.Parallel()
+
+	polar := &calcv1alpha1.OutputSpec{ComplexFormat: calcv1alpha1.ComplexFormatPolar}
+
+	testProcessTyped(t, complexVariables, []typedTest{
+		{name: "Sum", expression: "z + w", want: "4+2i"},
+		{name: "Product", expression: "z * w", want: "11-2i"},
+		{name: "Quotient", expression: "z / w", want: "-1+2i"},
+		{name: "Imaginary literal", expression: "z + 2i", want: "3+6i"},
+		{name: "Conjugate of a promoted integer", expression: "conj(z) - x", want: "1-4i"},
+		{name: "Zero power", expression: "pow(z, 0)", want: "1+0i"},
+		{name: "Modulus", expression: "abs(z)", want: "5"},
+		{name: "Argument", expression: "arg(-1 + 0i)", want: "3.141592653589793"},
+		{name: "Polar form", expression: "z", output: polar, want: "5∠0.9272952180016122"},
+		{name: "Real result in polar form", expression: "abs(w * conj(w))", output: polar, want: "5"},
+		{name: "Division by zero", expression: "z / (w - w)", wantErr: true},
+		{name: "No complex remainder", expression: "z % w", wantErr: true},
+	})
+}
+
+func TestValidateCalculatorVariables(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name     string
+		variable calcv1alpha1.Variable
+		wantErr  string
+	}{
+		{name: "Valid", variable: calcv1alpha1.Variable{Name: "v", Type: calcv1alpha1.VariableTypeComplex, Value: "2i"}},
+		{
+			name:     "Invalid value",
+			variable: calcv1alpha1.Variable{Name: "v", Type: calcv1alpha1.VariableTypeComplex, Value: "2j"},
+			wantErr:  `spec.variables[2].value: Invalid value: "2j": invalid complex number "2j", expected a form such as 3+4i`,
+		},
+		{
+			name:     "Reserved name",
+			variable: calcv1alpha1.Variable{Name: "x", Type: calcv1alpha1.VariableTypeComplex, Value: "2i"},
+			wantErr:  `spec.variables[2].name: Invalid value: "x": x and y are reserved`,
+		},
+		{
+			name:     "Unknown type",
+			variable: calcv1alpha1.Variable{Name: "v", Type: "Quaternion", Value: "2i"},
+			wantErr:  `spec.variables[2].value: Invalid value: "2i": unknown type "Quaternion"`,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calc := typedCalculator("z + v", complexVariables)
+			calc.Spec.Variables = append(calc.Spec.Variables, tt.variable)
+
+			errs := service.CalculatorService{}.ValidateCalculator(calc)
+			if tt.wantErr == "" {
+				assert.Empty(t, errs)
+
+				return
+			}
+
+			assert.EqualError(t, errs.ToAggregate(), tt.wantErr)
+		})
+	}
+}
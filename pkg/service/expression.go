@@ -0,0 +1,555 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Node is a node of a parsed expression.
+type Node interface {
+	// Pos returns the offset of the node in the expression.
+	Pos() int
+}
+
+// Literal is a constant operand.
+type Literal struct {
+	Offset int
+	Value  Value
+}
+
+// Pos returns the offset of the literal.
+func (n *Literal) Pos() int { return n.Offset }
+
+// Variable is a named operand, such as x and y.
+type Variable struct {
+	Offset int
+	Name   string
+}
+
+// Pos returns the offset of the variable.
+func (n *Variable) Pos() int { return n.Offset }
+
+// Call is an operation of the registry applied to its arguments. Infix operators are parsed into the
+// calls of the operations bound to them.
+type Call struct {
+	Offset int
+	Name   string
+	Args   []Node
+}
+
+// Pos returns the offset of the call.
+func (n *Call) Pos() int { return n.Offset }
+
+// infixOperators binds the infix operators to their operation and precedence.
+var infixOperators = map[string]struct {
+	op         string
+	precedence int
+	rightAssoc bool
+}{
+	"<":  {op: OpLt, precedence: 1},
+	"<=": {op: OpLe, precedence: 1},
+	">":  {op: OpGt, precedence: 1},
+	">=": {op: OpGe, precedence: 1},
+	"==": {op: OpEq, precedence: 1},
+	"!=": {op: OpNe, precedence: 1},
+	"+":  {op: OpAdd, precedence: 2},
+	"-":  {op: OpSub, precedence: 2},
+	"*":  {op: OpMul, precedence: 3},                   //nolint:gomnd // Binds tighter than + and -
+	"/":  {op: OpDiv, precedence: 3},                   //nolint:gomnd // Binds tighter than + and -
+	"%":  {op: OpMod, precedence: 3},                   //nolint:gomnd // Binds tighter than + and -
+	"^":  {op: OpPow, precedence: 5, rightAssoc: true}, //nolint:gomnd // Binds tighter than unary minus
+}
+
+// unaryPrecedence makes -2^2 parse as -(2^2).
+const unaryPrecedence = 4
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func lex(expression string) ([]token, error) {
+	var tokens []token
+
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+
+			// A decimal literal is an exact rational.
+			if i+1 < len(runes) && runes[i] == '.' && unicode.IsDigit(runes[i+1]) {
+				i++
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+
+			// An imaginary literal such as 4i or 0.5i.
+			if i < len(runes) && runes[i] == 'i' && (i+1 == len(runes) || !isIdentRune(runes[i+1])) {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), offset: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), offset: start})
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", offset: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", offset: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", offset: i})
+			i++
+		case strings.ContainsRune("+-*/%^", r):
+			tokens = append(tokens, token{kind: tokenOperator, text: string(r), offset: i})
+			i++
+		case strings.ContainsRune("<>=!", r):
+			// The comparisons < and > may be followed by =, while = and ! must be.
+			operator := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				operator += "="
+			} else if r == '=' || r == '!' {
+				return nil, Invalidf("unexpected %q at %d", r, i)
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, offset: i})
+			i += len(operator)
+		default:
+			return nil, Invalidf("unexpected %q at %d", r, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, offset: len(runes)}), nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses an expression made of integers, decimals such as 0.25 parsed into exact rationals, imaginary
+// numbers such as 4i, variables, the infix operators + - * / % ^, the comparisons < <= > >= == !=, conversions to
+// units such as rate to Mbit/s, parentheses and calls of registered operations such as add(x, y, 1).
+func Parse(expression string) (Node, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	node, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, Invalidf("unexpected %q at %d", next.text, next.offset)
+	}
+
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// parseExpression parses operators binding tighter than minPrecedence by precedence climbing.
+func (p *parser) parseExpression(minPrecedence int) (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+
+		// The conversion to a unit binds the loosest, so that a + b to GB converts the sum.
+		if t.kind == tokenIdent && t.text == OpTo && minPrecedence == 0 {
+			p.next()
+
+			unit, err := p.parseUnit()
+			if err != nil {
+				return nil, err
+			}
+
+			left = &Call{Offset: t.offset, Name: OpTo, Args: []Node{left, unit}}
+
+			continue
+		}
+
+		operator, ok := infixOperators[t.text]
+		if t.kind != tokenOperator || !ok || operator.precedence < minPrecedence {
+			return left, nil
+		}
+
+		p.next()
+
+		nextPrecedence := operator.precedence + 1
+		if operator.rightAssoc {
+			nextPrecedence = operator.precedence
+		}
+
+		right, err := p.parseExpression(nextPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &Call{Offset: t.offset, Name: operator.op, Args: []Node{left, right}}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	t := p.peek()
+
+	if t.kind == tokenOperator && (t.text == "-" || t.text == "+") {
+		p.next()
+
+		operand, err := p.parseExpression(unaryPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.text == "+" {
+			return operand, nil
+		}
+
+		return &Call{Offset: t.offset, Name: OpNeg, Args: []Node{operand}}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber:
+		if strings.HasSuffix(t.text, "i") {
+			f, err := strconv.ParseFloat(strings.TrimSuffix(t.text, "i"), 64)
+			if err != nil {
+				return nil, Invalidf("invalid number %q at %d", t.text, t.offset)
+			}
+
+			return &Literal{Offset: t.offset, Value: ComplexValue{Complex: complex(0, f)}}, nil
+		}
+
+		if strings.ContainsRune(t.text, '.') {
+			r, ok := new(big.Rat).SetString(t.text)
+			if !ok {
+				return nil, Invalidf("invalid number %q at %d", t.text, t.offset)
+			}
+
+			return &Literal{Offset: t.offset, Value: RatValue{Rat: r}}, nil
+		}
+
+		i, ok := new(big.Int).SetString(t.text, 10) //nolint:gomnd // Decimal literal
+		if !ok {
+			return nil, Invalidf("invalid number %q at %d", t.text, t.offset)
+		}
+
+		return &Literal{Offset: t.offset, Value: IntValue{Int: i}}, nil
+	case tokenIdent:
+		if p.peek().kind != tokenLParen {
+			return &Variable{Offset: t.offset, Name: t.text}, nil
+		}
+
+		p.next()
+
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Call{Offset: t.offset, Name: t.text, Args: args}, nil
+	case tokenLParen:
+		node, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, Invalidf("expected ) at %d", closing.offset)
+		}
+
+		return node, nil
+	case tokenEOF:
+		return nil, Invalidf("unexpected end of expression")
+	default:
+		return nil, Invalidf("unexpected %q at %d", t.text, t.offset)
+	}
+}
+
+// parseUnit parses the unit of a conversion, such as Mbit/s or m/s^2, into a measure of 1 of the unit. A * or a /
+// followed by a number ends the unit, so that d to km * 2 doubles the conversion.
+func (p *parser) parseUnit() (Node, error) {
+	start := p.next()
+	if start.kind != tokenIdent {
+		return nil, Invalidf("expected a unit at %d", start.offset)
+	}
+
+	text := start.text
+
+	for {
+		t := p.peek()
+
+		// An operator is followed by at least the end of the expression.
+		switch {
+		case t.kind == tokenOperator && (t.text == "*" || t.text == "/") && p.tokens[p.pos+1].kind == tokenIdent:
+			text += t.text + p.tokens[p.pos+1].text
+			p.pos += 2
+		case t.kind == tokenOperator && t.text == "^":
+			p.next()
+			text += "^"
+
+			if sign := p.peek(); sign.kind == tokenOperator && sign.text == "-" {
+				text += p.next().text
+			}
+
+			exponent := p.next()
+			if exponent.kind != tokenNumber {
+				return nil, Invalidf("expected an exponent at %d", exponent.offset)
+			}
+
+			text += exponent.text
+		default:
+			unit, err := ParseUnit(text)
+			if err != nil {
+				return nil, Invalidf("%s at %d", err, start.offset)
+			}
+
+			return &Literal{Offset: start.offset, Value: MeasureValue{Value: 1, Unit: unit}}, nil
+		}
+	}
+}
+
+func (p *parser) parseArgs() ([]Node, error) {
+	var args []Node
+
+	if p.peek().kind == tokenRParen {
+		p.next()
+
+		return args, nil
+	}
+
+	for {
+		arg, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+
+		switch t := p.next(); t.kind { //nolint:exhaustive // Any other token is an error
+		case tokenComma:
+		case tokenRParen:
+			return args, nil
+		default:
+			return nil, Invalidf("expected , or ) at %d", t.offset)
+		}
+	}
+}
+
+// Depth returns the nesting depth of the expression, a single operand has depth 0.
+func Depth(node Node) int {
+	call, ok := node.(*Call)
+	if !ok {
+		return 0
+	}
+
+	depth := 0
+
+	for _, arg := range call.Args {
+		if d := Depth(arg); d > depth {
+			depth = d
+		}
+	}
+
+	return depth + 1
+}
+
+// Operands returns the number of literals and variables of the expression.
+func Operands(node Node) int {
+	call, ok := node.(*Call)
+	if !ok {
+		return 1
+	}
+
+	operands := 0
+
+	for _, arg := range call.Args {
+		operands += Operands(arg)
+	}
+
+	return operands
+}
+
+// Check type checks the expression against the registry and returns its result type.
+func Check(node Node, registry *Registry, variables map[string]Type) (Type, error) {
+	switch n := node.(type) {
+	case *Literal:
+		return n.Value.Type(), nil
+	case *Variable:
+		t, ok := variables[n.Name]
+		if !ok {
+			return "", Invalidf("unknown variable %q at %d", n.Name, n.Offset)
+		}
+
+		return t, nil
+	case *Call:
+		evaluator, ok := registry.Lookup(n.Name)
+		if !ok {
+			return "", Invalidf("unknown operation %q at %d", n.Name, n.Offset)
+		}
+
+		args := make([]Type, 0, len(n.Args))
+
+		for _, arg := range n.Args {
+			t, err := Check(arg, registry, variables)
+			if err != nil {
+				return "", err
+			}
+
+			args = append(args, t)
+		}
+
+		signature, err := evaluator.Resolve(args)
+		if err != nil {
+			return "", Invalidf("%s at %d", err, n.Offset)
+		}
+
+		if evaluator.Infer == nil {
+			return signature.Result, nil
+		}
+
+		result, err := evaluator.Infer(args)
+		if err != nil {
+			return "", Invalidf("%s: %s at %d", n.Name, err, n.Offset)
+		}
+
+		if result == "" {
+			return signature.Result, nil
+		}
+
+		return result, nil
+	default:
+		return "", Invalidf("unknown node %T", node)
+	}
+}
+
+// Evaluate evaluates a type checked expression. Every intermediate result is checked against the
+// limits of the context, and the evaluation stops before the next operation once the context is done.
+func Evaluate(ctx context.Context, node Node, registry *Registry, variables map[string]Value) (Value, error) {
+	if err := interrupted(ctx); err != nil {
+		return nil, err
+	}
+
+	switch n := node.(type) {
+	case *Literal:
+		return n.Value, nil
+	case *Variable:
+		v, ok := variables[n.Name]
+		if !ok {
+			return nil, Invalidf("unknown variable %q at %d", n.Name, n.Offset)
+		}
+
+		return v, nil
+	case *Call:
+		evaluator, ok := registry.Lookup(n.Name)
+		if !ok {
+			return nil, Invalidf("unknown operation %q at %d", n.Name, n.Offset)
+		}
+
+		args := make([]Value, 0, len(n.Args))
+		types := make([]Type, 0, len(n.Args))
+
+		for _, arg := range n.Args {
+			v, err := Evaluate(ctx, arg, registry, variables)
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, v)
+			types = append(types, v.Type())
+		}
+
+		signature, err := evaluator.Resolve(types)
+		if err != nil {
+			return nil, Invalidf("%s at %d", err, n.Offset)
+		}
+
+		if evaluator.Infer != nil {
+			if _, err = evaluator.Infer(types); err != nil {
+				return nil, Invalidf("%s: %s at %d", n.Name, err, n.Offset)
+			}
+		}
+
+		for i, arg := range args {
+			if args[i], err = promote(arg, signature.Param(i)); err != nil {
+				return nil, Invalidf("%s: %s at %d", n.Name, err, n.Offset)
+			}
+		}
+
+		// The arguments may have used up the time of the calculation.
+		if err = interrupted(ctx); err != nil {
+			return nil, err
+		}
+
+		result, err := evaluator.Eval(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = LimitsFromContext(ctx).checkValue(result); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	default:
+		return nil, Invalidf("unknown node %T", node)
+	}
+}
@@ -0,0 +1,161 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+func TestEvaluate(t *testing.T) { //nolint:funlen // Test function
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "Default expression",
+			expression: service.DefaultExpression,
+			want:       "10",
+		},
+		{
+			name:       "Precedence",
+			expression: "x + y * 2",
+			want:       "13",
+		},
+		{
+			name:       "Parentheses",
+			expression: "(x + y) * 2",
+			want:       "20",
+		},
+		{
+			name:       "Power is right associative",
+			expression: "2 ^ 3 ^ 2",
+			want:       "512",
+		},
+		{
+			name:       "Unary minus binds looser than power",
+			expression: "-2 ^ 2",
+			want:       "-4",
+		},
+		{
+			name:       "Variadic call",
+			expression: "add(x, y, 1, mul(2, 3))",
+			want:       "17",
+		},
+		{
+			name:       "Truncated division",
+			expression: "-7 / 2 + -7 % 2",
+			want:       "-4",
+		},
+		{
+			name:       "Big integers",
+			expression: "9223372036854775807 + 1",
+			want:       "9223372036854775808",
+		},
+		{
+			name:       "Division by zero",
+			expression: "x / (y - 3)",
+			wantErr:    true,
+		},
+		{
+			name:       "Negative exponent",
+			expression: "2 ^ -1",
+			wantErr:    true,
+		},
+		{
+			name:       "Integer promoted to rational",
+			expression: "x / 2 + 0.5",
+			want:       "7/2",
+		},
+		{
+			name:       "Rational exponent",
+			expression: "0.5 ^ 2 ^ 2",
+			want:       "1/16",
+		},
+	}
+
+	variables := map[string]service.Value{"x": service.NewInt(7), "y": service.NewInt(3)}
+	registry := service.NewDefaultRegistry()
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			node, err := service.Parse(tt.expression)
+			assert.NoError(t, err)
+
+			got, err := service.Evaluate(context.Background(), node, registry, variables)
+			if tt.wantErr {
+				assert.True(t, service.IsInvalid(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{name: "Valid", expression: "sub(x, 1) ^ 2"},
+		{name: "Syntax error", expression: "x +", wantErr: "unexpected end of expression"},
+		{name: "Unbalanced parentheses", expression: "(x + y", wantErr: "expected ) at 6"},
+		{name: "Unexpected character", expression: "x $ y", wantErr: `unexpected '$' at 2`},
+		{name: "Unknown variable", expression: "x + z", wantErr: `unknown variable "z" at 4`},
+		{name: "Unknown operation", expression: "max(x, y)", wantErr: `unknown operation "max" at 0`},
+		{name: "Wrong arity", expression: "sub(x)", wantErr: "sub does not accept (int) at 0"},
+	}
+
+	variables := map[string]service.Type{"x": service.TypeInt, "y": service.TypeInt}
+	registry := service.NewDefaultRegistry()
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			node, err := service.Parse(tt.expression)
+			if err == nil {
+				_, err = service.Check(node, registry, variables)
+			}
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, tt.wantErr)
+			assert.True(t, service.IsInvalid(err))
+		})
+	}
+}
+
+func TestDepthAndOperands(t *testing.T) {
+	t.Parallel()
+
+	node, err := service.Parse("add(x, y * (2 + 3), 4)")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, service.Depth(node))
+	assert.Equal(t, 5, service.Operands(node))
+}
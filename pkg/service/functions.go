@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"strings"
+)
+
+// Names of the builtin math functions.
+const (
+	OpSqrt      = "sqrt"
+	OpLog       = "log"
+	OpExp       = "exp"
+	OpSin       = "sin"
+	OpCos       = "cos"
+	OpTan       = "tan"
+	OpAsin      = "asin"
+	OpAcos      = "acos"
+	OpAtan      = "atan"
+	OpFloor     = "floor"
+	OpCeil      = "ceil"
+	OpGcd       = "gcd"
+	OpLcm       = "lcm"
+	OpFactorial = "factorial"
+)
+
+// MaxFactorial is the largest argument of factorial.
+const MaxFactorial = 100000
+
+// factorialChunk is the number of factors multiplied between two checks of the context.
+const factorialChunk = 1024
+
+var factorialDomain = fmt.Sprintf("the argument must be between 0 and %d", MaxFactorial)
+
+// mathFunctions returns the catalog of the builtin math functions. The kubectl calc functions listing is
+// generated from it through the registry.
+func mathFunctions() []Evaluator {
+	return []Evaluator{
+		elementary(OpSqrt, "Square root, the principal one for complex numbers.",
+			"a real argument must be non-negative", math.Sqrt, cmplx.Sqrt),
+		elementary(OpLog, "Natural logarithm, the principal value for complex numbers.",
+			"a real argument must be positive, a complex one non-zero", math.Log, cmplx.Log),
+		elementary(OpExp, "Exponential function.", "the result must not overflow", math.Exp, cmplx.Exp),
+		elementary(OpSin, "Sine of an angle in radians.", "", math.Sin, cmplx.Sin),
+		elementary(OpCos, "Cosine of an angle in radians.", "", math.Cos, cmplx.Cos),
+		elementary(OpTan, "Tangent of an angle in radians.", "the result must not overflow", math.Tan, cmplx.Tan),
+		elementary(OpAsin, "Arcsine in radians.", "a real argument must be between -1 and 1", math.Asin, cmplx.Asin),
+		elementary(OpAcos, "Arccosine in radians.", "a real argument must be between -1 and 1", math.Acos, cmplx.Acos),
+		elementary(OpAtan, "Arctangent in radians.", "a complex argument must not be i or -i", math.Atan, cmplx.Atan),
+		{
+			Name: OpFloor, Doc: "Largest integer less than or equal to the argument.",
+			Signatures: []Signature{unary(TypeInt), {Params: []Type{TypeRational}, Result: TypeInt},
+				{Params: []Type{TypeReal}, Result: TypeInt}},
+			Eval: rounding(math.Floor, false),
+		},
+		{
+			Name: OpCeil, Doc: "Smallest integer greater than or equal to the argument.",
+			Signatures: []Signature{unary(TypeInt), {Params: []Type{TypeRational}, Result: TypeInt},
+				{Params: []Type{TypeReal}, Result: TypeInt}},
+			Eval: rounding(math.Ceil, true),
+		},
+		{
+			Name: OpGcd, Doc: "Greatest common divisor of the arguments, non-negative, gcd(0, 0) being 0.",
+			Signatures: []Signature{nAry(TypeInt)},
+			Eval: foldInt(func(z, x, y *big.Int) *big.Int {
+				return z.GCD(nil, nil, new(big.Int).Abs(x), new(big.Int).Abs(y))
+			}),
+		},
+		{
+			Name: OpLcm, Doc: "Least common multiple of the arguments, non-negative, 0 when an argument is 0.",
+			Signatures: []Signature{nAry(TypeInt)},
+			Eval:       foldInt(lcm),
+		},
+		{
+			Name: OpFactorial, Doc: "Product of the positive integers up to the argument, factorial(0) being 1.",
+			Domain:     factorialDomain,
+			Signatures: []Signature{unary(TypeInt)},
+			Eval:       factorial,
+		},
+	}
+}
+
+// elementary returns a function of a real or a complex argument. An argument is out of the domain of the
+// function when the result is not finite, such as sqrt(-1) for reals.
+func elementary(name, doc, domain string, realFunc func(float64) float64, complexFunc func(complex128) complex128,
+) Evaluator {
+	return Evaluator{
+		Name: name, Doc: doc, Domain: domain,
+		Signatures: []Signature{unary(TypeReal), unary(TypeComplex)},
+		Eval: byType(map[Type]EvalFunc{
+			TypeReal:    inDomain(name, domain, mapReal(realFunc)),
+			TypeComplex: inDomain(name, domain, mapComplex(complexFunc)),
+		}),
+	}
+}
+
+// inDomain reports the non-finite results of the evaluation as domain errors of the function.
+func inDomain(name, domain string, eval EvalFunc) EvalFunc {
+	return func(ctx context.Context, args []Value) (Value, error) {
+		result, err := eval(ctx, args)
+		if err == errNotFinite { //nolint:errorlint // Sentinel returned as is
+			return nil, domainError(name, domain, args)
+		}
+
+		return result, err
+	}
+}
+
+// domainError reports arguments out of the domain of a function.
+func domainError(name, domain string, args []Value) error {
+	values := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		values = append(values, arg.String())
+	}
+
+	if domain == "" {
+		return Invalidf("%s(%s) is not a finite number", name, strings.Join(values, ", "))
+	}
+
+	return Invalidf("%s(%s) is undefined: %s", name, strings.Join(values, ", "), domain)
+}
+
+// rounding returns floor or ceil, rounding the reals with round.
+func rounding(round func(float64) float64, up bool) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		switch v := args[0].(type) {
+		case IntValue:
+			return v, nil
+		case RatValue:
+			// The Euclidean quotient of a positive denominator is the floor.
+			quotient := new(big.Int).Div(v.Rat.Num(), v.Rat.Denom())
+			if up && !v.Rat.IsInt() {
+				quotient.Add(quotient, big.NewInt(1))
+			}
+
+			return IntValue{Int: quotient}, nil
+		case RealValue:
+			i, _ := big.NewFloat(round(v.Float)).Int(nil)
+
+			return IntValue{Int: i}, nil
+		default:
+			return nil, Invalidf("unexpected %s argument", args[0].Type())
+		}
+	}
+}
+
+func lcm(z, x, y *big.Int) *big.Int {
+	if x.Sign() == 0 || y.Sign() == 0 {
+		return z.SetInt64(0)
+	}
+
+	gcd := new(big.Int).GCD(nil, nil, new(big.Int).Abs(x), new(big.Int).Abs(y))
+	product := new(big.Int).Abs(new(big.Int).Mul(x, y))
+
+	return z.Quo(product, gcd)
+}
+
+func factorial(ctx context.Context, args []Value) (Value, error) {
+	n, err := asInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if n.Int.Sign() < 0 || n.Int.Cmp(big.NewInt(MaxFactorial)) > 0 {
+		return nil, domainError(OpFactorial, factorialDomain, args)
+	}
+
+	// Estimate the digits of the result before computing it, from log10(n!) = lgamma(n + 1) / ln(10).
+	if limits := LimitsFromContext(ctx); limits.MaxDigits > 0 {
+		lgamma, _ := math.Lgamma(float64(n.Int.Int64()) + 1)
+
+		if digits := lgamma / math.Ln10; digits > float64(limits.MaxDigits) {
+			return nil, &LimitExceededError{
+				Limit:   LimitMaxDigits,
+				Message: fmt.Sprintf("factorial(%s) has more than %d digits", n, limits.MaxDigits),
+			}
+		}
+	}
+
+	// The product is computed by chunks, stopping once the context is done.
+	result := big.NewInt(1)
+
+	for low := int64(1); low <= n.Int.Int64(); low += factorialChunk {
+		if err = interrupted(ctx); err != nil {
+			return nil, err
+		}
+
+		high := low + factorialChunk - 1
+		if high > n.Int.Int64() {
+			high = n.Int.Int64()
+		}
+
+		result.Mul(result, new(big.Int).MulRange(low, high))
+	}
+
+	return IntValue{Int: result}, nil
+}
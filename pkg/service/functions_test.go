@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+// largeLiteral is an integer literal above the largest float64.
+var largeLiteral = "2" + strings.Repeat("0", 308)
+
+func TestMathFunctions(t *testing.T) { //nolint:funlen // Test table
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name       string
+		mode       calcv1alpha1.Mode
+		expression string
+		want       string
+		wantErr    string
+	}{
+		{name: "Square root", expression: "sqrt(x * 4)", want: "2"},
+		{name: "Square root of -1 in real mode", mode: calcv1alpha1.ModeReal, expression: "sqrt(-1)",
+			wantErr: "sqrt(-1) is undefined: a real argument must be non-negative"},
+		{name: "Square root of -1 in complex mode", mode: calcv1alpha1.ModeComplex, expression: "sqrt(-1)", want: "0+1i"},
+		{name: "Logarithm", expression: "log(exp(2))", want: "2"},
+		{name: "Logarithm of zero", expression: "log(y - y)",
+			wantErr: "log(0) is undefined: a real argument must be positive, a complex one non-zero"},
+		{name: "Exponential overflow", expression: "exp(1000)",
+			wantErr: "exp(1000) is undefined: the result must not overflow"},
+		{name: "Literal too large for real mode", mode: calcv1alpha1.ModeReal, expression: largeLiteral + " - 1",
+			wantErr: "the int is too large for the real type at 0"},
+		{name: "Literal too large for complex mode", mode: calcv1alpha1.ModeComplex, expression: "x + " + largeLiteral,
+			wantErr: "the int is too large for the complex type at 4"},
+		{name: "Integer too large for a real argument", expression: "sqrt(factorial(200))",
+			wantErr: "sqrt: the int is too large for the real type at 0"},
+		{name: "Trigonometry", mode: calcv1alpha1.ModeReal, expression: "sin(0) + cos(0) + tan(0)", want: "1"},
+		{name: "Arcsine", expression: "asin(x)", want: "1.5707963267948966"},
+		{name: "Arcsine out of its domain", expression: "acos(y)",
+			wantErr: "acos(3) is undefined: a real argument must be between -1 and 1"},
+		{name: "Arctangent of i", expression: "atan(1i)",
+			wantErr: "atan(0+1i) is undefined: a complex argument must not be i or -i"},
+		{name: "Floor of a fraction", mode: calcv1alpha1.ModeRational, expression: "floor(-y / 2)", want: "-2"},
+		{name: "Ceil of a fraction", mode: calcv1alpha1.ModeRational, expression: "ceil(-y / 2)", want: "-1"},
+		{name: "Floor of a real", expression: "floor(sqrt(y))", want: "1"},
+		{name: "Ceil of a real", expression: "ceil(sqrt(y)) * 2", want: "4"},
+		{name: "Greatest common divisor", expression: "gcd(12, -18, 8)", want: "2"},
+		{name: "Least common multiple", expression: "lcm(4, 6, y)", want: "12"},
+		{name: "Least common multiple of zero", expression: "lcm(4, 0)", want: "0"},
+		{name: "Factorial", expression: "factorial(20)", want: "2432902008176640000"},
+		{name: "Factorial of zero", expression: "factorial(x - 1)", want: "1"},
+		{name: "Negative factorial", expression: "factorial(-y)",
+			wantErr: "factorial(-3) is undefined: the argument must be between 0 and 100000"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calc := &calcv1alpha1.Calculator{
+				Spec: calcv1alpha1.CalculatorSpec{X: 1, Y: 3, Expression: tt.expression, Mode: tt.mode},
+			}
+
+			err := service.CalculatorService{}.ProcessCalculator(context.Background(), calc)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.True(t, service.IsInvalid(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, calc.Status.Value)
+		})
+	}
+}
+
+func TestMathFunctionsTypeChecking(t *testing.T) {
+	t.Parallel()
+
+	for expression, want := range map[string]string{
+		"sqrt(x, y)":      "sqrt does not accept (int, int) at 0",
+		"factorial(0.5)":  "factorial does not accept (rational) at 0",
+		"gcd(x)":          "gcd does not accept (int) at 0",
+		"floor(1i)":       "floor does not accept (complex) at 0",
+		"factorial(sqrt)": `unknown variable "sqrt" at 10`,
+	} {
+		calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{Expression: expression}}
+
+		errs := service.CalculatorService{}.ValidateCalculator(calc)
+		if assert.Len(t, errs, 1, expression) {
+			assert.Equal(t, want, errs[0].Detail, expression)
+		}
+	}
+}
+
+func TestFactorialLimit(t *testing.T) {
+	t.Parallel()
+
+	calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{Expression: "factorial(1000)"}}
+
+	err := service.CalculatorService{Limits: service.DefaultLimits()}.ProcessCalculator(context.Background(), calc)
+	assert.True(t, service.IsLimitExceeded(err))
+}
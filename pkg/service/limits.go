@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Default limits of a single calculation.
+const (
+	DefaultTimeout     = time.Second
+	DefaultMaxDigits   = 1000
+	DefaultMaxDepth    = 32
+	DefaultMaxOperands = 256
+)
+
+// Names of the limits reported by LimitExceededError.
+const (
+	LimitTimeout     = "timeout"
+	LimitMaxDigits   = "maxDigits"
+	LimitMaxDepth    = "maxDepth"
+	LimitMaxOperands = "maxOperands"
+)
+
+// ErrLimitExceeded is matched by every LimitExceededError.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Limits configures the limits of a single calculation. A zero value disables the limit.
+type Limits struct {
+	// Timeout is the maximum duration of a calculation.
+	Timeout time.Duration
+	// MaxDigits is the maximum number of decimal digits of a result.
+	MaxDigits int
+	// MaxDepth is the maximum nesting depth of a calculation.
+	MaxDepth int
+	// MaxOperands is the maximum number of operands of a calculation.
+	MaxOperands int
+}
+
+// DefaultLimits returns the default limits.
+func DefaultLimits() Limits {
+	return Limits{
+		Timeout:     DefaultTimeout,
+		MaxDigits:   DefaultMaxDigits,
+		MaxDepth:    DefaultMaxDepth,
+		MaxOperands: DefaultMaxOperands,
+	}
+}
+
+// LimitExceededError reports a calculation exceeding one of its limits.
+type LimitExceededError struct {
+	// Limit is the name of the exceeded limit.
+	Limit string
+	// Message describes the violation.
+	Message string
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s limit exceeded: %s", e.Limit, e.Message)
+}
+
+// Is makes errors.Is match ErrLimitExceeded.
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded //nolint:errorlint // Sentinel comparison
+}
+
+// IsLimitExceeded reports whether the error is caused by a calculation exceeding one of its limits.
+func IsLimitExceeded(err error) bool {
+	return errors.Is(err, ErrLimitExceeded)
+}
+
+// CheckDepth checks the nesting depth of a calculation.
+func (l Limits) CheckDepth(depth int) error {
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &LimitExceededError{Limit: LimitMaxDepth, Message: fmt.Sprintf("depth %d > %d", depth, l.MaxDepth)}
+	}
+
+	return nil
+}
+
+// CheckOperands checks the number of operands of a calculation.
+func (l Limits) CheckOperands(operands int) error {
+	if l.MaxOperands > 0 && operands > l.MaxOperands {
+		return &LimitExceededError{
+			Limit:   LimitMaxOperands,
+			Message: fmt.Sprintf("%d operands > %d", operands, l.MaxOperands),
+		}
+	}
+
+	return nil
+}
+
+// CheckDigits checks the number of decimal digits of a number given in its decimal form.
+func (l Limits) CheckDigits(number string) error {
+	digits := 0
+
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+
+	if l.MaxDigits > 0 && digits > l.MaxDigits {
+		return &LimitExceededError{Limit: LimitMaxDigits, Message: fmt.Sprintf("%d digits > %d", digits, l.MaxDigits)}
+	}
+
+	return nil
+}
+
+// checkValue checks the digits of the arbitrary precision values, the floating point values have a bounded number
+// of digits.
+func (l Limits) checkValue(value Value) error {
+	switch value.(type) {
+	case IntValue, RatValue:
+		return l.CheckDigits(value.String())
+	default:
+		return nil
+	}
+}
+
+// run runs the evaluation within the timeout. The evaluation runs in its own goroutine so that a
+// computation ignoring the context does not hold the caller past the deadline, it keeps running until it checks
+// the context.
+func run[T any](ctx context.Context, l Limits, evaluate func(ctx context.Context) (T, error)) (T, error) {
+	if l.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		value T
+		err   error
+	}
+
+	done := make(chan outcome, 1)
+
+	go func() {
+		value, err := evaluate(ctx)
+		done <- outcome{value: value, err: err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.value, out.err
+	case <-ctx.Done():
+		var zero T
+
+		if l.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return zero, &LimitExceededError{Limit: LimitTimeout, Message: fmt.Sprintf("calculation took more than %s",
+				l.Timeout)}
+		}
+
+		return zero, interrupted(ctx)
+	}
+}
+
+// interrupted returns the error of a calculation whose context is done, nil otherwise. The evaluators check it
+// in the loops of their long computations.
+func interrupted(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("calculation interrupted: %w", err)
+	}
+
+	return nil
+}
+
+type limitsKey struct{}
+
+// WithLimits returns a context carrying the limits of the calculation.
+func WithLimits(ctx context.Context, l Limits) context.Context {
+	return context.WithValue(ctx, limitsKey{}, l)
+}
+
+// LimitsFromContext returns the limits of the calculation, evaluators use it to reject expensive
+// operations before computing them.
+func LimitsFromContext(ctx context.Context) Limits {
+	l, _ := ctx.Value(limitsKey{}).(Limits)
+
+	return l
+}
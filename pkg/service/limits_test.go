@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitsChecks(t *testing.T) {
+	t.Parallel()
+
+	limits := Limits{MaxDigits: 3, MaxDepth: 2, MaxOperands: 4}
+
+	assert.NoError(t, limits.CheckDigits("-999"))
+	assert.NoError(t, limits.CheckDepth(2))
+	assert.NoError(t, limits.CheckOperands(4))
+
+	assert.True(t, IsLimitExceeded(limits.CheckDigits("1000")))
+	assert.True(t, IsLimitExceeded(limits.CheckDepth(3)))
+	assert.True(t, IsLimitExceeded(limits.CheckOperands(5)))
+
+	// Zero disables the limits.
+	assert.NoError(t, Limits{}.CheckDigits("123456789"))
+	assert.NoError(t, Limits{}.CheckDepth(100))
+	assert.NoError(t, Limits{}.CheckOperands(100))
+}
+
+func TestRunTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+
+	// The evaluation ignores its context, run must return anyway.
+	_, err := run(context.Background(), Limits{Timeout: 10 * time.Millisecond}, func(context.Context) (int, error) {
+		<-release
+
+		return 0, nil
+	})
+
+	var limitErr *LimitExceededError
+
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, LimitTimeout, limitErr.Limit)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRunCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := run(ctx, Limits{}, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+
+		return 0, nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, IsLimitExceeded(err))
+}
+
+func TestRunStopsEvaluators(t *testing.T) {
+	t.Parallel()
+
+	finished := make(chan error, 1)
+
+	// 3^100000000 has about 47 million digits, the evaluation stops at its next check of the context.
+	_, err := run(context.Background(), Limits{Timeout: 10 * time.Millisecond},
+		func(ctx context.Context) (Value, error) {
+			value, err := powInt(ctx, []Value{IntValue{Int: big.NewInt(3)}, IntValue{Int: big.NewInt(100000000)}})
+			finished <- err
+
+			return value, err
+		})
+	assert.True(t, IsLimitExceeded(err))
+
+	select {
+	case err = <-finished:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the evaluation kept running after the timeout")
+	}
+}
+
+func TestEvaluatorsInterrupted(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := powInt(ctx, []Value{IntValue{Int: big.NewInt(2)}, IntValue{Int: big.NewInt(10)}})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = factorial(ctx, []Value{IntValue{Int: big.NewInt(10)}})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The powers of 0, 1 and -1 are immediate.
+	value, err := powInt(ctx, []Value{IntValue{Int: big.NewInt(-1)}, IntValue{Int: big.NewInt(3)}})
+	assert.NoError(t, err)
+	assert.Equal(t, "-1", value.String())
+}
+
+func TestEvaluateInterrupted(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	registry := NewRegistry()
+	registry.MustRegister(Evaluator{
+		Name:       "expire",
+		Signatures: []Signature{{Result: TypeInt}},
+		Eval: func(context.Context, []Value) (Value, error) {
+			calls++
+			cancel()
+
+			return IntValue{Int: big.NewInt(1)}, nil
+		},
+	})
+	registry.MustRegister(Evaluator{
+		Name:       "next",
+		Signatures: []Signature{{Params: []Type{TypeInt}, Result: TypeInt}},
+		Eval: func(_ context.Context, args []Value) (Value, error) {
+			calls++
+
+			return args[0], nil
+		},
+	})
+
+	// The context is done once expire returns, next is not called.
+	_, err := Evaluate(ctx, &Call{Name: "next", Args: []Node{&Call{Name: "expire"}}}, registry, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
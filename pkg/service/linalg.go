@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Names of the builtin linear algebra operations.
+const (
+	OpDot         = "dot"
+	OpCross       = "cross"
+	OpMatMul      = "matmul"
+	OpTranspose   = "transpose"
+	OpDeterminant = "determinant"
+	OpInverse     = "inverse"
+)
+
+var errSingular = Invalidf("the matrix is singular")
+
+// linearAlgebra returns the builtin operations on vectors and matrices. Their Infer functions check the
+// dimensions of the arguments when the calculation is validated.
+func linearAlgebra() []Evaluator {
+	return []Evaluator{
+		{
+			Name: OpDot, Doc: "Dot product of two vectors of the same length.",
+			Signatures: []Signature{{Params: []Type{TypeVector, TypeVector}, Result: TypeReal}},
+			Infer: func(args []Type) (Type, error) {
+				if a, b := args[0].Dims()[0], args[1].Dims()[0]; a != b {
+					return "", Invalidf("vectors of lengths %d and %d", a, b)
+				}
+
+				return TypeReal, nil
+			},
+			Eval: func(_ context.Context, args []Value) (Value, error) {
+				a, b := args[0].(VectorValue), args[1].(VectorValue) //nolint:forcetypeassert // Signature
+
+				var dot float64
+
+				for i := range a.Elems {
+					dot += a.Elems[i] * b.Elems[i]
+				}
+
+				return newReal(dot)
+			},
+		},
+		{
+			Name: OpCross, Doc: "Cross product of two vectors of length 3.",
+			Signatures: []Signature{{Params: []Type{TypeVector, TypeVector}, Result: TypeVector}},
+			Infer: func(args []Type) (Type, error) {
+				if args[0] != VectorType(3) || args[1] != VectorType(3) {
+					return "", Invalidf("expected vectors of length 3, got %s and %s", args[0], args[1])
+				}
+
+				return VectorType(3), nil
+			},
+			Eval: func(_ context.Context, args []Value) (Value, error) {
+				a, b := args[0].(VectorValue).Elems, args[1].(VectorValue).Elems //nolint:forcetypeassert // Signature
+
+				return newVector([]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]})
+			},
+		},
+		{
+			Name: OpMatMul,
+			Doc:  "Matrix product, a vector being a column on the right and a row on the left.",
+			Signatures: []Signature{
+				{Params: []Type{TypeMatrix, TypeMatrix}, Result: TypeMatrix},
+				{Params: []Type{TypeMatrix, TypeVector}, Result: TypeVector},
+				{Params: []Type{TypeVector, TypeMatrix}, Result: TypeVector},
+			},
+			Infer: inferMatMul,
+			Eval:  matMul,
+		},
+		{
+			Name: OpTranspose, Doc: "Transpose of the matrix.",
+			Signatures: []Signature{{Params: []Type{TypeMatrix}, Result: TypeMatrix}},
+			Infer: func(args []Type) (Type, error) {
+				dims := args[0].Dims()
+
+				return MatrixType(dims[1], dims[0]), nil
+			},
+			Eval: func(_ context.Context, args []Value) (Value, error) {
+				rows := args[0].(MatrixValue).Rows //nolint:forcetypeassert // Signature
+				transposed := newRows(len(rows[0]), len(rows))
+
+				for i, row := range rows {
+					for j, elem := range row {
+						transposed[j][i] = elem
+					}
+				}
+
+				return MatrixValue{Rows: transposed}, nil
+			},
+		},
+		{
+			Name: OpDeterminant, Doc: "Determinant of the square matrix.",
+			Signatures: []Signature{{Params: []Type{TypeMatrix}, Result: TypeReal}},
+			Infer: func(args []Type) (Type, error) {
+				if err := square(args[0]); err != nil {
+					return "", err
+				}
+
+				return TypeReal, nil
+			},
+			Eval: func(ctx context.Context, args []Value) (Value, error) {
+				_, det, err := gaussJordan(ctx, args[0].(MatrixValue).Rows) //nolint:forcetypeassert // Signature
+				if err != nil {
+					return nil, err
+				}
+
+				return newReal(det)
+			},
+		},
+		{
+			Name: OpInverse, Doc: "Inverse of the square matrix.", Domain: "the matrix must not be singular",
+			Signatures: []Signature{{Params: []Type{TypeMatrix}, Result: TypeMatrix}},
+			Infer: func(args []Type) (Type, error) {
+				if err := square(args[0]); err != nil {
+					return "", err
+				}
+
+				return args[0], nil
+			},
+			Eval: func(ctx context.Context, args []Value) (Value, error) {
+				inverse, _, err := gaussJordan(ctx, args[0].(MatrixValue).Rows) //nolint:forcetypeassert // Signature
+				if err != nil {
+					return nil, err
+				}
+
+				if inverse == nil {
+					return nil, errSingular
+				}
+
+				return newMatrix(inverse)
+			},
+		},
+	}
+}
+
+// square checks that the matrix type is square.
+func square(t Type) error {
+	if dims := t.Dims(); dims[0] != dims[1] {
+		return Invalidf("expected a square matrix, got %s", t)
+	}
+
+	return nil
+}
+
+// matrixDims returns the dimensions of the matrix type, a vector being a row or a column.
+func matrixDims(t Type, column bool) (int, int) {
+	dims := t.Dims()
+
+	switch {
+	case t.Kind() == TypeMatrix:
+		return dims[0], dims[1]
+	case column:
+		return dims[0], 1
+	default:
+		return 1, dims[0]
+	}
+}
+
+func inferMatMul(args []Type) (Type, error) {
+	rows, inner := matrixDims(args[0], false)
+	otherInner, cols := matrixDims(args[1], true)
+
+	if inner != otherInner {
+		return "", Invalidf("cannot multiply %s by %s", args[0], args[1])
+	}
+
+	switch {
+	case args[0].Kind() == TypeVector:
+		return VectorType(cols), nil
+	case args[1].Kind() == TypeVector:
+		return VectorType(rows), nil
+	default:
+		return MatrixType(rows, cols), nil
+	}
+}
+
+// asRows returns the rows of a matrix, or of a vector as a row or a column.
+func asRows(v Value, column bool) [][]float64 {
+	switch v := v.(type) {
+	case MatrixValue:
+		return v.Rows
+	case VectorValue:
+		if !column {
+			return [][]float64{v.Elems}
+		}
+
+		rows := newRows(len(v.Elems), 1)
+		for i, elem := range v.Elems {
+			rows[i][0] = elem
+		}
+
+		return rows
+	default:
+		return nil
+	}
+}
+
+func matMul(_ context.Context, args []Value) (Value, error) {
+	a, b := asRows(args[0], false), asRows(args[1], true)
+	product := newRows(len(a), len(b[0]))
+
+	for i := range a {
+		for j := range b[0] {
+			for k := range b {
+				product[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+
+	switch {
+	case args[0].Type().Kind() == TypeVector:
+		return newVector(product[0])
+	case args[1].Type().Kind() == TypeVector:
+		column := make([]float64, len(product))
+		for i, row := range product {
+			column[i] = row[0]
+		}
+
+		return newVector(column)
+	default:
+		return newMatrix(product)
+	}
+}
+
+// gaussJordan returns the inverse and the determinant of the square matrix, computed exactly from the shortest
+// decimal forms of the elements before being rounded to the nearest floating point numbers, so that the inverse of
+// [[4, 7], [2, 6]] is [[0.6, -0.7], [-0.2, 0.4]]. The rows are scaled to integers and reduced by the fraction-free
+// Gauss-Jordan elimination, whose divisions are exact. The inverse is nil and the determinant zero when the matrix is
+// singular.
+func gaussJordan(ctx context.Context, m [][]float64) ([][]float64, float64, error) {
+	n := len(m)
+
+	// The matrix augmented with the identity, both scaled by the denominators of the rows: the right half
+	// becomes the inverse multiplied by the determinant of the scaled matrix.
+	augmented := make([][]*big.Int, n)
+	scale := big.NewInt(1)
+
+	for i, row := range m {
+		augmented[i] = make([]*big.Int, 2*n)
+		elems := make([]*big.Rat, n)
+		denominator := big.NewInt(1)
+
+		for j, elem := range row {
+			elems[j], _ = new(big.Rat).SetString(strconv.FormatFloat(elem, 'g', -1, 64))
+			lcm(denominator, denominator, elems[j].Denom())
+		}
+
+		for j := range augmented[i] {
+			augmented[i][j] = new(big.Int)
+		}
+
+		for j, elem := range elems {
+			augmented[i][j].Quo(denominator, elem.Denom()).Mul(augmented[i][j], elem.Num())
+		}
+
+		augmented[i][n+i].Set(denominator)
+		scale.Mul(scale, denominator)
+	}
+
+	previous := big.NewInt(1)
+	sign := 1
+	product := new(big.Int)
+
+	for col := 0; col < n; col++ {
+		if err := interrupted(ctx); err != nil {
+			return nil, 0, err
+		}
+
+		pivot := col
+		for pivot < n && augmented[pivot][col].Sign() == 0 {
+			pivot++
+		}
+
+		if pivot == n {
+			return nil, 0, nil
+		}
+
+		if pivot != col {
+			augmented[pivot], augmented[col] = augmented[col], augmented[pivot]
+			sign = -sign
+		}
+
+		value := augmented[col][col]
+
+		for row := range augmented {
+			if row == col {
+				continue
+			}
+
+			factor := augmented[row][col]
+
+			for j := range augmented[row] {
+				if j == col {
+					continue
+				}
+
+				augmented[row][j].Mul(augmented[row][j], value)
+				augmented[row][j].Sub(augmented[row][j], product.Mul(factor, augmented[col][j]))
+				augmented[row][j].Quo(augmented[row][j], previous)
+			}
+
+			factor.SetInt64(0)
+		}
+
+		previous = new(big.Int).Set(value)
+	}
+
+	inverse := newRows(n, n)
+
+	for i, row := range augmented {
+		for j, elem := range row[n:] {
+			inverse[i][j], _ = new(big.Rat).SetFrac(elem, previous).Float64()
+		}
+	}
+
+	det, _ := new(big.Rat).SetFrac(new(big.Int).Mul(previous, big.NewInt(int64(sign))), scale).Float64()
+
+	return inverse, det, nil
+}
+
+func newRows(rows, cols int) [][]float64 {
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+
+	return m
+}
+
+// newVector returns the VectorValue of the elements, which must be finite.
+func newVector(elems []float64) (Value, error) {
+	for _, elem := range elems {
+		if math.IsInf(elem, 0) || math.IsNaN(elem) {
+			return nil, errNotFinite
+		}
+	}
+
+	return VectorValue{Elems: elems}, nil
+}
+
+// newMatrix returns the MatrixValue of the rows, whose elements must be finite.
+func newMatrix(rows [][]float64) (Value, error) {
+	for _, row := range rows {
+		if _, err := newVector(row); err != nil {
+			return nil, err
+		}
+	}
+
+	return MatrixValue{Rows: rows}, nil
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var linalgVariables = []calcv1alpha1.Variable{
+	{Name: "u", Type: calcv1alpha1.VariableTypeVector, Value: "[1, 2, 3]"},
+	{Name: "v", Type: calcv1alpha1.VariableTypeVector, Value: "[4, 5, 6]"},
+	{Name: "w", Type: calcv1alpha1.VariableTypeVector, Value: "[1, 1]"},
+	{Name: "a", Type: calcv1alpha1.VariableTypeMatrix, Value: "[[4, 7], [2, 6]]"},
+	{Name: "b", Type: calcv1alpha1.VariableTypeMatrix, Value: "[[1, 2, 3], [4, 5, 6]]"},
+	{Name: "d", Type: calcv1alpha1.VariableTypeMatrix, Value: "[[2, 1], [0, 4]]"},
+	{Name: "s", Type: calcv1alpha1.VariableTypeMatrix, Value: "[[1, 2], [2, 4]]"},
+	{Name: "c", Type: calcv1alpha1.VariableTypeMatrix, Value: "[[0.1, 0.2], [0.3, 0.4]]"},
+}
+
+func TestParseMatrix(t *testing.T) {
+	t.Parallel()
+
+	m, err := service.ParseMatrix("[[1, 2.5], [-3, 4e2]]")
+	assert.NoError(t, err)
+	assert.Equal(t, service.MatrixType(2, 2), m.Type())
+	assert.Equal(t, "[[1,2.5],[-3,400]]", m.String())
+
+	for _, s := range []string{"[]", "[[]]", "[[1, 2], [3]]", "[1, 2]", `[["1"]]`} {
+		_, err := service.ParseMatrix(s)
+		assert.True(t, service.IsInvalid(err), s)
+	}
+
+	v, err := service.ParseVector("[1, 2, 3]")
+	assert.NoError(t, err)
+	assert.Equal(t, service.VectorType(3), v.Type())
+	assert.Equal(t, []int{3}, v.Type().Dims())
+	assert.Equal(t, service.TypeVector, v.Type().Kind())
+
+	for _, s := range []string{"[]", "1", "[[1]]"} {
+		_, err := service.ParseVector(s)
+		assert.True(t, service.IsInvalid(err), s)
+	}
+}
+
+func TestProcessCalculatorLinearAlgebra(t *testing.T) {
+	t.Parallel()
+
+	testProcessTyped(t, linalgVariables, []typedTest{
+		{name: "Dot product", expression: "dot(u, v)", want: "32"},
+		{name: "Cross product", expression: "cross(u, v)", want: "[-3,6,-3]"},
+		{name: "Matrix product", expression: "matmul(a, b)", want: "[[32,43,54],[26,34,42]]"},
+		{name: "Matrix by column", expression: "matmul(b, u)", want: "[14,32]"},
+		{name: "Row by matrix", expression: "matmul(w, b)", want: "[5,7,9]"},
+		{name: "Transpose", expression: "transpose(b)", want: "[[1,4],[2,5],[3,6]]"},
+		{name: "Determinant", expression: "determinant(a)", want: "10"},
+		{name: "Inverse", expression: "inverse(d)", want: "[[0.5,-0.125],[0,0.25]]"},
+		{name: "Inverse not representable in binary", expression: "inverse(a)", want: "[[0.6,-0.7],[-0.2,0.4]]"},
+		{name: "Inverse of decimals", expression: "inverse(c)", want: "[[-20,10],[15,-5]]"},
+		{name: "Determinant of decimals", expression: "determinant(c)", want: "-0.02"},
+		{name: "Real arithmetic on results", expression: "determinant(a) / 4 + dot(w, w)", want: "4.5"},
+		{name: "Singular matrix", expression: "inverse(s)", wantErr: true},
+	})
+}
+
+func TestValidateCalculatorDimensions(t *testing.T) {
+	t.Parallel()
+
+	testValidateTyped(t, linalgVariables, []typedValidationTest{
+		{name: "Valid", expression: "dot(matmul(transpose(b), w), u)"},
+		{name: "Dot product", expression: "dot(u, w)", wantErr: "dot: vectors of lengths 3 and 2 at 0"},
+		{
+			name:       "Cross product",
+			expression: "cross(w, w)",
+			wantErr:    "cross: expected vectors of length 3, got vector[2] and vector[2] at 0",
+		},
+		{
+			name:       "Matrix product",
+			expression: "matmul(b, a)",
+			wantErr:    "matmul: cannot multiply matrix[2x3] by matrix[2x2] at 0",
+		},
+		{
+			name:       "Nested dimensions",
+			expression: "determinant(matmul(a, b))",
+			wantErr:    "determinant: expected a square matrix, got matrix[2x3] at 0",
+		},
+		{name: "Not a matrix", expression: "transpose(u)", wantErr: "transpose does not accept (vector[3]) at 0"},
+	})
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// OpTo is the name of the conversion of a measure to a unit, bound to the to infix operator as in rate to Mbit/s.
+const OpTo = "to"
+
+// conversion returns the conversion of the measures to a unit of the same dimension.
+func conversion() Evaluator {
+	return Evaluator{
+		Name: OpTo, Symbol: OpTo, Doc: "First argument converted to the unit of the second argument.",
+		Domain:     "the units must have the same dimension",
+		Signatures: []Signature{{Params: []Type{TypeMeasure, TypeMeasure}, Result: TypeMeasure}},
+		Infer: func(args []Type) (Type, error) {
+			if _, err := inferMeasure(args); err != nil {
+				return "", err
+			}
+
+			return args[1], nil
+		},
+		Eval: func(_ context.Context, args []Value) (Value, error) {
+			from, to := args[0].(MeasureValue), args[1].(MeasureValue) //nolint:forcetypeassert // Signature
+
+			return newMeasure(convert(from.Value, from.Unit, to.Unit), to.Unit)
+		},
+	}
+}
+
+// unitOf returns the unit of a measure type.
+func unitOf(t Type) (Unit, error) {
+	return ParseUnit(strings.TrimSuffix(strings.TrimPrefix(string(t), string(TypeMeasure)+"["), "]"))
+}
+
+// inferMeasure returns the type of the first measure argument, the others having units of the same dimension.
+func inferMeasure(args []Type) (Type, error) {
+	var (
+		result Type
+		first  Unit
+	)
+
+	for _, arg := range args {
+		if arg.Kind() != TypeMeasure {
+			continue
+		}
+
+		unit, err := unitOf(arg)
+		if err != nil {
+			return "", err
+		}
+
+		if result == "" {
+			result, first = arg, unit
+		} else if unit.Dim != first.Dim {
+			return "", Invalidf("incompatible units %s and %s", first.Name, unit.Name)
+		}
+	}
+
+	return result, nil
+}
+
+// inferMeasureProduct returns the type of a product of measures, written in the base units, or of a measure by a
+// number.
+func inferMeasureProduct(args []Type) (Type, error) {
+	return inferMeasureDims(args, 1)
+}
+
+// inferMeasureQuotient returns the type of a quotient of measures, written in the base units, or of a measure by a
+// number.
+func inferMeasureQuotient(args []Type) (Type, error) {
+	return inferMeasureDims(args, -1)
+}
+
+func inferMeasureDims(args []Type, sign int) (Type, error) {
+	a, b, err := unitsOf(args)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case b == nil:
+		return args[0], nil
+	case a == nil && sign > 0:
+		return args[1], nil
+	}
+
+	var d Dimension
+	if a != nil {
+		d = a.Dim
+	}
+
+	d = d.combine(b.Dim, sign)
+	if d.dimensionless() {
+		return TypeReal, nil
+	}
+
+	return MeasureType(baseUnit(d).Name), nil
+}
+
+// unitsOf returns the units of two arguments, nil for a number.
+func unitsOf(args []Type) (*Unit, *Unit, error) {
+	units := make([]*Unit, len(args))
+
+	for i, arg := range args {
+		if arg.Kind() != TypeMeasure {
+			continue
+		}
+
+		unit, err := unitOf(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		units[i] = &unit
+	}
+
+	return units[0], units[1], nil
+}
+
+// newMeasure returns the MeasureValue of f, which must be finite.
+func newMeasure(f float64, unit Unit) (Value, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, errNotFinite
+	}
+
+	return MeasureValue{Value: f + 0, Unit: unit}, nil
+}
+
+// convert converts a number of a unit to another unit of the same dimension.
+func convert(f float64, from, to Unit) float64 {
+	ratio := new(big.Rat).Quo(from.Scale, to.Scale)
+	num, _ := new(big.Float).SetInt(ratio.Num()).Float64()
+	denom, _ := new(big.Float).SetInt(ratio.Denom()).Float64()
+
+	// Multiplying before dividing keeps 1500 ms to s exact.
+	return f * num / denom
+}
+
+// base returns the measure in the base units of its dimension.
+func (v MeasureValue) base() float64 {
+	return convert(v.Value, v.Unit, Unit{Scale: rat("1")})
+}
+
+// foldMeasure applies a binary operation to measures of the same dimension from left to right, in the unit of the
+// first one.
+func foldMeasure(op func(x, y float64) float64) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		first := args[0].(MeasureValue) //nolint:forcetypeassert // Signature
+		result := first.Value
+
+		for _, arg := range args[1:] {
+			m := arg.(MeasureValue) //nolint:forcetypeassert // Signature
+			result = op(result, convert(m.Value, m.Unit, first.Unit))
+		}
+
+		return newMeasure(result, first.Unit)
+	}
+}
+
+// mapMeasure applies a unary operation to a measure.
+func mapMeasure(op func(x float64) float64) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		m := args[0].(MeasureValue) //nolint:forcetypeassert // Signature
+
+		return newMeasure(op(m.Value), m.Unit)
+	}
+}
+
+// mulMeasure multiplies a measure by a number, or two measures into the base units of their dimensions.
+func mulMeasure(_ context.Context, args []Value) (Value, error) {
+	a, aOK := args[0].(MeasureValue)
+	b, bOK := args[1].(MeasureValue)
+
+	switch {
+	case !bOK:
+		return newMeasure(a.Value*args[1].(RealValue).Float, a.Unit) //nolint:forcetypeassert // Signature
+	case !aOK:
+		return newMeasure(args[0].(RealValue).Float*b.Value, b.Unit) //nolint:forcetypeassert // Signature
+	}
+
+	return newBaseMeasure(a.base()*b.base(), a.Unit.Dim.combine(b.Unit.Dim, 1))
+}
+
+// divMeasure divides a measure by a number, a number by a measure, or two measures, into the base units of their
+// dimensions.
+func divMeasure(_ context.Context, args []Value) (Value, error) {
+	a, aOK := args[0].(MeasureValue)
+	b, bOK := args[1].(MeasureValue)
+
+	switch {
+	case !bOK:
+		divisor := args[1].(RealValue).Float //nolint:forcetypeassert // Signature
+		if divisor == 0 {
+			return nil, errDivZero
+		}
+
+		return newMeasure(a.Value/divisor, a.Unit)
+	case b.Value == 0:
+		return nil, errDivZero
+	case !aOK:
+		//nolint:forcetypeassert // Signature
+		return newBaseMeasure(args[0].(RealValue).Float/b.base(), Dimension{}.combine(b.Unit.Dim, -1))
+	}
+
+	return newBaseMeasure(a.base()/b.base(), a.Unit.Dim.combine(b.Unit.Dim, -1))
+}
+
+// newBaseMeasure returns the measure of the dimension in its base units, a real number without a dimension.
+func newBaseMeasure(f float64, d Dimension) (Value, error) {
+	if d.dimensionless() {
+		return newReal(f)
+	}
+
+	return newMeasure(f, baseUnit(d))
+}
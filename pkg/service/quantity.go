@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"math/big"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// quantityScale is the number of fractional digits of the quantities, their smallest unit being the nano.
+const quantityScale = 9
+
+// inferQuantity returns the type of the quantity arguments, which must measure the same resource. The result
+// type of the signature is used without a quantity argument.
+func inferQuantity(args []Type) (Type, error) {
+	var result Type
+
+	for _, arg := range args {
+		if arg.Kind() != TypeQuantity {
+			continue
+		}
+
+		if result != "" && arg != result {
+			return "", Invalidf("cannot mix %s and %s", result, arg)
+		}
+
+		result = arg
+	}
+
+	return result, nil
+}
+
+// inferQuantityDiv checks the quantities of a division, the ratio of two quantities being a rational.
+func inferQuantityDiv(args []Type) (Type, error) {
+	result, err := inferQuantity(args)
+	if err != nil || args[1].Kind() == TypeQuantity {
+		return "", err
+	}
+
+	return result, nil
+}
+
+func asQuantity(v Value) (QuantityValue, error) {
+	q, ok := v.(QuantityValue)
+	if !ok {
+		return QuantityValue{}, Invalidf("expected %s, got %s", TypeQuantity, v.Type())
+	}
+
+	return q, nil
+}
+
+// foldQuantity applies a binary quantity operation from left to right, the result having the format of the
+// first argument. A rational argument is a quantity of the resource of the others, such as 1.5 CPU.
+func foldQuantity(op func(q *resource.Quantity, y resource.Quantity)) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		var like QuantityValue
+
+		for _, arg := range args {
+			if q, ok := arg.(QuantityValue); ok {
+				like = q
+
+				break
+			}
+		}
+
+		first, err := quantityOperand(args[0], like)
+		if err != nil {
+			return nil, err
+		}
+
+		result := first.Quantity.DeepCopy()
+
+		for _, arg := range args[1:] {
+			q, err := quantityOperand(arg, like)
+			if err != nil {
+				return nil, err
+			}
+
+			op(&result, q.Quantity)
+		}
+
+		return QuantityValue{Quantity: result, Resource: like.Resource}, nil
+	}
+}
+
+// quantityOperand returns the argument as a quantity, a rational being a quantity of the resource and the format
+// of like.
+func quantityOperand(v Value, like QuantityValue) (QuantityValue, error) {
+	r, ok := v.(RatValue)
+	if !ok {
+		return asQuantity(v)
+	}
+
+	q, err := newQuantity(r.Rat, like)
+	if err != nil {
+		return QuantityValue{}, err
+	}
+
+	return q.(QuantityValue), nil //nolint:forcetypeassert // newQuantity returns quantities
+}
+
+// mapQuantity applies a unary quantity operation.
+func mapQuantity(op func(q *resource.Quantity)) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		first, err := asQuantity(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		result := first.Quantity.DeepCopy()
+		op(&result)
+
+		return QuantityValue{Quantity: result, Resource: first.Resource}, nil
+	}
+}
+
+// mulQuantity multiplies a quantity by a rational on either side.
+func mulQuantity(_ context.Context, args []Value) (Value, error) {
+	q, factor := args[0], args[1]
+	if _, ok := q.(QuantityValue); !ok {
+		q, factor = factor, q
+	}
+
+	quantity, err := asQuantity(q)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := asRat(factor)
+	if err != nil {
+		return nil, err
+	}
+
+	return newQuantity(new(big.Rat).Mul(quantityRat(quantity.Quantity), r.Rat), quantity)
+}
+
+// divQuantity divides a quantity by a rational, or by a quantity of the same resource into a rational.
+func divQuantity(_ context.Context, args []Value) (Value, error) {
+	quantity, err := asQuantity(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var divisor *big.Rat
+
+	switch y := args[1].(type) {
+	case QuantityValue:
+		divisor = quantityRat(y.Quantity)
+	case RatValue:
+		divisor = y.Rat
+	default:
+		return nil, Invalidf("unexpected %s divisor", args[1].Type())
+	}
+
+	if divisor.Sign() == 0 {
+		return nil, errDivZero
+	}
+
+	quotient := new(big.Rat).Quo(quantityRat(quantity.Quantity), divisor)
+
+	if _, ok := args[1].(QuantityValue); ok {
+		return RatValue{Rat: quotient}, nil
+	}
+
+	return newQuantity(quotient, quantity)
+}
+
+// quantityRat returns the exact value of the quantity.
+func quantityRat(q resource.Quantity) *big.Rat {
+	r, _ := new(big.Rat).SetString(q.AsDec().String())
+
+	return r
+}
+
+// newQuantity returns the quantity of the value rounded to the nano, of the resource and the format of like.
+func newQuantity(r *big.Rat, like QuantityValue) (Value, error) {
+	parsed, err := resource.ParseQuantity(r.FloatString(quantityScale))
+	if err != nil {
+		return nil, Invalidf("%s is not a quantity: %s", r.FloatString(quantityScale), err)
+	}
+
+	return QuantityValue{
+		Quantity: *resource.NewDecimalQuantity(*parsed.AsDec(), like.Quantity.Format),
+		Resource: like.Resource,
+	}, nil
+}
+
+// absQuantity makes the quantity non-negative.
+func absQuantity(q *resource.Quantity) {
+	if q.Sign() < 0 {
+		q.Neg()
+	}
+}
@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var quantityVariables = []calcv1alpha1.Variable{
+	{Name: "request", Type: calcv1alpha1.VariableTypeQuantity, Value: "500m", Resource: "cpu"},
+	{Name: "burst", Type: calcv1alpha1.VariableTypeQuantity, Value: "1.5", Resource: "cpu"},
+	{Name: "heap", Type: calcv1alpha1.VariableTypeQuantity, Value: "512Mi", Resource: "memory"},
+	{Name: "cache", Type: calcv1alpha1.VariableTypeQuantity, Value: "2Gi", Resource: "memory"},
+	{Name: "disk", Type: calcv1alpha1.VariableTypeQuantity, Value: "1G", Resource: "ephemeral-storage"},
+}
+
+func TestParseQuantity(t *testing.T) {
+	t.Parallel()
+
+	q, err := service.ParseQuantity("1536Mi", "memory")
+	assert.NoError(t, err)
+	assert.Equal(t, service.QuantityType("memory"), q.Type())
+	assert.Equal(t, service.TypeQuantity, q.Type().Kind())
+	assert.Equal(t, "1536Mi", q.String())
+
+	for _, tt := range []struct{ value, resource string }{
+		{value: "1Gi"},
+		{value: "1Gi", resource: "Memory Size"},
+		{value: "1GiB", resource: "memory"},
+	} {
+		_, err := service.ParseQuantity(tt.value, tt.resource)
+		assert.True(t, service.IsInvalid(err), tt)
+	}
+}
+
+func TestProcessCalculatorQuantities(t *testing.T) {
+	t.Parallel()
+
+	testProcessTyped(t, quantityVariables, []typedTest{
+		{name: "CPU sum", expression: "request + burst", want: "2"},
+		{name: "Memory sum", expression: "heap + cache", want: "2560Mi"},
+		{name: "Plus a number", expression: "request + 1.5", want: "2"},
+		{name: "Number plus", expression: "1 + heap / 2", want: "268435457"},
+		{name: "Difference", expression: "request - burst", want: "-1"},
+		{name: "Number minus", expression: "2 - request", want: "1500m"},
+		{name: "Negation", expression: "-heap", want: "-512Mi"},
+		{name: "Absolute value", expression: "abs(request - burst)", want: "1"},
+		{name: "Scaled by an integer", expression: "3 * heap", want: "1536Mi"},
+		{name: "Scaled by a decimal", expression: "request * 0.25", want: "125m"},
+		{name: "Divided by an integer", expression: "cache / 4", want: "512Mi"},
+		{name: "Rounded to the nano", expression: "request / 3", want: "166666667n"},
+		{name: "Ratio", expression: "cache / heap", want: "4/1"},
+		{name: "Decimal format kept", expression: "disk * 2", want: "2G"},
+		{name: "Division by zero", expression: "heap / (heap - heap)", wantErr: true},
+	})
+}
+
+func TestValidateCalculatorQuantities(t *testing.T) {
+	t.Parallel()
+
+	testValidateTyped(t, quantityVariables, []typedValidationTest{
+		{name: "Valid", expression: "(request + burst) * 2 + request / 4"},
+		{name: "CPU and memory", expression: "request + heap",
+			wantErr: "add: cannot mix quantity[cpu] and quantity[memory] at 8"},
+		{name: "Nested", expression: "heap - (disk * 2)",
+			wantErr: "sub: cannot mix quantity[memory] and quantity[ephemeral-storage] at 5"},
+		{name: "Ratio of resources", expression: "heap / request",
+			wantErr: "div: cannot mix quantity[memory] and quantity[cpu] at 5"},
+		{name: "Number operand", expression: "2 - request + 1"},
+		{name: "Real operand", expression: "request + sqrt(2)",
+			wantErr: "add does not accept (quantity[cpu], real) at 8"},
+		{name: "Product of quantities", expression: "heap * heap",
+			wantErr: "mul does not accept (quantity[memory], quantity[memory]) at 5"},
+	})
+}
+
+func TestValidateCalculatorQuantityResource(t *testing.T) {
+	t.Parallel()
+
+	calc := typedCalculator("request", quantityVariables)
+	calc.Spec.Variables[0].Resource = ""
+
+	errs := service.CalculatorService{}.ValidateCalculator(calc)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "spec.variables[0].resource", errs[0].Field)
+	}
+}
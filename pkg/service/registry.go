@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrRegistration is returned when an evaluator cannot be registered.
+var ErrRegistration = errors.New("invalid evaluator")
+
+var evaluatorName = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
+// EvalFunc evaluates an operation. The arguments match one of the signatures of its Evaluator, promoted to its
+// parameter types. A long computation must stop once the context is done, as the timeout of the calculation only
+// stops waiting for it.
+type EvalFunc func(ctx context.Context, args []Value) (Value, error)
+
+// InferFunc returns the result type of an operation from the types of its arguments.
+type InferFunc func(args []Type) (Type, error)
+
+// Signature is a type signature of an operation.
+type Signature struct {
+	// Params are the parameter types.
+	Params []Type
+	// Variadic makes the last parameter repeatable.
+	Variadic bool
+	// Result is the result type.
+	Result Type
+}
+
+func (s Signature) String() string {
+	params := make([]string, 0, len(s.Params))
+
+	for _, param := range s.Params {
+		params = append(params, string(param))
+	}
+
+	if s.Variadic {
+		params[len(params)-1] += "..."
+	}
+
+	return fmt.Sprintf("(%s) %s", strings.Join(params, ", "), s.Result)
+}
+
+// Param returns the type of the parameter of the argument at index i.
+func (s Signature) Param(i int) Type {
+	if i >= len(s.Params) {
+		return s.Params[len(s.Params)-1]
+	}
+
+	return s.Params[i]
+}
+
+// matches reports whether the signature accepts the argument types, or their promotions when promote is set. A
+// parameter of a kind such as vector accepts the types of any dimensions.
+func (s Signature) matches(args []Type, promote bool) bool {
+	if len(args) < len(s.Params) || (!s.Variadic && len(args) != len(s.Params)) {
+		return false
+	}
+
+	for i, arg := range args {
+		if param := s.Param(i); arg != param && arg.Kind() != param && !(promote && promotes(arg, param)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Evaluator is an operation of the registry.
+type Evaluator struct {
+	// Name is the name the operation is called by in expressions.
+	Name string
+	// Symbol is the infix operator bound to the operation, if any.
+	Symbol string
+	// Doc describes the operation.
+	Doc string
+	// Domain describes the arguments the operation fails on with an InvalidError, such as a zero divisor. The
+	// operation accepts every argument of its signatures when empty.
+	Domain string
+	// Signatures are the accepted type signatures.
+	Signatures []Signature
+	// Eval evaluates the operation.
+	Eval EvalFunc
+	// Infer returns the result type of the arguments accepted by a signature, checking their dimensions, such as
+	// the columns of the first matrix of a product matching the rows of the second. The result type of the
+	// signature is used when unset, or when Infer returns an empty type.
+	Infer InferFunc
+}
+
+// Arity returns the minimum and the maximum number of arguments, the maximum is -1 when unbounded.
+func (e Evaluator) Arity() (int, int) {
+	minArgs, maxArgs := len(e.Signatures[0].Params), len(e.Signatures[0].Params)
+
+	for _, signature := range e.Signatures {
+		if len(signature.Params) < minArgs {
+			minArgs = len(signature.Params)
+		}
+
+		switch {
+		case signature.Variadic:
+			maxArgs = -1
+		case maxArgs != -1 && len(signature.Params) > maxArgs:
+			maxArgs = len(signature.Params)
+		}
+	}
+
+	return minArgs, maxArgs
+}
+
+// Resolve returns the signature accepting the argument types. A signature accepting the exact types is
+// preferred, the arguments are otherwise promoted to the parameter types of the first signature accepting them,
+// such as an int to a rational.
+func (e Evaluator) Resolve(args []Type) (Signature, error) {
+	for _, promote := range []bool{false, true} {
+		for _, signature := range e.Signatures {
+			if signature.matches(args, promote) {
+				return signature, nil
+			}
+		}
+	}
+
+	types := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		types = append(types, string(arg))
+	}
+
+	return Signature{}, Invalidf("%s does not accept (%s)", e.Name, strings.Join(types, ", "))
+}
+
+// Registry holds the operations available to calculations. It is the single source of truth for
+// the evaluation, the validation and the documentation of operations.
+type Registry struct {
+	mu         sync.RWMutex
+	evaluators map[string]Evaluator
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{evaluators: make(map[string]Evaluator)}
+}
+
+// NewDefaultRegistry returns a registry holding the builtin operations.
+func NewDefaultRegistry() *Registry {
+	registry := NewRegistry()
+
+	for _, evaluator := range builtins() {
+		registry.MustRegister(evaluator)
+	}
+
+	return registry
+}
+
+// Register adds an operation to the registry.
+func (r *Registry) Register(evaluator Evaluator) error {
+	if err := validateEvaluator(evaluator); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.evaluators[evaluator.Name]; exists {
+		return fmt.Errorf("%w: %s is already registered", ErrRegistration, evaluator.Name)
+	}
+
+	r.evaluators[evaluator.Name] = evaluator
+
+	return nil
+}
+
+// Replace adds an operation to the registry in place of the one registered under its name, if any, and reports
+// whether it replaced one. The operations of the infix operators cannot be replaced.
+func (r *Registry) Replace(evaluator Evaluator) (bool, error) {
+	if err := validateEvaluator(evaluator); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.evaluators[evaluator.Name]
+	if existing.Symbol != "" {
+		return false, fmt.Errorf("%w: %s is the operation of %s", ErrRegistration, evaluator.Name, existing.Symbol)
+	}
+
+	r.evaluators[evaluator.Name] = evaluator
+
+	return exists, nil
+}
+
+// validateEvaluator checks the name, the signatures and the eval function of an operation.
+func validateEvaluator(evaluator Evaluator) error {
+	if !evaluatorName.MatchString(evaluator.Name) {
+		return fmt.Errorf("%w: name %q must match %s", ErrRegistration, evaluator.Name, evaluatorName)
+	}
+
+	if len(evaluator.Signatures) == 0 || evaluator.Eval == nil {
+		return fmt.Errorf("%w: %s needs signatures and an eval function", ErrRegistration, evaluator.Name)
+	}
+
+	for _, signature := range evaluator.Signatures {
+		if signature.Variadic && len(signature.Params) == 0 {
+			return fmt.Errorf("%w: %s variadic signature without parameters", ErrRegistration, evaluator.Name)
+		}
+	}
+
+	return nil
+}
+
+// MustRegister adds an operation to the registry and panics on error.
+func (r *Registry) MustRegister(evaluator Evaluator) {
+	if err := r.Register(evaluator); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the operation registered under the name.
+func (r *Registry) Lookup(name string) (Evaluator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	evaluator, ok := r.evaluators[name]
+
+	return evaluator, ok
+}
+
+// Evaluators returns the registered operations sorted by name.
+func (r *Registry) Evaluators() []Evaluator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	evaluators := make([]Evaluator, 0, len(r.evaluators))
+
+	for _, evaluator := range r.evaluators {
+		evaluators = append(evaluators, evaluator)
+	}
+
+	sort.Slice(evaluators, func(i, j int) bool {
+		return evaluators[i].Name < evaluators[j].Name
+	})
+
+	return evaluators
+}
@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+func maxEvaluator() service.Evaluator {
+	return service.Evaluator{
+		Name: "max",
+		Doc:  "Largest of the arguments.",
+		Signatures: []service.Signature{
+			{Params: []service.Type{service.TypeInt}, Variadic: true, Result: service.TypeInt},
+		},
+		Eval: func(_ context.Context, args []service.Value) (service.Value, error) {
+			result := new(big.Int)
+
+			for i, arg := range args {
+				v, _ := arg.(service.IntValue)
+				if i == 0 || v.Int.Cmp(result) > 0 {
+					result.Set(v.Int)
+				}
+			}
+
+			return service.IntValue{Int: result}, nil
+		},
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name      string
+		evaluator service.Evaluator
+		wantErr   bool
+	}{
+		{name: "Custom evaluator", evaluator: maxEvaluator()},
+		{name: "Duplicate", evaluator: service.Evaluator{
+			Name: service.OpAdd, Signatures: maxEvaluator().Signatures, Eval: maxEvaluator().Eval,
+		}, wantErr: true},
+		{name: "Invalid name", evaluator: service.Evaluator{
+			Name: "Max-2", Signatures: maxEvaluator().Signatures, Eval: maxEvaluator().Eval,
+		}, wantErr: true},
+		{name: "No signatures", evaluator: service.Evaluator{Name: "min", Eval: maxEvaluator().Eval}, wantErr: true},
+		{name: "No eval", evaluator: service.Evaluator{Name: "min", Signatures: maxEvaluator().Signatures}, wantErr: true},
+		{name: "Variadic without parameters", evaluator: service.Evaluator{
+			Name: "min", Signatures: []service.Signature{{Variadic: true, Result: service.TypeInt}}, Eval: maxEvaluator().Eval,
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := service.NewDefaultRegistry().Register(tt.evaluator)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, service.ErrRegistration)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRegistryReplace(t *testing.T) {
+	t.Parallel()
+
+	registry := service.NewDefaultRegistry()
+
+	replaced, err := registry.Replace(maxEvaluator())
+	assert.NoError(t, err)
+	assert.False(t, replaced)
+
+	gcd := maxEvaluator()
+	gcd.Name = "gcd"
+
+	replaced, err = registry.Replace(gcd)
+	assert.NoError(t, err)
+	assert.True(t, replaced)
+
+	evaluator, _ := registry.Lookup("gcd")
+	assert.Equal(t, gcd.Doc, evaluator.Doc)
+
+	// The operations of the operators are kept.
+	add := maxEvaluator()
+	add.Name = service.OpAdd
+
+	_, err = registry.Replace(add)
+	assert.ErrorIs(t, err, service.ErrRegistration)
+
+	_, err = registry.Replace(service.Evaluator{Name: "min", Eval: maxEvaluator().Eval})
+	assert.ErrorIs(t, err, service.ErrRegistration)
+}
+
+func TestRegistryEvaluators(t *testing.T) {
+	t.Parallel()
+
+	registry := service.NewDefaultRegistry()
+
+	var names []string
+
+	for _, evaluator := range registry.Evaluators() {
+		names = append(names, evaluator.Name)
+	}
+
+	assert.Equal(t, []string{
+		"abs", "acos", "add", "arg", "asin", "atan", "ceil", "conj", "cos", "cross", "determinant", "div", "dot", "eq",
+		"exp", "factorial", "floor", "gcd", "ge", "gt", "inverse", "lcm", "le", "log", "lt", "matmul", "mod", "mul",
+		"ne", "neg", "pow", "sin", "sqrt", "sub", "tan", "to", "transpose",
+	}, names)
+
+	add, ok := registry.Lookup(service.OpAdd)
+	assert.True(t, ok)
+
+	minArgs, maxArgs := add.Arity()
+	assert.Equal(t, 2, minArgs)
+	assert.Equal(t, -1, maxArgs)
+	assert.Equal(t, "(int, int...) int", add.Signatures[0].String())
+}
+
+func TestCustomEvaluator(t *testing.T) {
+	t.Parallel()
+
+	registry := service.NewDefaultRegistry()
+	registry.MustRegister(maxEvaluator())
+
+	calculatorService := service.CalculatorService{Registry: registry}
+	calc := &calcv1alpha1.Calculator{
+		Spec: calcv1alpha1.CalculatorSpec{X: 4, Y: 9, Expression: "max(x, y, 5) * 2"},
+	}
+
+	assert.Empty(t, calculatorService.ValidateCalculator(calc))
+	assert.NoError(t, calculatorService.ProcessCalculator(context.Background(), calc))
+	assert.Equal(t, "18", calc.Status.Value)
+
+	// The default registry does not know the custom evaluator.
+	assert.Len(t, service.CalculatorService{}.ValidateCalculator(calc), 1)
+	assert.True(t, service.IsInvalid(service.CalculatorService{}.ProcessCalculator(context.Background(), calc)))
+}
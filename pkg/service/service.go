@@ -2,7 +2,8 @@ package service
 
 import (
 	"context"
-	"strconv"
+	"math/big"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -10,30 +11,132 @@ import (
 	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
 )
 
+const (
+	// DefaultManagedBy is the default value of the managed-by annotation.
+	DefaultManagedBy = "calc-operator"
+	// DefaultResultKey is the default secret data key of the result.
+	DefaultResultKey = "result"
+)
+
 type Repository interface {
 	// ProcessCalculator processes the given calculator.
 	ProcessCalculator(ctx context.Context, calc *calcv1alpha1.Calculator) error
-	// DefineSecret defines a calculator operator secret.
-	DefineSecret(ctx context.Context, name, namespace string, result int) (*corev1.Secret, error)
+	// DefineSecret defines a calculator operator secret holding the canonical form of the result.
+	DefineSecret(ctx context.Context, name, namespace string, value string) (*corev1.Secret, error)
+}
+
+// OutputOptions configures the secrets defined by the service.
+type OutputOptions struct {
+	// ManagedBy is the value of the managed-by annotation. Defaults to DefaultManagedBy.
+	ManagedBy string
+	// ResultKey is the data key of the result. Defaults to DefaultResultKey.
+	ResultKey string
 }
 
-type CalculatorService struct{}
+type CalculatorService struct {
+	Output OutputOptions
+	// Limits bounds every calculation, the zero value disables all limits.
+	Limits Limits
+	// Registry holds the available operations. Defaults to the builtin operations.
+	Registry *Registry
+}
 
 // ProcessCalculator processes the given calculator.
-func (c CalculatorService) ProcessCalculator(_ context.Context, calc *calcv1alpha1.Calculator) error {
-	calc.Status.Result = calc.Spec.X + calc.Spec.Y
+func (c CalculatorService) ProcessCalculator(ctx context.Context, calc *calcv1alpha1.Calculator) error {
+	loc, err := TimeZone(calc)
+	if err != nil {
+		return err
+	}
+
+	variables, err := Variables(calc)
+	if err != nil {
+		return err
+	}
+
+	node, err := c.compile(calc, variables)
+	if err != nil {
+		return err
+	}
+
+	value, err := run(WithLimits(ctx, c.Limits), c.Limits, func(ctx context.Context) (Value, error) {
+		return Evaluate(ctx, node, c.registry(), variables)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = c.Limits.checkValue(value); err != nil {
+		return err
+	}
+
+	render(calc, value, loc)
+
 	calc.Status.Processed = true
 
 	return nil
 }
 
+// render records the result in the status, in the formats of the output spec, the timestamps in the time zone.
+func render(calc *calcv1alpha1.Calculator, value Value, loc *time.Location) {
+	output := calc.Spec.Output
+	if output == nil {
+		output = &calcv1alpha1.OutputSpec{}
+	}
+
+	calc.Status.Result = 0
+	if i, ok := integer(value); ok && i.IsInt64() {
+		calc.Status.Result = int(i.Int64())
+	}
+
+	calc.Status.Value = value.String()
+	calc.Status.Decimal = ""
+
+	switch v := value.(type) {
+	case RatValue:
+		if output.Precision != nil {
+			calc.Status.Decimal = v.Decimal(int(*output.Precision))
+		}
+	case ComplexValue:
+		if output.ComplexFormat == calcv1alpha1.ComplexFormatPolar {
+			calc.Status.Value = v.Polar()
+		}
+	case TimestampValue:
+		calc.Status.Value = v.In(loc)
+	}
+}
+
+// integer returns the integer value of an integer or of a fraction with denominator 1.
+func integer(value Value) (*big.Int, bool) {
+	switch v := value.(type) {
+	case IntValue:
+		return v.Int, true
+	case RatValue:
+		if v.Rat.IsInt() {
+			return v.Rat.Num(), true
+		}
+	}
+
+	return nil, false
+}
+
 // DefineSecret defines a calculator operator secret.
-func (c CalculatorService) DefineSecret(_ context.Context, name, namespace string, result int) (*corev1.Secret, error) {
+func (c CalculatorService) DefineSecret(_ context.Context, name, namespace string, value string,
+) (*corev1.Secret, error) {
+	resultKey := c.Output.ResultKey
+	if resultKey == "" {
+		resultKey = DefaultResultKey
+	}
+
+	managedBy := c.Output.ManagedBy
+	if managedBy == "" {
+		managedBy = DefaultManagedBy
+	}
+
 	data := make(map[string]string)
-	data["result"] = strconv.Itoa(result)
+	data[resultKey] = value
 
 	annotations := make(map[string]string)
-	annotations["managed-by"] = "calc-operator"
+	annotations["managed-by"] = managedBy
 
 	immutable := false
 
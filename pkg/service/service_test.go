@@ -38,6 +38,7 @@ func TestProcessCalculator(t *testing.T) { //nolint:funlen // Test function
 				Status: calcv1alpha1.CalculatorStatus{
 					Processed: true,
 					Result:    4294967294,
+					Value:     "4294967294",
 				},
 			},
 		},
@@ -61,6 +62,7 @@ func TestProcessCalculator(t *testing.T) { //nolint:funlen // Test function
 				Status: calcv1alpha1.CalculatorStatus{
 					Processed: true,
 					Result:    2,
+					Value:     "2",
 				},
 			},
 		},
@@ -84,6 +86,7 @@ func TestProcessCalculator(t *testing.T) { //nolint:funlen // Test function
 				Status: calcv1alpha1.CalculatorStatus{
 					Processed: true,
 					Result:    2,
+					Value:     "2",
 				},
 			},
 		},
@@ -108,20 +111,154 @@ func TestProcessCalculator(t *testing.T) { //nolint:funlen // Test function
 	}
 }
 
+func TestProcessCalculatorRational(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name       string
+		expression string
+		precision  *int32
+		want       calcv1alpha1.CalculatorStatus
+	}{
+		{
+			name:       "Exact division",
+			expression: "x / y",
+			want:       calcv1alpha1.CalculatorStatus{Processed: true, Value: "2/3"},
+		},
+		{
+			name:       "Canonical reduction",
+			expression: "(x / y) * (y / x) * 4 / -6",
+			want:       calcv1alpha1.CalculatorStatus{Processed: true, Value: "-2/3"},
+		},
+		{
+			name:       "Integral result",
+			expression: "x / 3 + y / 3 + 1 / 3",
+			want:       calcv1alpha1.CalculatorStatus{Processed: true, Result: 2, Value: "2/1"},
+		},
+		{
+			name:       "Chained divisions",
+			expression: "1 / 3 / 3 * 9",
+			want:       calcv1alpha1.CalculatorStatus{Processed: true, Result: 1, Value: "1/1"},
+		},
+		{
+			name:       "Decimal literal",
+			expression: "0.1 + 0.2",
+			want:       calcv1alpha1.CalculatorStatus{Processed: true, Value: "3/10"},
+		},
+		{
+			name:       "Negative exponent",
+			expression: "(x / y) ^ -2",
+			want:       calcv1alpha1.CalculatorStatus{Processed: true, Value: "9/4"},
+		},
+		{
+			name:       "Decimal rendering",
+			expression: "x / y",
+			precision:  pointer.Int32(4),
+			want:       calcv1alpha1.CalculatorStatus{Processed: true, Value: "2/3", Decimal: "0.6667"},
+		},
+		{
+			name:       "Decimal rendering without fractional digits",
+			expression: "-x / y",
+			precision:  pointer.Int32(0),
+			want:       calcv1alpha1.CalculatorStatus{Processed: true, Value: "-2/3", Decimal: "-1"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calc := &calcv1alpha1.Calculator{
+				Spec: calcv1alpha1.CalculatorSpec{
+					X: 2, Y: 3, Expression: tt.expression, Mode: calcv1alpha1.ModeRational,
+					Output: &calcv1alpha1.OutputSpec{Precision: tt.precision},
+				},
+			}
+
+			assert.NoError(t, service.CalculatorService{}.ProcessCalculator(context.Background(), calc))
+			assert.Equal(t, tt.want, calc.Status)
+		})
+	}
+}
+
+func TestProcessCalculatorRationalErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, expression := range []string{"x / (y - 3)", "x ^ (1 / 2)", "(y - 3) ^ -1", "x % y"} {
+		calc := &calcv1alpha1.Calculator{
+			Spec: calcv1alpha1.CalculatorSpec{X: 2, Y: 3, Expression: expression, Mode: calcv1alpha1.ModeRational},
+		}
+
+		assert.True(t, service.IsInvalid(service.CalculatorService{}.ProcessCalculator(context.Background(), calc)),
+			expression)
+	}
+}
+
+func TestProcessCalculatorLimits(t *testing.T) {
+	t.Parallel()
+
+	calc := &calcv1alpha1.Calculator{
+		Spec: calcv1alpha1.CalculatorSpec{
+			X: 60,
+			Y: 40,
+		},
+	}
+
+	s := &service.CalculatorService{Limits: service.Limits{MaxDigits: 2}}
+
+	// Process calculator
+	err := s.ProcessCalculator(context.Background(), calc)
+	assert.True(t, service.IsLimitExceeded(err))
+
+	// Check nothing was recorded
+	assert.Equal(t, calcv1alpha1.CalculatorStatus{}, calc.Status)
+}
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+
+	precision := int32(101)
+
+	errs := service.ValidateSchema(&calcv1alpha1.CalculatorSpec{
+		Mode:      "Octal",
+		Variables: make([]calcv1alpha1.Variable, service.MaxVariables+1),
+		Output:    &calcv1alpha1.OutputSpec{Precision: &precision, ComplexFormat: "Exponential"},
+	})
+
+	var fields []string
+
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+
+	assert.Equal(t, []string{"spec.mode", "spec.variables", "spec.output.precision", "spec.output.complexFormat"},
+		fields)
+
+	precision = service.MaxPrecision
+	assert.Empty(t, service.ValidateSchema(&calcv1alpha1.CalculatorSpec{
+		Mode:   calcv1alpha1.ModeComplex,
+		Output: &calcv1alpha1.OutputSpec{Precision: &precision, ComplexFormat: calcv1alpha1.ComplexFormatPolar},
+	}))
+}
+
 func TestDefineSecret(t *testing.T) { //nolint:funlen // Test function
 	t.Parallel()
 
 	// Test table
 	tests := []struct {
 		name       string
-		result     int
+		value      string
 		secretName string
 		namespace  string
 		want       *corev1.Secret
 	}{
 		{
 			name:       "Define secret",
-			result:     2,
+			value:      "2",
 			secretName: "test-secret",
 			namespace:  "non-default",
 			want: &corev1.Secret{
@@ -155,7 +292,7 @@ func TestDefineSecret(t *testing.T) { //nolint:funlen // Test function
 			ctx := context.Background()
 
 			// Define secret
-			got, err := s.DefineSecret(ctx, tt.secretName, tt.namespace, tt.result)
+			got, err := s.DefineSecret(ctx, tt.secretName, tt.namespace, tt.value)
 			assert.NoError(t, err)
 
 			// Check result
@@ -163,3 +300,81 @@ func TestDefineSecret(t *testing.T) { //nolint:funlen // Test function
 		})
 	}
 }
+
+// typedTest is a calculation over typed variables, with its value or an InvalidError.
+type typedTest struct {
+	name       string
+	expression string
+	output     *calcv1alpha1.OutputSpec
+	want       string
+	wantErr    bool
+}
+
+// typedValidationTest is a validation of a calculation over typed variables, with the detail of its single error.
+type typedValidationTest struct {
+	name       string
+	expression string
+	wantErr    string
+}
+
+// typedCalculator returns a calculator of the expression over x = 2, y = 3 and a copy of the variables.
+func typedCalculator(expression string, variables []calcv1alpha1.Variable) *calcv1alpha1.Calculator {
+	return &calcv1alpha1.Calculator{
+		Spec: calcv1alpha1.CalculatorSpec{
+			X: 2, Y: 3, Expression: expression,
+			Variables: append([]calcv1alpha1.Variable(nil), variables...),
+		},
+	}
+}
+
+// testProcessTyped processes the calculations of the tests over the variables.
+func testProcessTyped(t *testing.T, variables []calcv1alpha1.Variable, tests []typedTest) {
+	t.Helper()
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calc := typedCalculator(tt.expression, variables)
+			calc.Spec.Output = tt.output
+
+			err := service.CalculatorService{}.ProcessCalculator(context.Background(), calc)
+			if tt.wantErr {
+				assert.True(t, service.IsInvalid(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, calc.Status.Value)
+		})
+	}
+}
+
+// testValidateTyped validates the calculations of the tests over the variables.
+func testValidateTyped(t *testing.T, variables []calcv1alpha1.Variable, tests []typedValidationTest) {
+	t.Helper()
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			errs := service.CalculatorService{}.ValidateCalculator(typedCalculator(tt.expression, variables))
+			if tt.wantErr == "" {
+				assert.Empty(t, errs)
+
+				return
+			}
+
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, tt.wantErr, errs[0].Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	// The time zones of the output are loaded without depending on the tzdata of the operator image.
+	_ "time/tzdata"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+)
+
+var errDurationRange = Invalidf("the duration is out of range, about 292 years at most")
+
+// addTime adds durations, or durations to a timestamp.
+func addTime(_ context.Context, args []Value) (Value, error) {
+	var (
+		timestamp *time.Time
+		sum       time.Duration
+		err       error
+	)
+
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case TimestampValue:
+			timestamp = &v.Time
+		case DurationValue:
+			if sum, err = addDurations(sum, v.Duration); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, Invalidf("unexpected %s argument", arg.Type())
+		}
+	}
+
+	if timestamp == nil {
+		return DurationValue{Duration: sum}, nil
+	}
+
+	return TimestampValue{Time: timestamp.Add(sum)}, nil
+}
+
+// subTime subtracts a duration from a duration or a timestamp, or two timestamps into the duration between them.
+func subTime(ctx context.Context, args []Value) (Value, error) {
+	switch y := args[1].(type) {
+	case DurationValue:
+		neg, err := negDuration(y.Duration)
+		if err != nil {
+			return nil, err
+		}
+
+		return addTime(ctx, []Value{args[0], DurationValue{Duration: neg}})
+	case TimestampValue:
+		x, ok := args[0].(TimestampValue)
+		if !ok {
+			return nil, Invalidf("expected %s, got %s", TypeTimestamp, args[0].Type())
+		}
+
+		// Sub saturates the durations out of range.
+		d := x.Time.Sub(y.Time)
+		if !y.Time.Add(d).Equal(x.Time) {
+			return nil, errDurationRange
+		}
+
+		return DurationValue{Duration: d}, nil
+	default:
+		return nil, Invalidf("unexpected %s argument", args[1].Type())
+	}
+}
+
+// mapDuration applies a unary operation to a duration.
+func mapDuration(op func(d time.Duration) (time.Duration, error)) EvalFunc {
+	return func(_ context.Context, args []Value) (Value, error) {
+		d, ok := args[0].(DurationValue)
+		if !ok {
+			return nil, Invalidf("expected %s, got %s", TypeDuration, args[0].Type())
+		}
+
+		result, err := op(d.Duration)
+		if err != nil {
+			return nil, err
+		}
+
+		return DurationValue{Duration: result}, nil
+	}
+}
+
+func addDurations(x, y time.Duration) (time.Duration, error) {
+	sum := x + y
+	if (y > 0 && sum < x) || (y < 0 && sum > x) {
+		return 0, errDurationRange
+	}
+
+	return sum, nil
+}
+
+func negDuration(d time.Duration) (time.Duration, error) {
+	if d == math.MinInt64 {
+		return 0, errDurationRange
+	}
+
+	return -d, nil
+}
+
+func absDuration(d time.Duration) (time.Duration, error) {
+	if d < 0 {
+		return negDuration(d)
+	}
+
+	return d, nil
+}
+
+// TimeZone returns the time zone rendering the timestamp results of the calculator, UTC by default.
+func TimeZone(calc *calcv1alpha1.Calculator) (*time.Location, error) {
+	if calc.Spec.Output == nil || calc.Spec.Output.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(calc.Spec.Output.TimeZone)
+	if err != nil {
+		return nil, Invalidf("unknown time zone %q, expected an IANA time zone such as Europe/Kyiv",
+			calc.Spec.Output.TimeZone)
+	}
+
+	return loc, nil
+}
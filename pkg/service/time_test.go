@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var timeVariables = []calcv1alpha1.Variable{
+	{Name: "expiry", Type: calcv1alpha1.VariableTypeTimestamp, Value: "2024-03-31T12:00:00Z"},
+	{Name: "now", Type: calcv1alpha1.VariableTypeTimestamp, Value: "2024-03-15T14:30:00+02:00"},
+	{Name: "renewal", Type: calcv1alpha1.VariableTypeDuration, Value: "720h"},
+	{Name: "first", Type: calcv1alpha1.VariableTypeDuration, Value: "1h30m"},
+	{Name: "second", Type: calcv1alpha1.VariableTypeDuration, Value: "45m"},
+	{Name: "long", Type: calcv1alpha1.VariableTypeDuration, Value: "2000000h"},
+}
+
+func TestParseTimestamp(t *testing.T) {
+	t.Parallel()
+
+	ts, err := service.ParseTimestamp("2024-03-15T14:30:00.5+02:00")
+	assert.NoError(t, err)
+	assert.Equal(t, service.TypeTimestamp, ts.Type())
+	assert.Equal(t, "2024-03-15T12:30:00.5Z", ts.String())
+
+	d, err := service.ParseDuration("1h30m")
+	assert.NoError(t, err)
+	assert.Equal(t, service.TypeDuration, d.Type())
+	assert.Equal(t, "1h30m0s", d.String())
+
+	for _, s := range []string{"2024-03-15", "2024-03-15 14:30:00Z", "yesterday"} {
+		_, err := service.ParseTimestamp(s)
+		assert.True(t, service.IsInvalid(err), s)
+	}
+
+	for _, s := range []string{"30d", "1.5", ""} {
+		_, err := service.ParseDuration(s)
+		assert.True(t, service.IsInvalid(err), s)
+	}
+}
+
+func TestProcessCalculatorTime(t *testing.T) {
+	t.Parallel()
+
+	kyiv := &calcv1alpha1.OutputSpec{TimeZone: "Europe/Kyiv"}
+
+	testProcessTyped(t, timeVariables, []typedTest{
+		{name: "Timestamp minus a duration", expression: "expiry - renewal", want: "2024-03-01T12:00:00Z"},
+		{name: "Duration plus a timestamp", expression: "first + now", want: "2024-03-15T14:00:00Z"},
+		{name: "Sum of durations", expression: "first + second + first", want: "3h45m0s"},
+		{name: "Duration between timestamps", expression: "expiry - now", want: "383h30m0s"},
+		{name: "Negative duration", expression: "now - expiry", want: "-383h30m0s"},
+		{name: "Absolute duration", expression: "abs(second - first)", want: "45m0s"},
+		{name: "Time zone", expression: "expiry - renewal", output: kyiv, want: "2024-03-01T14:00:00+02:00"},
+		{name: "Time zone of a duration", expression: "second", output: kyiv, want: "45m0s"},
+		{name: "Timestamps compared", expression: "expiry - renewal < now", want: "true"},
+		{name: "Durations compared", expression: "first + second >= expiry - now", want: "false"},
+		{name: "Equal timestamps of different offsets", expression: "now == now - second + second", want: "true"},
+		{name: "Numbers compared", expression: "x / 4.0 != y - 2.5", want: "false"},
+		{name: "Duration out of range", expression: "long + long + long + long + long", wantErr: true},
+	})
+}
+
+func TestValidateCalculatorTime(t *testing.T) {
+	t.Parallel()
+
+	testValidateTyped(t, timeVariables, []typedValidationTest{
+		{name: "Valid", expression: "expiry - renewal <= now + first"},
+		{name: "Sum of timestamps", expression: "expiry + now", wantErr: "add does not accept (timestamp, timestamp) at 7"},
+		{name: "Duration from a duration", expression: "renewal - now",
+			wantErr: "sub does not accept (duration, timestamp) at 8"},
+		{name: "Unitless duration", expression: "renewal + 1", wantErr: "add does not accept (duration, int) at 8"},
+		{name: "Mixed comparison", expression: "now > first",
+			wantErr: "gt does not accept (timestamp, duration) at 4"},
+		{name: "Chained comparison", expression: "x < y < 3", wantErr: "lt does not accept (bool, int) at 6"},
+		{name: "Assignment", expression: "x = y", wantErr: `unexpected '=' at 2`},
+	})
+}
+
+func TestValidateCalculatorTimeZone(t *testing.T) {
+	t.Parallel()
+
+	calc := typedCalculator("expiry", timeVariables)
+	calc.Spec.Output = &calcv1alpha1.OutputSpec{TimeZone: "Mars/Olympus"}
+
+	errs := service.CalculatorService{}.ValidateCalculator(calc)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "spec.output.timeZone", errs[0].Field)
+	}
+
+	err := service.CalculatorService{}.ProcessCalculator(context.Background(), calc)
+	assert.True(t, service.IsInvalid(err))
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Base dimensions of the physical units.
+const (
+	dimLength = iota
+	dimMass
+	dimTime
+	dimCurrent
+	dimTemperature
+	dimAmount
+	dimLuminosity
+	dimInformation
+	dimensions
+)
+
+// baseUnits are the units of the base dimensions, the results of products and quotients are written in them.
+var baseUnits = [dimensions]string{"m", "kg", "s", "A", "K", "mol", "cd", "bit"}
+
+// binaryBase is the base of the binary prefixes, Ki being 1024.
+const binaryBase = 1024
+
+// maxUnitExponent bounds the exponents of the factors of a unit, such as the 2 of m/s^2.
+const maxUnitExponent = 9
+
+// Dimension holds the exponents of the base dimensions of a unit, such as length 1 and time -1 for m/s.
+type Dimension [dimensions]int
+
+// Unit is a physical unit, a multiple of the base units of its dimension.
+type Unit struct {
+	// Name is the unit as written, such as GB/s.
+	Name string
+	// Scale is the value of the unit in the base units of its dimension, such as 8e9 bit/s for GB/s.
+	Scale *big.Rat
+	// Dim is the dimension of the unit.
+	Dim Dimension
+}
+
+// unitPrefixes are the prefixes a unit of the registry accepts.
+type unitPrefixes int
+
+const (
+	noPrefixes unitPrefixes = iota
+	siPrefixes
+	siAndBinaryPrefixes
+)
+
+type unitDef struct {
+	scale    *big.Rat
+	dim      Dimension
+	prefixes unitPrefixes
+}
+
+// rat returns the fraction of a constant of the registry.
+func rat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic(fmt.Sprintf("invalid fraction %q", s))
+	}
+
+	return r
+}
+
+func dim(exponents map[int]int) Dimension {
+	var d Dimension
+
+	for base, exponent := range exponents {
+		d[base] = exponent
+	}
+
+	return d
+}
+
+// unitRegistry holds the units of the measures by symbol, the prefixed units such as km or GiB are resolved from
+// them.
+var unitRegistry = map[string]unitDef{
+	"m":   {scale: rat("1"), dim: dim(map[int]int{dimLength: 1}), prefixes: siPrefixes},
+	"g":   {scale: rat("1/1000"), dim: dim(map[int]int{dimMass: 1}), prefixes: siPrefixes},
+	"s":   {scale: rat("1"), dim: dim(map[int]int{dimTime: 1}), prefixes: siPrefixes},
+	"min": {scale: rat("60"), dim: dim(map[int]int{dimTime: 1})},
+	"h":   {scale: rat("3600"), dim: dim(map[int]int{dimTime: 1})},
+	"d":   {scale: rat("86400"), dim: dim(map[int]int{dimTime: 1})},
+	"A":   {scale: rat("1"), dim: dim(map[int]int{dimCurrent: 1}), prefixes: siPrefixes},
+	"K":   {scale: rat("1"), dim: dim(map[int]int{dimTemperature: 1}), prefixes: siPrefixes},
+	"mol": {scale: rat("1"), dim: dim(map[int]int{dimAmount: 1}), prefixes: siPrefixes},
+	"cd":  {scale: rat("1"), dim: dim(map[int]int{dimLuminosity: 1}), prefixes: siPrefixes},
+	"bit": {scale: rat("1"), dim: dim(map[int]int{dimInformation: 1}), prefixes: siAndBinaryPrefixes},
+	"B":   {scale: rat("8"), dim: dim(map[int]int{dimInformation: 1}), prefixes: siAndBinaryPrefixes},
+	"Hz":  {scale: rat("1"), dim: dim(map[int]int{dimTime: -1}), prefixes: siPrefixes},
+	"N": {
+		scale: rat("1"), dim: dim(map[int]int{dimMass: 1, dimLength: 1, dimTime: -2}), prefixes: siPrefixes,
+	},
+	"Pa": {
+		scale: rat("1"), dim: dim(map[int]int{dimMass: 1, dimLength: -1, dimTime: -2}), prefixes: siPrefixes,
+	},
+	"J": {
+		scale: rat("1"), dim: dim(map[int]int{dimMass: 1, dimLength: 2, dimTime: -2}), prefixes: siPrefixes,
+	},
+	"Wh": {
+		scale: rat("3600"), dim: dim(map[int]int{dimMass: 1, dimLength: 2, dimTime: -2}), prefixes: siPrefixes,
+	},
+	"W": {
+		scale: rat("1"), dim: dim(map[int]int{dimMass: 1, dimLength: 2, dimTime: -3}), prefixes: siPrefixes,
+	},
+	"V": {
+		scale: rat("1"), dim: dim(map[int]int{dimMass: 1, dimLength: 2, dimTime: -3, dimCurrent: -1}),
+		prefixes: siPrefixes,
+	},
+	"L": {scale: rat("1/1000"), dim: dim(map[int]int{dimLength: 3}), prefixes: siPrefixes},
+}
+
+// siPrefixScales are the decimal prefixes, µ and u both being micro.
+var siPrefixScales = map[string]int{
+	"y": -24, "z": -21, "a": -18, "f": -15, "p": -12, "n": -9, "u": -6, "µ": -6, "m": -3, "c": -2, "d": -1,
+	"da": 1, "h": 2, "k": 3, "M": 6, "G": 9, "T": 12, "P": 15, "E": 18, "Z": 21, "Y": 24,
+}
+
+// binaryPrefixScales are the powers of 1024 of the binary prefixes.
+var binaryPrefixScales = map[string]int{"Ki": 1, "Mi": 2, "Gi": 3, "Ti": 4, "Pi": 5, "Ei": 6, "Zi": 7, "Yi": 8}
+
+// lookupUnit returns the unit of a symbol of the registry, prefixed or not. An unprefixed unit is preferred, so
+// that min is a minute and h an hour.
+func lookupUnit(symbol string) (Unit, bool) {
+	if def, ok := unitRegistry[symbol]; ok {
+		return Unit{Name: symbol, Scale: def.scale, Dim: def.dim}, true
+	}
+
+	for prefix, exponent := range binaryPrefixScales {
+		if def, ok := unitRegistry[strings.TrimPrefix(symbol, prefix)]; ok && strings.HasPrefix(symbol, prefix) &&
+			def.prefixes == siAndBinaryPrefixes {
+			scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(binaryBase), big.NewInt(int64(exponent)), nil))
+
+			return Unit{Name: symbol, Scale: scale.Mul(scale, def.scale), Dim: def.dim}, true
+		}
+	}
+
+	for prefix, exponent := range siPrefixScales {
+		if def, ok := unitRegistry[strings.TrimPrefix(symbol, prefix)]; ok && strings.HasPrefix(symbol, prefix) &&
+			def.prefixes != noPrefixes {
+			return Unit{Name: symbol, Scale: new(big.Rat).Mul(pow10(exponent), def.scale), Dim: def.dim}, true
+		}
+	}
+
+	return Unit{}, false
+}
+
+func pow10(exponent int) *big.Rat {
+	p := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exponent))), nil) //nolint:gomnd // Decimal base
+	if exponent < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), p)
+	}
+
+	return new(big.Rat).SetInt(p)
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
+
+// ParseUnit parses a unit made of the symbols of the registry with SI or binary prefixes, multiplied, divided and
+// raised to integer powers, such as GB/s, kW*h or m/s^2. The operators apply from left to right.
+func ParseUnit(s string) (Unit, error) {
+	name := strings.Join(strings.Fields(s), "")
+	unit := Unit{Name: name, Scale: rat("1")}
+	sign := 1
+
+	for rest := name; ; {
+		end := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsLetter(r) })
+		if end < 0 {
+			end = len(rest)
+		}
+
+		symbol := rest[:end]
+		if symbol == "" {
+			return Unit{}, Invalidf("invalid unit %q, expected a form such as GB/s or m/s^2", s)
+		}
+
+		rest = rest[end:]
+		exponent := 1
+
+		if strings.HasPrefix(rest, "^") {
+			end = 1
+			for end < len(rest) && (unicode.IsDigit(rune(rest[end])) || (end == 1 && rest[end] == '-')) {
+				end++
+			}
+
+			e, err := strconv.Atoi(rest[1:end])
+			if err != nil || abs(e) > maxUnitExponent {
+				return Unit{}, Invalidf("invalid exponent %q of unit %q, expected an integer between -%d and %d",
+					rest[1:end], s, maxUnitExponent, maxUnitExponent)
+			}
+
+			exponent, rest = e, rest[end:]
+		}
+
+		factor, ok := lookupUnit(symbol)
+		if !ok {
+			return Unit{}, Invalidf("unknown unit %q", symbol)
+		}
+
+		unit = unit.mul(factor, sign*exponent)
+
+		if rest == "" {
+			return unit, nil
+		}
+
+		switch rest[0] {
+		case '*':
+			sign = 1
+		case '/':
+			sign = -1
+		default:
+			return Unit{}, Invalidf("invalid unit %q, expected a form such as GB/s or m/s^2", s)
+		}
+
+		rest = rest[1:]
+	}
+}
+
+// mul returns the unit multiplied by the factor raised to the exponent, keeping its name.
+func (u Unit) mul(factor Unit, exponent int) Unit {
+	scale := new(big.Rat).Set(u.Scale)
+
+	for i := 0; i < abs(exponent); i++ {
+		if exponent > 0 {
+			scale.Mul(scale, factor.Scale)
+		} else {
+			scale.Quo(scale, factor.Scale)
+		}
+	}
+
+	return Unit{Name: u.Name, Scale: scale, Dim: u.Dim.combine(factor.Dim, exponent)}
+}
+
+// combine returns the dimension of the product of units of the dimensions, the second one raised to the exponent.
+func (d Dimension) combine(other Dimension, exponent int) Dimension {
+	for i := range d {
+		d[i] += other[i] * exponent
+	}
+
+	return d
+}
+
+// dimensionless reports whether the unit has no dimension, such as the quotient of two lengths.
+func (d Dimension) dimensionless() bool {
+	return d == Dimension{}
+}
+
+// baseUnit returns the unit of the dimension written in the base units, such as kg*m^2/s^2 for energies, or
+// s^-1 without a positive exponent.
+func baseUnit(d Dimension) Unit {
+	var numerator, denominator, negative []string
+
+	for i, exponent := range d {
+		switch {
+		case exponent == 1:
+			numerator = append(numerator, baseUnits[i])
+		case exponent > 1:
+			numerator = append(numerator, fmt.Sprintf("%s^%d", baseUnits[i], exponent))
+		case exponent == -1:
+			denominator = append(denominator, baseUnits[i])
+		case exponent < -1:
+			denominator = append(denominator, fmt.Sprintf("%s^%d", baseUnits[i], -exponent))
+		}
+
+		if exponent < 0 {
+			negative = append(negative, fmt.Sprintf("%s^%d", baseUnits[i], exponent))
+		}
+	}
+
+	name := strings.Join(numerator, "*")
+
+	switch {
+	case len(numerator) == 0:
+		name = strings.Join(negative, "*")
+	case len(denominator) > 0:
+		name += "/" + strings.Join(denominator, "/")
+	}
+
+	return Unit{Name: name, Scale: rat("1"), Dim: d}
+}
@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+var measureVariables = []calcv1alpha1.Variable{
+	{Name: "rate", Type: calcv1alpha1.VariableTypeMeasure, Value: "1.5 GB/s"},
+	{Name: "window", Type: calcv1alpha1.VariableTypeMeasure, Value: "2 h"},
+	{Name: "pause", Type: calcv1alpha1.VariableTypeMeasure, Value: "1500 ms"},
+	{Name: "disk", Type: calcv1alpha1.VariableTypeMeasure, Value: "2 TiB"},
+	{Name: "power", Type: calcv1alpha1.VariableTypeMeasure, Value: "400 W"},
+}
+
+func TestParseUnit(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		unit    string
+		want    string
+		wantErr bool
+	}{
+		{unit: "GB/s", want: "GB/s"},
+		{unit: "m / s^2", want: "m/s^2"},
+		{unit: "kW*h", want: "kW*h"},
+		{unit: "KiB", want: "KiB"},
+		{unit: "µs", want: "µs"},
+		{unit: "min", want: "min"},
+		{unit: "KB", wantErr: true},
+		{unit: "Kim", wantErr: true},
+		{unit: "furlong", wantErr: true},
+		{unit: "m^x", wantErr: true},
+		{unit: "m^10", wantErr: true},
+		{unit: "m//s", wantErr: true},
+		{unit: "", wantErr: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.unit, func(t *testing.T) {
+			t.Parallel()
+
+			unit, err := service.ParseUnit(tt.unit)
+			if tt.wantErr {
+				assert.True(t, service.IsInvalid(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, unit.Name)
+		})
+	}
+}
+
+func TestParseMeasure(t *testing.T) {
+	t.Parallel()
+
+	m, err := service.ParseMeasure(" 10 Mbit/s ")
+	assert.NoError(t, err)
+	assert.Equal(t, service.MeasureType("Mbit/s"), m.Type())
+	assert.Equal(t, "10 Mbit/s", m.String())
+
+	for _, s := range []string{"10", "10GB", "ten GB", "1e999 GB", "10 parsec"} {
+		_, err := service.ParseMeasure(s)
+		assert.True(t, service.IsInvalid(err), s)
+	}
+}
+
+func TestProcessCalculatorMeasures(t *testing.T) {
+	t.Parallel()
+
+	testProcessTyped(t, measureVariables, []typedTest{
+		{name: "Conversion", expression: "rate to Mbit/s", want: "12000 Mbit/s"},
+		{name: "Hours to seconds", expression: "window to s", want: "7200 s"},
+		{name: "Exact decimal conversion", expression: "pause to s", want: "1.5 s"},
+		{name: "Binary prefixes", expression: "disk to GiB", want: "2048 GiB"},
+		{name: "Sum in the first unit", expression: "window + pause", want: "2.0004166666666667 h"},
+		{name: "Conversion of a sum", expression: "pause + window to min", want: "120.025 min"},
+		{name: "Scaled", expression: "2 * rate / 3", want: "1 GB/s"},
+		{name: "Negation", expression: "abs(-window)", want: "2 h"},
+		{name: "Product in base units", expression: "rate * window", want: "8.64e+13 bit"},
+		{name: "Product converted", expression: "rate * window to TB", want: "10.8 TB"},
+		{name: "Energy", expression: "power * window to kWh", want: "0.8 kWh"},
+		{name: "Ratio without a unit", expression: "window / pause", want: "4800"},
+		{name: "Inverse", expression: "1 / pause to Hz", want: "0.6666666666666666 Hz"},
+		{name: "Transfer time", expression: "disk / rate to min", want: "24.433591728355555 min"},
+		{name: "Division by zero", expression: "rate / (window - window)", wantErr: true},
+	})
+}
+
+func TestValidateCalculatorUnits(t *testing.T) {
+	t.Parallel()
+
+	testValidateTyped(t, measureVariables, []typedValidationTest{
+		{name: "Valid", expression: "(disk / rate + window) to min"},
+		{name: "Incompatible sum", expression: "rate + window", wantErr: "add: incompatible units GB/s and h at 5"},
+		{name: "Incompatible conversion", expression: "rate to s", wantErr: "to: incompatible units GB/s and s at 5"},
+		{name: "Unknown unit", expression: "rate to Gbps", wantErr: `unknown unit "Gbps" at 8`},
+		{name: "Missing unit", expression: "rate to 5", wantErr: "expected a unit at 8"},
+		{name: "Unitless operand", expression: "window + 1", wantErr: "add does not accept (measure[h], int) at 7"},
+		{name: "Function of a measure", expression: "sqrt(window)", wantErr: "sqrt does not accept (measure[h]) at 0"},
+		{
+			name:       "Product dimensions",
+			expression: "power * window to s",
+			wantErr:    "to: incompatible units m^2*kg/s^2 and s at 15",
+		},
+	})
+}
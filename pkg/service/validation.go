@@ -0,0 +1,255 @@
+package service
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+)
+
+// DefaultExpression is the expression of a calculator without one.
+const DefaultExpression = "x + y"
+
+// Bounds of the Calculator schema, checked by ValidateSchema.
+const (
+	// MaxVariables is the maximum number of spec.variables.
+	MaxVariables = 32
+	// MaxPrecision is the maximum spec.output.precision.
+	MaxPrecision = 100
+)
+
+// defaultRegistry is used by services without a registry.
+var defaultRegistry = NewDefaultRegistry()
+
+// Expression returns the expression of the calculator.
+func Expression(calc *calcv1alpha1.Calculator) string {
+	if calc.Spec.Expression == "" {
+		return DefaultExpression
+	}
+
+	return calc.Spec.Expression
+}
+
+// modeTypes are the types of x, y and the number literals in the modes other than Integer.
+var modeTypes = map[calcv1alpha1.Mode]Type{
+	calcv1alpha1.ModeRational: TypeRational,
+	calcv1alpha1.ModeReal:     TypeReal,
+	calcv1alpha1.ModeComplex:  TypeComplex,
+}
+
+// reservedVariables are the names of the x and y variables.
+var reservedVariables = map[string]bool{"x": true, "y": true}
+
+// Variables returns the values of the variables of the calculator expression, x and y having the type of the mode.
+// It fails on the first invalid variable of the spec.
+func Variables(calc *calcv1alpha1.Calculator) (map[string]Value, error) {
+	variables := map[string]Value{
+		"x": NewInt(int64(calc.Spec.X)),
+		"y": NewInt(int64(calc.Spec.Y)),
+	}
+
+	if t, ok := modeTypes[calc.Spec.Mode]; ok {
+		for name, value := range variables {
+			promoted, err := promote(value, t)
+			if err != nil {
+				return nil, Invalidf("variable %s: %s", name, err)
+			}
+
+			variables[name] = promoted
+		}
+	}
+
+	for _, variable := range calc.Spec.Variables {
+		if reservedVariables[variable.Name] {
+			return nil, Invalidf("variable %s is reserved", variable.Name)
+		}
+
+		value, err := parseVariable(variable)
+		if err != nil {
+			return nil, Invalidf("variable %s: %s", variable.Name, err)
+		}
+
+		variables[variable.Name] = value
+	}
+
+	return variables, nil
+}
+
+// parseVariable parses the value of a variable of the spec.
+func parseVariable(variable calcv1alpha1.Variable) (Value, error) {
+	switch variable.Type {
+	case calcv1alpha1.VariableTypeComplex:
+		return ParseComplex(variable.Value)
+	case calcv1alpha1.VariableTypeVector:
+		return ParseVector(variable.Value)
+	case calcv1alpha1.VariableTypeMatrix:
+		return ParseMatrix(variable.Value)
+	case calcv1alpha1.VariableTypeQuantity:
+		return ParseQuantity(variable.Value, variable.Resource)
+	case calcv1alpha1.VariableTypeMeasure:
+		return ParseMeasure(variable.Value)
+	case calcv1alpha1.VariableTypeTimestamp:
+		return ParseTimestamp(variable.Value)
+	case calcv1alpha1.VariableTypeDuration:
+		return ParseDuration(variable.Value)
+	default:
+		return nil, Invalidf("unknown type %q", variable.Type)
+	}
+}
+
+// promoteLiterals promotes the number literals of the expression to the type, so that 1 / 3 is exact in Rational
+// mode. The literals of wider types are left as is. It fails on the first literal too large for the type.
+func promoteLiterals(node Node, t Type) error {
+	switch n := node.(type) {
+	case *Literal:
+		if !promotes(n.Value.Type(), t) {
+			return nil
+		}
+
+		value, err := promote(n.Value, t)
+		if err != nil {
+			return Invalidf("%s at %d", err, n.Offset)
+		}
+
+		n.Value = value
+	case *Call:
+		for _, arg := range n.Args {
+			if err := promoteLiterals(arg, t); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// variableTypes returns the types of the variables of the calculator expression.
+func variableTypes(variables map[string]Value) map[string]Type {
+	types := make(map[string]Type, len(variables))
+
+	for name, value := range variables {
+		types[name] = value.Type()
+	}
+
+	return types
+}
+
+// registry returns the registry of the service.
+func (c CalculatorService) registry() *Registry {
+	if c.Registry == nil {
+		return defaultRegistry
+	}
+
+	return c.Registry
+}
+
+// compile parses the calculator expression and checks it against the limits, the registry and the variables.
+func (c CalculatorService) compile(calc *calcv1alpha1.Calculator, variables map[string]Value) (Node, error) {
+	node, err := Parse(Expression(calc))
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := modeTypes[calc.Spec.Mode]; ok {
+		if err = promoteLiterals(node, t); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = c.Limits.CheckDepth(Depth(node)); err != nil {
+		return nil, err
+	}
+
+	if err = c.Limits.CheckOperands(Operands(node)); err != nil {
+		return nil, err
+	}
+
+	if _, err = Check(node, c.registry(), variableTypes(variables)); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// ValidateSchema checks the fields of the spec which the schema of the Calculator CRD restricts, for the APIs
+// evaluating specs that are not stored as Calculators: the mode, the number of variables and the formats of the
+// output.
+func ValidateSchema(spec *calcv1alpha1.CalculatorSpec) field.ErrorList {
+	var errs field.ErrorList
+
+	path := field.NewPath("spec")
+
+	if _, ok := modeTypes[spec.Mode]; !ok && spec.Mode != "" && spec.Mode != calcv1alpha1.ModeInteger {
+		errs = append(errs, field.NotSupported(path.Child("mode"), spec.Mode, []string{
+			string(calcv1alpha1.ModeInteger), string(calcv1alpha1.ModeRational), string(calcv1alpha1.ModeReal),
+			string(calcv1alpha1.ModeComplex),
+		}))
+	}
+
+	if len(spec.Variables) > MaxVariables {
+		errs = append(errs, field.TooMany(path.Child("variables"), len(spec.Variables), MaxVariables))
+	}
+
+	if output := spec.Output; output != nil {
+		if precision := output.Precision; precision != nil && (*precision < 0 || *precision > MaxPrecision) {
+			errs = append(errs, field.Invalid(path.Child("output", "precision"), *precision,
+				fmt.Sprintf("must be between 0 and %d", MaxPrecision)))
+		}
+
+		switch output.ComplexFormat {
+		case "", calcv1alpha1.ComplexFormatRectangular, calcv1alpha1.ComplexFormatPolar:
+		default:
+			errs = append(errs, field.NotSupported(path.Child("output", "complexFormat"), output.ComplexFormat,
+				[]string{string(calcv1alpha1.ComplexFormatRectangular), string(calcv1alpha1.ComplexFormatPolar)}))
+		}
+	}
+
+	return errs
+}
+
+// ValidateCalculator validates the calculator spec without evaluating it. The admission webhook and
+// the command line tools share it.
+func (c CalculatorService) ValidateCalculator(calc *calcv1alpha1.Calculator) field.ErrorList {
+	var errs field.ErrorList
+
+	for i, variable := range calc.Spec.Variables {
+		path := field.NewPath("spec", "variables").Index(i)
+
+		if reservedVariables[variable.Name] {
+			errs = append(errs, field.Invalid(path.Child("name"), variable.Name, "x and y are reserved"))
+		}
+
+		if variable.Type == calcv1alpha1.VariableTypeQuantity {
+			if err := ValidateResource(variable.Resource); err != nil {
+				errs = append(errs, field.Invalid(path.Child("resource"), variable.Resource, err.Error()))
+
+				continue
+			}
+		}
+
+		if _, err := parseVariable(variable); err != nil {
+			errs = append(errs, field.Invalid(path.Child("value"), variable.Value, err.Error()))
+		}
+	}
+
+	if _, err := TimeZone(calc); err != nil {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "output", "timeZone"), calc.Spec.Output.TimeZone,
+			err.Error()))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	variables, err := Variables(calc)
+	if err == nil {
+		_, err = c.compile(calc, variables)
+	}
+
+	if err != nil {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "expression"), Expression(calc), err.Error()))
+	}
+
+	return errs
+}
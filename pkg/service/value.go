@@ -0,0 +1,534 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Type is the type of a Value.
+type Type string
+
+const (
+	// TypeInt is an arbitrary precision integer.
+	TypeInt Type = "int"
+	// TypeRational is an exact fraction of arbitrary precision integers.
+	TypeRational Type = "rational"
+	// TypeReal is a double precision floating point number.
+	TypeReal Type = "real"
+	// TypeComplex is a complex number of double precision floating point parts.
+	TypeComplex Type = "complex"
+	// TypeVector is the kind of the vectors of real numbers, the type of a vector holds its length as in vector[3].
+	TypeVector Type = "vector"
+	// TypeMatrix is the kind of the matrices of real numbers, the type of a matrix holds its rows and columns as in
+	// matrix[2x3].
+	TypeMatrix Type = "matrix"
+	// TypeQuantity is the kind of the Kubernetes resource quantities, the type of a quantity holds the resource it
+	// measures as in quantity[cpu].
+	TypeQuantity Type = "quantity"
+	// TypeMeasure is the kind of the numbers of a physical unit, the type of a measure holds its unit as in
+	// measure[GB/s].
+	TypeMeasure Type = "measure"
+	// TypeTimestamp is a point in time with a nanosecond precision.
+	TypeTimestamp Type = "timestamp"
+	// TypeDuration is a signed duration with a nanosecond precision, of about 292 years at most.
+	TypeDuration Type = "duration"
+	// TypeBool is the result of a comparison.
+	TypeBool Type = "bool"
+)
+
+// VectorType returns the type of the vectors of length n.
+func VectorType(n int) Type {
+	return Type(fmt.Sprintf("%s[%d]", TypeVector, n))
+}
+
+// MatrixType returns the type of the matrices of the rows and columns.
+func MatrixType(rows, cols int) Type {
+	return Type(fmt.Sprintf("%s[%dx%d]", TypeMatrix, rows, cols))
+}
+
+// QuantityType returns the type of the quantities of the resource.
+func QuantityType(res string) Type {
+	return Type(fmt.Sprintf("%s[%s]", TypeQuantity, res))
+}
+
+// MeasureType returns the type of the measures of the unit.
+func MeasureType(unit string) Type {
+	return Type(fmt.Sprintf("%s[%s]", TypeMeasure, unit))
+}
+
+// Kind returns the type without its dimensions, such as vector for vector[3].
+func (t Type) Kind() Type {
+	if i := strings.IndexByte(string(t), '['); i >= 0 {
+		return t[:i]
+	}
+
+	return t
+}
+
+// Dims returns the dimensions of the type, such as [2 3] for matrix[2x3], nil for the types without dimensions.
+func (t Type) Dims() []int {
+	start, end := strings.IndexByte(string(t), '['), strings.IndexByte(string(t), ']')
+	if start < 0 || end < start {
+		return nil
+	}
+
+	var dims []int
+
+	for _, dim := range strings.Split(string(t[start+1:end]), "x") {
+		n, err := strconv.Atoi(dim)
+		if err != nil {
+			return nil
+		}
+
+		dims = append(dims, n)
+	}
+
+	return dims
+}
+
+// Value is an operand or a result of a calculation.
+type Value interface {
+	// Type returns the type of the value.
+	Type() Type
+	// String returns the canonical form of the value, as written to the result secret.
+	String() string
+}
+
+// IntValue is a TypeInt value.
+type IntValue struct {
+	Int *big.Int
+}
+
+// NewInt returns the IntValue of i.
+func NewInt(i int64) IntValue {
+	return IntValue{Int: big.NewInt(i)}
+}
+
+// Type returns TypeInt.
+func (v IntValue) Type() Type {
+	return TypeInt
+}
+
+func (v IntValue) String() string {
+	return v.Int.String()
+}
+
+// RatValue is a TypeRational value.
+type RatValue struct {
+	Rat *big.Rat
+}
+
+// NewRat returns the RatValue of a/b, b must not be zero.
+func NewRat(a, b int64) RatValue {
+	return RatValue{Rat: big.NewRat(a, b)}
+}
+
+// Type returns TypeRational.
+func (v RatValue) Type() Type {
+	return TypeRational
+}
+
+// String returns the fraction reduced to its lowest terms as numerator/denominator, the denominator is positive
+// and 1 for integers.
+func (v RatValue) String() string {
+	return v.Rat.String()
+}
+
+// Decimal returns the decimal form of the fraction with the given number of fractional digits, the last digit
+// rounded to nearest with halves away from zero.
+func (v RatValue) Decimal(precision int) string {
+	return v.Rat.FloatString(precision)
+}
+
+// RealValue is a TypeReal value, always finite.
+type RealValue struct {
+	Float float64
+}
+
+// Type returns TypeReal.
+func (v RealValue) Type() Type {
+	return TypeReal
+}
+
+// String returns the shortest decimal form reading back as the same number.
+func (v RealValue) String() string {
+	return strconv.FormatFloat(v.Float, 'g', -1, 64)
+}
+
+// ComplexValue is a TypeComplex value, its parts always finite.
+type ComplexValue struct {
+	Complex complex128
+}
+
+// ParseComplex parses the rectangular form of a complex number, such as 3+4i, -4i or 2.5.
+func ParseComplex(s string) (ComplexValue, error) {
+	c, err := strconv.ParseComplex(strings.TrimSpace(s), 128) //nolint:gomnd // complex128
+	if err != nil || cmplx.IsInf(c) || cmplx.IsNaN(c) {
+		return ComplexValue{}, Invalidf("invalid complex number %q, expected a form such as 3+4i", s)
+	}
+
+	return ComplexValue{Complex: c}, nil
+}
+
+// Type returns TypeComplex.
+func (v ComplexValue) Type() Type {
+	return TypeComplex
+}
+
+// String returns the rectangular form of the number, such as 3+4i.
+func (v ComplexValue) String() string {
+	return strings.Trim(strconv.FormatComplex(v.Complex, 'g', -1, 128), "()") //nolint:gomnd // complex128
+}
+
+// Polar returns the polar form of the number, its modulus and its argument in radians, such as 5∠0.9273.
+func (v ComplexValue) Polar() string {
+	return RealValue{Float: cmplx.Abs(v.Complex)}.String() + "∠" + RealValue{Float: cmplx.Phase(v.Complex)}.String()
+}
+
+// MaxDimension is the maximum length of a vector, and of the rows and columns of a matrix.
+const MaxDimension = 64
+
+// VectorValue is a vector of real numbers, its type holds its length.
+type VectorValue struct {
+	Elems []float64
+}
+
+// ParseVector parses a vector written as a JSON array of numbers, such as [1, 2.5, -3].
+func ParseVector(s string) (VectorValue, error) {
+	var elems []float64
+
+	if err := json.Unmarshal([]byte(s), &elems); err != nil {
+		return VectorValue{}, Invalidf("invalid vector %q, expected a JSON array of numbers such as [1, 2, 3]", s)
+	}
+
+	if len(elems) == 0 || len(elems) > MaxDimension {
+		return VectorValue{}, Invalidf("a vector has between 1 and %d elements, got %d", MaxDimension, len(elems))
+	}
+
+	return VectorValue{Elems: elems}, nil
+}
+
+// Type returns the vector type of the length of the vector.
+func (v VectorValue) Type() Type {
+	return VectorType(len(v.Elems))
+}
+
+// String returns the JSON array of the vector.
+func (v VectorValue) String() string {
+	return marshal(v.Elems)
+}
+
+// MatrixValue is a matrix of real numbers, its type holds its rows and columns.
+type MatrixValue struct {
+	Rows [][]float64
+}
+
+// ParseMatrix parses a matrix written as a JSON array of rows, such as [[1, 2], [3, 4]].
+func ParseMatrix(s string) (MatrixValue, error) {
+	var rows [][]float64
+
+	if err := json.Unmarshal([]byte(s), &rows); err != nil {
+		return MatrixValue{}, Invalidf("invalid matrix %q, expected a JSON array of rows such as [[1, 2], [3, 4]]", s)
+	}
+
+	if len(rows) == 0 || len(rows) > MaxDimension {
+		return MatrixValue{}, Invalidf("a matrix has between 1 and %d rows, got %d", MaxDimension, len(rows))
+	}
+
+	for i, row := range rows {
+		if len(row) == 0 || len(row) > MaxDimension {
+			return MatrixValue{}, Invalidf("a matrix has between 1 and %d columns, got %d", MaxDimension, len(row))
+		}
+
+		if len(row) != len(rows[0]) {
+			return MatrixValue{}, Invalidf("row %d has %d columns, row 0 has %d", i, len(row), len(rows[0]))
+		}
+	}
+
+	return MatrixValue{Rows: rows}, nil
+}
+
+// Type returns the matrix type of the rows and columns of the matrix.
+func (v MatrixValue) Type() Type {
+	return MatrixType(len(v.Rows), len(v.Rows[0]))
+}
+
+// String returns the JSON array of the rows of the matrix.
+func (v MatrixValue) String() string {
+	return marshal(v.Rows)
+}
+
+// QuantityValue is a Kubernetes resource quantity, its type holds the resource it measures so that quantities
+// of different resources are not mixed.
+type QuantityValue struct {
+	Quantity resource.Quantity
+	Resource string
+}
+
+// ParseQuantity parses a quantity of the resource in the Kubernetes syntax, such as 500m or 512Mi. The resource
+// is a qualified name such as cpu, memory or nvidia.com/gpu.
+func ParseQuantity(s, res string) (QuantityValue, error) {
+	if err := ValidateResource(res); err != nil {
+		return QuantityValue{}, err
+	}
+
+	q, err := resource.ParseQuantity(strings.TrimSpace(s))
+	if err != nil {
+		return QuantityValue{}, Invalidf("invalid quantity %q, expected a form such as 500m or 512Mi", s)
+	}
+
+	return QuantityValue{Quantity: q, Resource: res}, nil
+}
+
+// ValidateResource checks the name of the resource of a quantity.
+func ValidateResource(res string) error {
+	if res == "" {
+		return Invalidf("a quantity needs the resource it measures, such as cpu or memory")
+	}
+
+	if errs := validation.IsQualifiedName(res); len(errs) > 0 {
+		return Invalidf("invalid resource %q: %s", res, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// Type returns the quantity type of the resource.
+func (v QuantityValue) Type() Type {
+	return QuantityType(v.Resource)
+}
+
+// String returns the canonical form of the quantity, as written in the resources of a pod spec.
+func (v QuantityValue) String() string {
+	q := v.Quantity
+
+	return q.String()
+}
+
+// MeasureValue is a real number of a physical unit, its type holds the unit, always finite.
+type MeasureValue struct {
+	Value float64
+	Unit  Unit
+}
+
+// ParseMeasure parses a number and its unit separated by a space, such as 10 GB/s or 1.5 h.
+func ParseMeasure(s string) (MeasureValue, error) {
+	number, unit, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return MeasureValue{}, Invalidf("invalid measure %q, expected a number and a unit such as 10 GB/s", s)
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return MeasureValue{}, Invalidf("invalid number %q of measure %q", number, s)
+	}
+
+	u, err := ParseUnit(unit)
+	if err != nil {
+		return MeasureValue{}, err
+	}
+
+	return MeasureValue{Value: f, Unit: u}, nil
+}
+
+// Type returns the measure type of the unit.
+func (v MeasureValue) Type() Type {
+	return MeasureType(v.Unit.Name)
+}
+
+// String returns the number and the unit, such as 8000 Mbit/s.
+func (v MeasureValue) String() string {
+	return RealValue{Float: v.Value}.String() + " " + v.Unit.Name
+}
+
+// TimestampValue is a TypeTimestamp value.
+type TimestampValue struct {
+	Time time.Time
+}
+
+// ParseTimestamp parses a timestamp in RFC 3339 format, such as 2024-03-01T12:00:00Z or 2024-03-01T14:00:00+02:00.
+func ParseTimestamp(s string) (TimestampValue, error) {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s))
+	if err != nil {
+		return TimestampValue{}, Invalidf("invalid timestamp %q, expected RFC 3339 such as 2024-03-01T12:00:00Z", s)
+	}
+
+	return TimestampValue{Time: t}, nil
+}
+
+// Type returns TypeTimestamp.
+func (v TimestampValue) Type() Type {
+	return TypeTimestamp
+}
+
+// String returns the timestamp in RFC 3339 format in UTC.
+func (v TimestampValue) String() string {
+	return v.In(time.UTC)
+}
+
+// In returns the timestamp in RFC 3339 format in the time zone.
+func (v TimestampValue) In(loc *time.Location) string {
+	return v.Time.In(loc).Format(time.RFC3339Nano)
+}
+
+// DurationValue is a TypeDuration value.
+type DurationValue struct {
+	Duration time.Duration
+}
+
+// ParseDuration parses a duration in Go syntax, such as 720h, 1h30m or -1.5s.
+func ParseDuration(s string) (DurationValue, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return DurationValue{}, Invalidf("invalid duration %q, expected a form such as 720h or 1h30m", s)
+	}
+
+	return DurationValue{Duration: d}, nil
+}
+
+// Type returns TypeDuration.
+func (v DurationValue) Type() Type {
+	return TypeDuration
+}
+
+// String returns the duration in Go syntax, such as 720h0m0s.
+func (v DurationValue) String() string {
+	return v.Duration.String()
+}
+
+// BoolValue is a TypeBool value.
+type BoolValue struct {
+	Bool bool
+}
+
+// Type returns TypeBool.
+func (v BoolValue) Type() Type {
+	return TypeBool
+}
+
+// String returns true or false.
+func (v BoolValue) String() string {
+	return strconv.FormatBool(v.Bool)
+}
+
+// marshal returns the JSON encoding of finite numbers, which cannot fail.
+func marshal(v interface{}) string {
+	data, _ := json.Marshal(v)
+
+	return string(data)
+}
+
+// promotions are the implicit conversions of the arguments of an operation without a signature accepting
+// their types, from the narrowest to the widest.
+var promotions = map[Type][]Type{
+	TypeInt:      {TypeRational, TypeReal, TypeComplex},
+	TypeRational: {TypeReal, TypeComplex},
+	TypeReal:     {TypeComplex},
+}
+
+// promotes reports whether a value of type from is implicitly converted to type to.
+func promotes(from, to Type) bool {
+	for _, t := range promotions[from] {
+		if t == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// promote converts the value to the type, which is its own type or one it promotes to. The conversions to
+// floating point numbers round to nearest, and fail on the numbers too large for them.
+func promote(v Value, to Type) (Value, error) {
+	if v.Type() == to {
+		return v, nil
+	}
+
+	switch v := v.(type) {
+	case IntValue:
+		if to == TypeRational {
+			return RatValue{Rat: new(big.Rat).SetInt(v.Int)}, nil
+		}
+
+		f, _ := new(big.Float).SetInt(v.Int).Float64()
+
+		return promoteReal(f, v.Type(), to)
+	case RatValue:
+		f, _ := v.Rat.Float64()
+
+		return promoteReal(f, v.Type(), to)
+	case RealValue:
+		if to == TypeComplex {
+			return newComplex(complex(v.Float, 0))
+		}
+	}
+
+	return v, nil
+}
+
+// promoteReal promotes the floating point conversion of a number of the type from.
+func promoteReal(f float64, from, to Type) (Value, error) {
+	value, err := newReal(f)
+	if err != nil {
+		return nil, Invalidf("the %s is too large for the %s type", from, to)
+	}
+
+	return promote(value, to)
+}
+
+// ErrInvalid is matched by every InvalidError.
+var ErrInvalid = errors.New("invalid calculation")
+
+// InvalidError reports a calculation that cannot be evaluated because of its spec: a syntax error,
+// a type error or a domain error such as a division by zero. Retrying it cannot succeed.
+type InvalidError struct {
+	// Message describes the problem.
+	Message string
+}
+
+// Invalidf returns an InvalidError with a formatted message.
+func Invalidf(format string, args ...interface{}) *InvalidError {
+	return &InvalidError{Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *InvalidError) Error() string {
+	return e.Message
+}
+
+// Is makes errors.Is match ErrInvalid.
+func (e *InvalidError) Is(target error) bool {
+	return target == ErrInvalid //nolint:errorlint // Sentinel comparison
+}
+
+// IsInvalid reports whether the error is caused by an invalid calculation.
+func IsInvalid(err error) bool {
+	return errors.Is(err, ErrInvalid)
+}
+
+func asRat(v Value) (RatValue, error) {
+	r, ok := v.(RatValue)
+	if !ok {
+		return RatValue{}, Invalidf("expected %s, got %s", TypeRational, v.Type())
+	}
+
+	return r, nil
+}
+
+func asInt(v Value) (IntValue, error) {
+	i, ok := v.(IntValue)
+	if !ok {
+		return IntValue{}, Invalidf("expected %s, got %s", TypeInt, v.Type())
+	}
+
+	return i, nil
+}
@@ -0,0 +1,144 @@
+// Package sharding splits the Calculators between the replicas of the operator.
+//
+// Every replica runs a shard: it holds the lease of its shard ID and owns the Calculators whose namespace/name
+// hashes to its ID modulo the shard count. A replica writes the results of a Calculator only once it has claimed
+// it with the ClaimAnnotation, an optimistic update of the Calculator.
+//
+// When the shard count changes, the ownership of a Calculator moves to another shard while the replicas are rolled
+// out, and replicas with the old and the new count run side by side. An owner only claims an unclaimed Calculator,
+// or one claimed by a shard whose lease expired: a replica releases the Calculators it no longer owns once it runs
+// with the new count, and the lease of a removed shard expires once its replica is gone. A single replica holds the
+// lease of a shard ID, so no two replicas write the secret of a Calculator at the same time.
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// ClaimAnnotation holds the ID of the shard writing the results of a Calculator.
+const ClaimAnnotation = "calc.example.com/shard"
+
+// Of returns the shard owning the object of the namespace and name among count shards, from the FNV-1a hash of
+// namespace/name. The hash is stable across replicas and versions of the operator.
+func Of(namespace, name string, count int) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(namespace + "/" + name))
+
+	return int(hash.Sum32() % uint32(count))
+}
+
+// Claim returns the shard claiming the object, false when unclaimed.
+func Claim(obj client.Object) (int, bool) {
+	value, ok := obj.GetAnnotations()[ClaimAnnotation]
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		// A malformed claim is claimed by no shard.
+		return 0, false
+	}
+
+	return id, true
+}
+
+// SetClaim claims the object for the shard, or releases it when id is negative.
+func SetClaim(obj client.Object, id int) {
+	annotations := obj.GetAnnotations()
+
+	if id < 0 {
+		delete(annotations, ClaimAnnotation)
+		obj.SetAnnotations(annotations)
+
+		return
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[ClaimAnnotation] = strconv.Itoa(id)
+	obj.SetAnnotations(annotations)
+}
+
+// Shard is the shard run by a replica. A nil Shard owns every object.
+type Shard struct {
+	// ID is the shard of the replica, between 0 and Count - 1.
+	ID int
+	// Count is the number of shards.
+	Count int
+	// LeaseNamespace and LeasePrefix locate the leases of the shards, named LeasePrefix-shard-ID.
+	LeaseNamespace string
+	LeasePrefix    string
+	// Reader reads the leases of the other shards. It should not be cached, the leases are renewed every few
+	// seconds.
+	Reader client.Reader
+}
+
+// LeaseName returns the name of the lease held by the replica running the shard.
+func (s *Shard) LeaseName(id int) string {
+	return fmt.Sprintf("%s-shard-%d", s.LeasePrefix, id)
+}
+
+// Owns reports whether the shard owns the object.
+func (s *Shard) Owns(obj client.Object) bool {
+	return s == nil || Of(obj.GetNamespace(), obj.GetName(), s.Count) == s.ID
+}
+
+// Claimed reports whether the object is claimed by the shard.
+func (s *Shard) Claimed(obj client.Object) bool {
+	id, ok := Claim(obj)
+
+	return s != nil && ok && id == s.ID
+}
+
+// Predicate filters the events of the objects the shard owns or has to release.
+func (s *Shard) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s.Owns(obj) || s.Claimed(obj)
+	})
+}
+
+// LeaseExpired reports whether the replica running the shard lost its lease, so its claims can be taken over.
+// Only a lease that existed expires: a missing lease is not taken as expired, as the leases of the replicas
+// would all be missing if they were not where the shard reads them.
+func (s *Shard) LeaseExpired(ctx context.Context, id int, now time.Time) (bool, error) {
+	lease := &coordinationv1.Lease{}
+
+	err := s.Reader.Get(ctx, types.NamespacedName{Namespace: s.LeaseNamespace, Name: s.LeaseName(id)}, lease)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to get the lease of shard %d: %w", id, err)
+	}
+
+	return Expired(lease, now), nil
+}
+
+// Expired reports whether the lease was not renewed within its duration.
+func Expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return true
+	}
+
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+
+	duration := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+
+	return now.After(lease.Spec.RenewTime.Add(duration))
+}
@@ -0,0 +1,140 @@
+package sharding_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/mykysha/kubCalculator/pkg/sharding"
+)
+
+func object(namespace, name string, annotations map[string]string) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Annotations: annotations}}
+}
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+
+	// The hash must not change between versions, the replicas would disagree on the ownership.
+	assert.Equal(t, 1, sharding.Of("default", "sum", 3))
+	assert.Equal(t, 0, sharding.Of("default", "sum", 1))
+
+	counts := make([]int, 4)
+	for i := 0; i < 1000; i++ {
+		counts[sharding.Of("default", fmt.Sprint("calc-", i), len(counts))]++
+	}
+
+	for shard, count := range counts {
+		assert.InDelta(t, 250, count, 50, "shard %d", shard)
+	}
+}
+
+func TestClaim(t *testing.T) {
+	t.Parallel()
+
+	obj := object("default", "sum", nil)
+
+	_, ok := sharding.Claim(obj)
+	assert.False(t, ok)
+
+	sharding.SetClaim(obj, 2)
+	id, ok := sharding.Claim(obj)
+	assert.True(t, ok)
+	assert.Equal(t, 2, id)
+
+	sharding.SetClaim(obj, -1)
+	_, ok = sharding.Claim(obj)
+	assert.False(t, ok)
+
+	_, ok = sharding.Claim(object("default", "sum", map[string]string{sharding.ClaimAnnotation: "two"}))
+	assert.False(t, ok)
+}
+
+func TestShardPredicate(t *testing.T) {
+	t.Parallel()
+
+	shard := &sharding.Shard{ID: 1, Count: 3}
+	predicate := shard.Predicate()
+
+	// Test table
+	tests := []struct {
+		name string
+		obj  *corev1.Secret
+		want bool
+	}{
+		{name: "Owned", obj: object("default", "sum", nil), want: true},
+		{name: "Owned by another shard", obj: object("default", "mul", nil)},
+		{
+			name: "Claimed before a resharding",
+			obj:  object("default", "mul", map[string]string{sharding.ClaimAnnotation: "1"}),
+			want: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, predicate.Create(event.CreateEvent{Object: tt.obj}))
+			assert.Equal(t, tt.want, predicate.Update(event.UpdateEvent{ObjectOld: tt.obj, ObjectNew: tt.obj}))
+		})
+	}
+
+	var unsharded *sharding.Shard
+	assert.True(t, unsharded.Owns(object("default", "mul", nil)))
+}
+
+func TestLeaseExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	lease := func(name string, renewed time.Time) *coordinationv1.Lease {
+		holder, duration := "replica", int32(15)
+
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "calc-system", Name: name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &duration,
+				RenewTime:            &metav1.MicroTime{Time: renewed},
+			},
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	shard := &sharding.Shard{
+		ID: 0, Count: 3, LeaseNamespace: "calc-system", LeasePrefix: "calc",
+		Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			lease("calc-shard-1", now.Add(-5*time.Second)),
+			lease("calc-shard-2", now.Add(-time.Minute)),
+		).Build(),
+	}
+
+	// Shard 3 never had a lease.
+	for id, want := range map[int]bool{1: false, 2: true, 3: false} {
+		expired, err := shard.LeaseExpired(context.Background(), id, now)
+		require.NoError(t, err)
+		assert.Equal(t, want, expired, "shard %d", id)
+	}
+
+	released := lease("calc-shard-1", now)
+	released.Spec.HolderIdentity = nil
+	assert.True(t, sharding.Expired(released, now))
+}
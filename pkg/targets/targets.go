@@ -0,0 +1,275 @@
+// Package targets applies the results of the Calculators to fields of other objects of their namespace, with
+// server-side apply under a field manager of their own.
+package targets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+)
+
+const (
+	// DefaultTemplate renders the canonical form of the result.
+	DefaultTemplate = "{{ .Value }}"
+	// FieldManagerPrefix prefixes the field managers of the Calculators.
+	FieldManagerPrefix = "calculator-"
+
+	// maxFieldManagerLength is the maximum length of a field manager accepted by the API server.
+	maxFieldManagerLength = 128
+	hashLength            = 8
+)
+
+// errEncrypted is returned for the targets of a Calculator with encryption, its result is kept out of the
+// other objects.
+var errEncrypted = errors.New("the result of a Calculator with spec.output.encryption is not written to targets")
+
+// Data is the data of the templates.
+type Data struct {
+	// Value is the canonical form of the result.
+	Value string
+	// Result is the result, when it fits into an integer.
+	Result int
+	X      int
+	Y      int
+	// Name is the name of the Calculator.
+	Name string
+}
+
+// Allowlist lists the kinds Calculators may apply their results to.
+type Allowlist []schema.GroupKind
+
+// Allows reports whether the kind is allowed.
+func (a Allowlist) Allows(groupKind schema.GroupKind) bool {
+	for _, allowed := range a {
+		if allowed == groupKind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FieldManager returns the field manager of the Calculator, distinct for every Calculator of a namespace.
+func FieldManager(calc *calcv1alpha1.Calculator) string {
+	manager := FieldManagerPrefix + calc.Name
+	if len(manager) <= maxFieldManagerLength {
+		return manager
+	}
+
+	sum := sha256.Sum256([]byte(calc.Name))
+
+	return manager[:maxFieldManagerLength-hashLength-1] + "-" + hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// ParsePath parses a JSONPath made of field names, such as .spec.replicas or .data['result.txt'], into the
+// field names. The surrounding braces and the leading $ are optional.
+func ParsePath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") && strings.HasSuffix(path, "}") {
+		path = path[1 : len(path)-1]
+	}
+
+	path = strings.TrimPrefix(path, "$")
+
+	var fields []string
+
+	for path != "" {
+		var name string
+
+		switch path[0] {
+		case '.':
+			end := strings.IndexAny(path[1:], ".[")
+			if end < 0 {
+				end = len(path) - 1
+			}
+
+			name, path = path[1:end+1], path[end+1:]
+		case '[':
+			if len(path) < 2 || (path[1] != '\'' && path[1] != '"') {
+				return nil, fmt.Errorf("only quoted field names are supported in brackets, not %q", path)
+			}
+
+			end := strings.Index(path[2:], string(path[1])+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated bracket in %q", path)
+			}
+
+			name, path = path[2:end+2], path[end+4:]
+		default:
+			return nil, fmt.Errorf("unexpected %q, expected . or [", path)
+		}
+
+		if name == "" || strings.ContainsAny(name, "*") {
+			return nil, fmt.Errorf("invalid field name %q", name)
+		}
+
+		fields = append(fields, name)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	return fields, checkFields(fields)
+}
+
+// checkFields rejects the fields the API server or the operator own.
+func checkFields(fields []string) error {
+	switch fields[0] {
+	case "apiVersion", "kind", "status":
+		return fmt.Errorf("field %s cannot be written", fields[0])
+	case "metadata":
+		if len(fields) != 3 || (fields[1] != "labels" && fields[1] != "annotations") {
+			return fmt.Errorf("only metadata labels and annotations can be written")
+		}
+	}
+
+	return nil
+}
+
+// Render renders the value of the target for the Calculator, and its JSON value of the target type.
+func Render(target calcv1alpha1.Target, calc *calcv1alpha1.Calculator) (string, interface{}, error) {
+	if encrypted(calc) {
+		return "", nil, errEncrypted
+	}
+
+	tmpl, err := parseTemplate(target)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var rendered bytes.Buffer
+
+	if err = tmpl.Execute(&rendered, Data{
+		Value:  calc.Status.Value,
+		Result: calc.Status.Result,
+		X:      calc.Spec.X,
+		Y:      calc.Spec.Y,
+		Name:   calc.Name,
+	}); err != nil {
+		return "", nil, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	text := rendered.String()
+
+	switch target.Type {
+	case calcv1alpha1.TargetTypeInteger:
+		value, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%q is not a 64-bit integer", text)
+		}
+
+		return text, value, nil
+	case calcv1alpha1.TargetTypeJSON:
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+
+		var value interface{}
+		if err = decoder.Decode(&value); err != nil {
+			return "", nil, fmt.Errorf("%q is not JSON: %w", text, err)
+		}
+
+		return text, value, nil
+	default:
+		return text, text, nil
+	}
+}
+
+// Object returns the apply configuration setting the target field of the object in the namespace.
+func Object(target calcv1alpha1.Target, namespace string, value interface{}) (*unstructured.Unstructured, error) {
+	fields, err := ParsePath(target.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(target.APIVersion)
+	obj.SetKind(target.Kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(target.Name)
+
+	if err = unstructured.SetNestedField(obj.Object, value, fields...); err != nil {
+		return nil, fmt.Errorf("failed to set %s: %w", target.Path, err)
+	}
+
+	return obj, nil
+}
+
+// GroupKind returns the kind of the target object.
+func GroupKind(target calcv1alpha1.Target) (schema.GroupKind, error) {
+	groupVersion, err := schema.ParseGroupVersion(target.APIVersion)
+	if err != nil {
+		return schema.GroupKind{}, fmt.Errorf("invalid apiVersion: %w", err)
+	}
+
+	return groupVersion.WithKind(target.Kind).GroupKind(), nil
+}
+
+// Validate validates the targets of the Calculator against the allowlist.
+func Validate(calc *calcv1alpha1.Calculator, allowlist Allowlist) field.ErrorList {
+	var errs field.ErrorList
+
+	if encrypted(calc) && len(calc.Spec.Targets) > 0 {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "targets"), errEncrypted.Error()))
+	}
+
+	for i, target := range calc.Spec.Targets {
+		path := field.NewPath("spec", "targets").Index(i)
+
+		groupKind, err := GroupKind(target)
+		if err != nil {
+			errs = append(errs, field.Invalid(path.Child("apiVersion"), target.APIVersion, err.Error()))
+		} else if !allowlist.Allows(groupKind) {
+			errs = append(errs, field.Forbidden(path.Child("kind"),
+				fmt.Sprintf("%s is not allowed by the operator configuration", groupKind)))
+		}
+
+		if _, err = ParsePath(target.Path); err != nil {
+			errs = append(errs, field.Invalid(path.Child("path"), target.Path, err.Error()))
+		}
+
+		if _, err = parseTemplate(target); err != nil {
+			errs = append(errs, field.Invalid(path.Child("template"), target.Template, err.Error()))
+		}
+
+		for j, other := range calc.Spec.Targets[:i] {
+			if other.APIVersion == target.APIVersion && other.Kind == target.Kind && other.Name == target.Name &&
+				other.Path == target.Path {
+				errs = append(errs, field.Duplicate(path, fmt.Sprintf("same field as spec.targets[%d]", j)))
+			}
+		}
+	}
+
+	return errs
+}
+
+func parseTemplate(target calcv1alpha1.Target) (*template.Template, error) {
+	text := target.Template
+	if text == "" {
+		text = DefaultTemplate
+	}
+
+	tmpl, err := template.New("target").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// encrypted reports whether the Calculator encrypts its result.
+func encrypted(calc *calcv1alpha1.Calculator) bool {
+	return calc.Spec.Output != nil && calc.Spec.Output.Encryption != nil
+}
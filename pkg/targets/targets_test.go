@@ -0,0 +1,240 @@
+package targets_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/targets"
+)
+
+func TestParsePath(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Dotted fields", path: ".spec.replicas", want: []string{"spec", "replicas"}},
+		{name: "Braces and root", path: "{$.spec.replicas}", want: []string{"spec", "replicas"}},
+		{name: "Quoted key", path: ".data['result.txt']", want: []string{"data", "result.txt"}},
+		{name: "Double quoted key", path: `.data["a'b"]`, want: []string{"data", "a'b"}},
+		{
+			name: "Annotation",
+			path: ".metadata.annotations['calc.example.com/result']",
+			want: []string{"metadata", "annotations", "calc.example.com/result"},
+		},
+		{name: "Empty", path: "", wantErr: true},
+		{name: "Index", path: ".spec.containers[0].image", wantErr: true},
+		{name: "Wildcard", path: ".spec.*", wantErr: true},
+		{name: "Recursive descent", path: "..replicas", wantErr: true},
+		{name: "Unterminated bracket", path: ".data['key", wantErr: true},
+		{name: "Missing dot", path: "spec.replicas", wantErr: true},
+		{name: "Status", path: ".status.replicas", wantErr: true},
+		{name: "Kind", path: ".kind", wantErr: true},
+		{name: "Metadata name", path: ".metadata.name", wantErr: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := targets.ParsePath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	calc := &calcv1alpha1.Calculator{
+		ObjectMeta: metav1.ObjectMeta{Name: "replicas"},
+		Spec:       calcv1alpha1.CalculatorSpec{X: 3, Y: 4},
+		Status:     calcv1alpha1.CalculatorStatus{Processed: true, Result: 7, Value: "7"},
+	}
+
+	// Test table
+	tests := []struct {
+		name      string
+		target    calcv1alpha1.Target
+		encrypted bool
+		want      interface{}
+		wantErr   bool
+	}{
+		{name: "Default template", target: calcv1alpha1.Target{}, want: "7"},
+		{
+			name:   "Template",
+			target: calcv1alpha1.Target{Template: "{{ .Name }}={{ .X }}+{{ .Y }}"},
+			want:   "replicas=3+4",
+		},
+		{name: "Integer", target: calcv1alpha1.Target{Type: calcv1alpha1.TargetTypeInteger}, want: int64(7)},
+		{
+			name:    "Not an integer",
+			target:  calcv1alpha1.Target{Type: calcv1alpha1.TargetTypeInteger, Template: "{{ .Value }}m"},
+			wantErr: true,
+		},
+		{
+			name: "JSON",
+			target: calcv1alpha1.Target{
+				Type:     calcv1alpha1.TargetTypeJSON,
+				Template: `{"cpu": "{{ .Value }}", "count": {{ .Result }}}`,
+			},
+			want: map[string]interface{}{"cpu": "7", "count": json.Number("7")},
+		},
+		{
+			name:    "Invalid JSON",
+			target:  calcv1alpha1.Target{Type: calcv1alpha1.TargetTypeJSON, Template: "{"},
+			wantErr: true,
+		},
+		{name: "Unknown field", target: calcv1alpha1.Target{Template: "{{ .Missing }}"}, wantErr: true},
+		{name: "Encrypted", target: calcv1alpha1.Target{}, encrypted: true, wantErr: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			subject := calc.DeepCopy()
+			if tt.encrypted {
+				subject.Spec.Output = &calcv1alpha1.OutputSpec{Encryption: &calcv1alpha1.EncryptionSpec{}}
+			}
+
+			_, got, err := targets.Render(tt.target, subject)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestObject(t *testing.T) {
+	t.Parallel()
+
+	obj, err := targets.Object(calcv1alpha1.Target{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "web",
+		Path:       ".spec.replicas",
+	}, "default", int64(7))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec":       map[string]interface{}{"replicas": int64(7)},
+	}, obj.Object)
+}
+
+func TestFieldManager(t *testing.T) {
+	t.Parallel()
+
+	calc := &calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: "replicas"}}
+	assert.Equal(t, "calculator-replicas", targets.FieldManager(calc))
+
+	long := &calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 200)}}
+	other := &calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 199) + "b"}}
+
+	assert.Len(t, targets.FieldManager(long), 128)
+	assert.NotEqual(t, targets.FieldManager(long), targets.FieldManager(other))
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	allowlist := targets.Allowlist{{Group: "apps", Kind: "Deployment"}, {Kind: "ConfigMap"}}
+
+	// Test table
+	tests := []struct {
+		name    string
+		targets []calcv1alpha1.Target
+		output  *calcv1alpha1.OutputSpec
+		want    []string
+	}{
+		{
+			name: "Allowed",
+			targets: []calcv1alpha1.Target{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Path: ".spec.replicas"},
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "settings", Path: ".data.replicas"},
+			},
+		},
+		{
+			name: "Not allowed",
+			targets: []calcv1alpha1.Target{
+				{APIVersion: "v1", Kind: "Secret", Name: "settings", Path: ".data.replicas"},
+			},
+			want: []string{"spec.targets[0].kind"},
+		},
+		{
+			name: "Invalid path and template",
+			targets: []calcv1alpha1.Target{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "settings", Path: ".status", Template: "{{"},
+			},
+			want: []string{"spec.targets[0].path", "spec.targets[0].template"},
+		},
+		{
+			name: "Duplicate",
+			targets: []calcv1alpha1.Target{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "settings", Path: ".data.replicas"},
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "settings", Path: ".data.replicas"},
+			},
+			want: []string{"spec.targets[1]"},
+		},
+		{
+			name: "Encrypted",
+			targets: []calcv1alpha1.Target{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "settings", Path: ".data.replicas"},
+			},
+			output: &calcv1alpha1.OutputSpec{Encryption: &calcv1alpha1.EncryptionSpec{}},
+			want:   []string{"spec.targets"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{
+				Targets: tt.targets,
+				Output:  tt.output,
+			}}
+
+			var got []string
+			for _, err := range targets.Validate(calc, allowlist) {
+				got = append(got, err.Field)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package wasm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Extension is the file extension of the modules.
+const Extension = ".wasm"
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+
+// ReadDir reads the modules of a local directory, keyed by file name without the extension.
+func ReadDir(path string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+
+	modules := make(map[string][]byte)
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != Extension {
+			continue
+		}
+
+		binary, err := os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plugin: %w", err)
+		}
+
+		modules[strings.TrimSuffix(entry.Name(), Extension)] = binary
+	}
+
+	return modules, nil
+}
+
+// FromConfigMap returns the modules of the binary data of a ConfigMap, keyed by key without the
+// extension.
+func FromConfigMap(configMap *corev1.ConfigMap) map[string][]byte {
+	modules := make(map[string][]byte)
+
+	for key, binary := range configMap.BinaryData {
+		if filepath.Ext(key) == Extension {
+			modules[strings.TrimSuffix(key, Extension)] = binary
+		}
+	}
+
+	return modules
+}
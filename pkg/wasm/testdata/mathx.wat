@@ -0,0 +1,37 @@
+;; Functions of the test plugin, assembled into mathx.wasm.
+(module
+  (memory 1)
+
+  ;; Greatest common divisor of two numbers by the Euclidean algorithm, replaces the builtin gcd.
+  (func $gcd (export "gcd") (param $a i64) (param $b i64) (result i64)
+    (local $t i64)
+    (block $done
+      (loop $next
+        (br_if $done (i64.eqz (local.get $b)))
+        (local.set $t (i64.rem_s (local.get $a) (local.get $b)))
+        (local.set $a (local.get $b))
+        (local.set $b (local.get $t))
+        (br $next)))
+    (local.get $a))
+
+  ;; Naive recursive Fibonacci, burns fuel.
+  (func $fib (export "fib") (param $n i64) (result i64)
+    (if (result i64) (i64.lt_s (local.get $n) (i64.const 2))
+      (then (local.get $n))
+      (else
+        (i64.add
+          (call $fib (i64.sub (local.get $n) (i64.const 1)))
+          (call $fib (i64.sub (local.get $n) (i64.const 2)))))))
+
+  ;; Quotient, traps on a division by zero.
+  (func $quot (export "quot") (param i64 i64) (result i64)
+    (i64.div_s (local.get 0) (local.get 1)))
+
+  ;; Never returns.
+  (func $spin (export "spin") (result i64)
+    (loop $forever (br $forever))
+    (i64.const 0))
+
+  ;; Grows the memory by the number of pages, returns the previous size or -1.
+  (func $grow (export "grow") (param i64) (result i64)
+    (i64.extend_i32_s (memory.grow (i32.wrap_i64 (local.get 0))))))
@@ -0,0 +1,251 @@
+// Package wasm evaluates calculations with functions of WebAssembly modules, run in a sandbox by a
+// pure Go runtime.
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mykysha/kubCalculator/pkg/service"
+)
+
+// PageSize is the size of a WebAssembly memory page.
+const PageSize = 64 << 10
+
+// DefaultMaxMemoryPages is the default memory limit of the plugin functions.
+const DefaultMaxMemoryPages = 256
+
+// LimitFuel is the limit reported when a plugin function runs out of fuel.
+const LimitFuel = "fuel"
+
+// Options configures the sandbox of the plugin functions.
+type Options struct {
+	// MaxMemoryPages is the maximum memory of a module instance, in pages. Defaults to DefaultMaxMemoryPages.
+	MaxMemoryPages uint32
+	// Fuel is the maximum number of WebAssembly function calls of a single plugin function call, bounding
+	// its recursions. Zero, the default, disables it. Instructions are not metered: the evaluation timeout
+	// is the only bound on loops.
+	Fuel int64
+}
+
+// Repository is a service.Repository whose expressions can call the functions exported by
+// WebAssembly modules, in addition to the operations of its service.
+//
+// A function is exposed under its export name when all its parameters and its single result are
+// i64. It replaces the builtin function of the same name, the operations of the infix operators
+// cannot be replaced and two modules cannot export the same function. Every call runs in a fresh
+// instance of its module, calls cannot share state.
+type Repository struct {
+	service.CalculatorService
+
+	runtime wazero.Runtime
+	fuel    int64
+	// modules are the names of the modules of the functions.
+	modules map[string]string
+}
+
+// NewRepository compiles the modules, keyed by name, and registers their functions in the registry
+// of the service. The registry defaults to the builtin operations.
+func NewRepository(ctx context.Context, calculatorService service.CalculatorService, opts Options,
+	modules map[string][]byte,
+) (*Repository, error) {
+	maxMemoryPages := opts.MaxMemoryPages
+	if maxMemoryPages == 0 {
+		maxMemoryPages = DefaultMaxMemoryPages
+	}
+
+	r := &Repository{
+		CalculatorService: calculatorService,
+		runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+			WithMemoryLimitPages(maxMemoryPages).
+			WithCloseOnContextDone(true)),
+		fuel:    opts.Fuel,
+		modules: make(map[string]string),
+	}
+
+	if r.Registry == nil {
+		r.Registry = service.NewDefaultRegistry()
+	}
+
+	// Modules are compiled with the fuel meter, it is found in the context of every call.
+	if r.fuel > 0 {
+		ctx = context.WithValue(ctx, experimental.FunctionListenerFactoryKey{},
+			experimental.FunctionListenerFactoryFunc(func(api.FunctionDefinition) experimental.FunctionListener {
+				return experimental.FunctionListenerFunc(burn)
+			}))
+	}
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := r.load(ctx, name, modules[name]); err != nil {
+			_ = r.Close(ctx)
+
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Close releases the compiled modules.
+func (r *Repository) Close(ctx context.Context) error {
+	if err := r.runtime.Close(ctx); err != nil {
+		return fmt.Errorf("failed to close the WebAssembly runtime: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) load(ctx context.Context, name string, binary []byte) error {
+	compiled, err := r.runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return fmt.Errorf("failed to compile module %s: %w", name, err)
+	}
+
+	logger := log.FromContext(ctx).WithName("wasm")
+	exports := compiled.ExportedFunctions()
+
+	functions := make([]string, 0, len(exports))
+	for function := range exports {
+		functions = append(functions, function)
+	}
+
+	sort.Strings(functions)
+
+	for _, function := range functions {
+		params, ok := int64Signature(exports[function])
+		if !ok {
+			continue
+		}
+
+		if module, exists := r.modules[function]; exists {
+			return fmt.Errorf("failed to register function %s of module %s: %w: it is exported by module %s",
+				function, name, service.ErrRegistration, module)
+		}
+
+		var replaced bool
+
+		replaced, err = r.Registry.Replace(service.Evaluator{
+			Name: function,
+			Doc:  fmt.Sprintf("WebAssembly function %s of the %s module.", function, name),
+			Signatures: []service.Signature{
+				{Params: params, Result: service.TypeInt},
+			},
+			Eval: r.eval(compiled, function),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register function %s of module %s: %w", function, name, err)
+		}
+
+		if replaced {
+			logger.Info("WebAssembly function replaces a registered operation", "function", function, "module", name)
+		}
+
+		r.modules[function] = name
+	}
+
+	return nil
+}
+
+// int64Signature returns the parameter types of a function taking and returning i64 values only.
+func int64Signature(def api.FunctionDefinition) ([]service.Type, bool) {
+	if len(def.ResultTypes()) != 1 || def.ResultTypes()[0] != api.ValueTypeI64 {
+		return nil, false
+	}
+
+	params := make([]service.Type, 0, len(def.ParamTypes()))
+
+	for _, param := range def.ParamTypes() {
+		if param != api.ValueTypeI64 {
+			return nil, false
+		}
+
+		params = append(params, service.TypeInt)
+	}
+
+	return params, true
+}
+
+func (r *Repository) eval(compiled wazero.CompiledModule, function string) service.EvalFunc {
+	return func(ctx context.Context, args []service.Value) (service.Value, error) {
+		params := make([]uint64, 0, len(args))
+
+		for _, arg := range args {
+			i, ok := arg.(service.IntValue)
+			if !ok || !i.Int.IsInt64() {
+				return nil, service.Invalidf("%s: argument %s does not fit into an i64", function, arg)
+			}
+
+			params = append(params, api.EncodeI64(i.Int.Int64()))
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		meter := &fuelMeter{remaining: r.fuel, cancel: cancel}
+		if r.fuel > 0 {
+			ctx = context.WithValue(ctx, fuelKey{}, meter)
+		}
+
+		// An anonymous instance, so that concurrent calls do not conflict.
+		instance, err := r.runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate %s: %w", function, err)
+		}
+
+		defer instance.Close(ctx)
+
+		results, err := instance.ExportedFunction(function).Call(ctx, params...)
+
+		switch {
+		case meter.exhausted:
+			return nil, &service.LimitExceededError{
+				Limit:   LimitFuel,
+				Message: fmt.Sprintf("%s made more than %d calls", function, r.fuel),
+			}
+		case err != nil && ctx.Err() != nil:
+			return nil, fmt.Errorf("%s interrupted: %w", function, ctx.Err())
+		case err != nil:
+			// A trap, such as a division by zero, fails again with the same arguments.
+			return nil, service.Invalidf("%s: %s", function, err)
+		}
+
+		return service.IntValue{Int: big.NewInt(int64(results[0]))}, nil
+	}
+}
+
+type fuelKey struct{}
+
+// fuelMeter counts the calls of a plugin function call and interrupts it when the fuel is exhausted.
+type fuelMeter struct {
+	remaining int64
+	exhausted bool
+	cancel    context.CancelFunc
+}
+
+// burn consumes a unit of fuel for every WebAssembly function called.
+func burn(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	meter, ok := ctx.Value(fuelKey{}).(*fuelMeter)
+	if !ok {
+		return
+	}
+
+	meter.remaining--
+	if meter.remaining < 0 && !meter.exhausted {
+		meter.exhausted = true
+		meter.cancel()
+	}
+}
@@ -0,0 +1,187 @@
+package wasm_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/wasm"
+)
+
+func newRepository(t *testing.T, opts wasm.Options) *wasm.Repository {
+	t.Helper()
+
+	modules, err := wasm.ReadDir("testdata")
+	require.NoError(t, err)
+
+	repository, err := wasm.NewRepository(context.Background(), service.CalculatorService{
+		Limits: service.Limits{Timeout: time.Second},
+	}, opts, modules)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, repository.Close(context.Background()))
+	})
+
+	return repository
+}
+
+func TestRepository(t *testing.T) { //nolint:funlen // Test function
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+		wantLimit  string
+		wantErr    func(err error) bool
+	}{
+		{
+			name:       "Plugin function",
+			expression: "gcd(x, y)",
+			want:       "6",
+		},
+		{
+			name:       "Mixed with builtins",
+			expression: "fib(10) + gcd(x, y) * 2",
+			want:       "67",
+		},
+		{
+			name:       "Builtin replaced",
+			expression: "gcd(x, y, 4)",
+			wantErr:    service.IsInvalid,
+		},
+		{
+			name:       "Trap",
+			expression: "quot(x, 0)",
+			wantErr:    service.IsInvalid,
+		},
+		{
+			name:       "Argument out of range",
+			expression: "fib(9223372036854775808)",
+			wantErr:    service.IsInvalid,
+		},
+		{
+			name:       "Wrong arity",
+			expression: "gcd(x)",
+			wantErr:    service.IsInvalid,
+		},
+		{
+			name:       "Out of fuel",
+			expression: "fib(30)",
+			wantLimit:  wasm.LimitFuel,
+		},
+		{
+			name:       "Infinite loop",
+			expression: "spin()",
+			wantLimit:  service.LimitTimeout,
+		},
+		{
+			name:       "Memory within limit",
+			expression: "grow(1)",
+			want:       "1",
+		},
+		{
+			name:       "Memory over limit",
+			expression: "grow(2)",
+			want:       "-1",
+		},
+	}
+
+	repository := newRepository(t, wasm.Options{MaxMemoryPages: 2, Fuel: 10000})
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calc := &calcv1alpha1.Calculator{
+				Spec: calcv1alpha1.CalculatorSpec{X: 48, Y: 18, Expression: tt.expression},
+			}
+
+			err := repository.ProcessCalculator(context.Background(), calc)
+
+			switch {
+			case tt.wantLimit != "":
+				var limitErr *service.LimitExceededError
+
+				assert.True(t, errors.As(err, &limitErr), "unexpected error %v", err)
+				assert.Equal(t, tt.wantLimit, limitErr.Limit)
+			case tt.wantErr != nil:
+				assert.True(t, tt.wantErr(err), "unexpected error %v", err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, calc.Status.Value)
+			}
+		})
+	}
+}
+
+func TestRepositoryWithoutFuel(t *testing.T) {
+	t.Parallel()
+
+	repository := newRepository(t, wasm.Options{})
+
+	// The calls are not counted by default.
+	calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{Expression: "fib(25)"}}
+
+	assert.NoError(t, repository.ProcessCalculator(context.Background(), calc))
+	assert.Equal(t, "75025", calc.Status.Value)
+}
+
+func TestRepositoryValidation(t *testing.T) {
+	t.Parallel()
+
+	repository := newRepository(t, wasm.Options{})
+
+	calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{Expression: "fib(x)"}}
+
+	assert.Empty(t, repository.ValidateCalculator(calc))
+	assert.NotEmpty(t, service.CalculatorService{}.ValidateCalculator(calc))
+
+	gcd, ok := repository.Registry.Lookup("gcd")
+	assert.True(t, ok)
+	assert.Equal(t, "WebAssembly function gcd of the mathx module.", gcd.Doc)
+}
+
+func TestNewRepositoryErrors(t *testing.T) {
+	t.Parallel()
+
+	modules, err := wasm.ReadDir("testdata")
+	require.NoError(t, err)
+
+	_, err = wasm.NewRepository(context.Background(), service.CalculatorService{}, wasm.Options{},
+		map[string][]byte{"broken": []byte("not a module")})
+	assert.Error(t, err)
+
+	// Two modules export the same functions.
+	_, err = wasm.NewRepository(context.Background(), service.CalculatorService{}, wasm.Options{},
+		map[string][]byte{"mathx": modules["mathx"], "mathy": modules["mathx"]})
+	assert.ErrorIs(t, err, service.ErrRegistration)
+
+	// A module exports the operation of the + operator.
+	_, err = wasm.NewRepository(context.Background(), service.CalculatorService{}, wasm.Options{},
+		map[string][]byte{"mathx": bytes.Replace(modules["mathx"], []byte("\x03gcd"), []byte("\x03add"), 1)})
+	assert.ErrorIs(t, err, service.ErrRegistration)
+}
+
+func TestFromConfigMap(t *testing.T) {
+	t.Parallel()
+
+	modules := wasm.FromConfigMap(&corev1.ConfigMap{
+		Data:       map[string]string{"README": "plugins"},
+		BinaryData: map[string][]byte{"mathx.wasm": {0}, "notes.txt": {1}},
+	})
+
+	assert.Equal(t, map[string][]byte{"mathx": {0}}, modules)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook contains the admission webhooks of the operator.
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/quota"
+	"github.com/mykysha/kubCalculator/pkg/targets"
+)
+
+// Validator validates a calculator spec.
+type Validator interface {
+	// ValidateCalculator validates the calculator spec without evaluating it.
+	ValidateCalculator(calc *calcv1alpha1.Calculator) field.ErrorList
+}
+
+//+kubebuilder:webhook:path=/validate-calc-example-com-v1alpha1-calculator,mutating=false,failurePolicy=fail,sideEffects=None,groups=calc.example.com,resources=calculators,verbs=create;update,versions=v1alpha1,name=vcalculator.kb.io,admissionReviewVersions=v1
+
+// CalculatorValidator validates Calculators on admission.
+type CalculatorValidator struct {
+	Validator Validator
+	// Evaluators validate the Calculators selecting them with spec.evaluatorRef.
+	Evaluators map[string]Validator
+	// AllowedTargets are the kinds the Calculators may apply their results to with spec.targets.
+	AllowedTargets targets.Allowlist
+	// Reader lists the CalculatorQuotas and the Calculators of the namespaces, the quotas are not enforced when
+	// nil.
+	Reader client.Reader
+	// Namespaces are the namespaces watched by the operator, all of them when empty. The Calculators of the other
+	// namespaces are admitted without validation, they are validated by the operators watching them.
+	Namespaces []string
+}
+
+// SetupWebhookWithManager registers the webhook with the Manager.
+func (v *CalculatorValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	err := ctrl.NewWebhookManagedBy(mgr).
+		For(&calcv1alpha1.Calculator{}).
+		WithValidator(v).
+		Complete()
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateCreate validates a created Calculator.
+func (v *CalculatorValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return v.validate(ctx, obj, nil)
+}
+
+// ValidateUpdate validates an updated Calculator.
+func (v *CalculatorValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	old, ok := oldObj.(*calcv1alpha1.Calculator)
+	if !ok {
+		return fmt.Errorf("expected a Calculator, got %T", oldObj)
+	}
+
+	return v.validate(ctx, newObj, old)
+}
+
+// ValidateDelete accepts every deletion.
+func (v *CalculatorValidator) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+func (v *CalculatorValidator) validate(ctx context.Context, obj runtime.Object, old *calcv1alpha1.Calculator) error {
+	calc, ok := obj.(*calcv1alpha1.Calculator)
+	if !ok {
+		return fmt.Errorf("expected a Calculator, got %T", obj)
+	}
+
+	if !v.watches(calc.Namespace) {
+		return nil
+	}
+
+	validator := v.Validator
+
+	if ref := calc.Spec.EvaluatorRef; ref != nil {
+		if validator, ok = v.Evaluators[ref.Name]; !ok {
+			return apierrors.NewInvalid(calcv1alpha1.GroupVersion.WithKind("Calculator").GroupKind(), calc.Name,
+				field.ErrorList{field.NotFound(field.NewPath("spec", "evaluatorRef", "name"), ref.Name)})
+		}
+	}
+
+	errs := validator.ValidateCalculator(calc)
+	errs = append(errs, targets.Validate(calc, v.AllowedTargets)...)
+
+	if output := calc.Spec.Output; output != nil && output.RolloutSelector != nil {
+		errs = append(errs, metav1validation.ValidateLabelSelector(output.RolloutSelector,
+			field.NewPath("spec", "output", "rolloutSelector"))...)
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(calcv1alpha1.GroupVersion.WithKind("Calculator").GroupKind(), calc.Name, errs)
+	}
+
+	return v.admitQuota(ctx, calc, old)
+}
+
+// watches reports whether the namespace is watched by the operator, the cache of the Reader only holds the
+// objects of the watched namespaces.
+func (v *CalculatorValidator) watches(namespace string) bool {
+	if len(v.Namespaces) == 0 {
+		return true
+	}
+
+	for _, watched := range v.Namespaces {
+		if watched == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+//+kubebuilder:rbac:groups=calc.example.com,resources=calculatorquotas,verbs=get;list;watch
+
+// admitQuota rejects the Calculators exceeding a CalculatorQuota of their namespace.
+func (v *CalculatorValidator) admitQuota(ctx context.Context, calc, old *calcv1alpha1.Calculator) error {
+	if v.Reader == nil {
+		return nil
+	}
+
+	quotas := &calcv1alpha1.CalculatorQuotaList{}
+	if err := v.Reader.List(ctx, quotas, client.InNamespace(calc.Namespace)); err != nil {
+		return fmt.Errorf("failed to list quotas: %w", err)
+	}
+
+	if len(quotas.Items) == 0 {
+		return nil
+	}
+
+	calculators := &calcv1alpha1.CalculatorList{}
+	if err := v.Reader.List(ctx, calculators, client.InNamespace(calc.Namespace)); err != nil {
+		return fmt.Errorf("failed to list calculators: %w", err)
+	}
+
+	others := make([]calcv1alpha1.Calculator, 0, len(calculators.Items))
+
+	for _, other := range calculators.Items {
+		if other.Name != calc.Name {
+			others = append(others, other)
+		}
+	}
+
+	if err := quota.Admit(quotas.Items, others, calc, old); err != nil {
+		return apierrors.NewForbidden(calcv1alpha1.GroupVersion.WithResource("calculators").GroupResource(),
+			calc.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	calcv1alpha1 "github.com/mykysha/kubCalculator/api/v1alpha1"
+	"github.com/mykysha/kubCalculator/pkg/service"
+	"github.com/mykysha/kubCalculator/pkg/targets"
+	"github.com/mykysha/kubCalculator/pkg/webhook"
+)
+
+func TestCalculatorValidator(t *testing.T) {
+	t.Parallel()
+
+	// Test table
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    bool
+	}{
+		{name: "Default expression"},
+		{name: "Valid expression", expression: "x * y - 1"},
+		{name: "Syntax error", expression: "x *", wantErr: true},
+		{name: "Unknown operation", expression: "max(x, y)", wantErr: true},
+	}
+
+	validator := &webhook.CalculatorValidator{Validator: service.CalculatorService{}}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{X: 1, Y: 2, Expression: tt.expression}}
+
+			createErr := validator.ValidateCreate(context.Background(), calc)
+			updateErr := validator.ValidateUpdate(context.Background(), calc, calc)
+
+			if tt.wantErr {
+				assert.True(t, apierrors.IsInvalid(createErr))
+				assert.True(t, apierrors.IsInvalid(updateErr))
+			} else {
+				assert.NoError(t, createErr)
+				assert.NoError(t, updateErr)
+			}
+
+			assert.NoError(t, validator.ValidateDelete(context.Background(), calc))
+		})
+	}
+}
+
+func TestCalculatorValidatorWrongObject(t *testing.T) {
+	t.Parallel()
+
+	validator := &webhook.CalculatorValidator{Validator: service.CalculatorService{}}
+
+	assert.Error(t, validator.ValidateCreate(context.Background(), &corev1.Secret{}))
+}
+
+func TestCalculatorValidatorEvaluatorRef(t *testing.T) {
+	t.Parallel()
+
+	validator := &webhook.CalculatorValidator{
+		Validator: service.CalculatorService{},
+		Evaluators: map[string]webhook.Validator{
+			"remote": acceptAll{},
+		},
+	}
+
+	// The remote evaluator owns the syntax of its expressions.
+	calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{
+		Expression:   "max(x, y)",
+		EvaluatorRef: &calcv1alpha1.EvaluatorReference{Name: "remote"},
+	}}
+	assert.NoError(t, validator.ValidateCreate(context.Background(), calc))
+
+	calc.Spec.EvaluatorRef.Name = "unknown"
+	assert.True(t, apierrors.IsInvalid(validator.ValidateCreate(context.Background(), calc)))
+}
+
+type acceptAll struct{}
+
+func (acceptAll) ValidateCalculator(_ *calcv1alpha1.Calculator) field.ErrorList {
+	return nil
+}
+
+func TestCalculatorValidatorTargets(t *testing.T) {
+	t.Parallel()
+
+	validator := &webhook.CalculatorValidator{
+		Validator:      service.CalculatorService{},
+		AllowedTargets: targets.Allowlist{{Group: "apps", Kind: "Deployment"}},
+	}
+
+	calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{
+		Targets: []calcv1alpha1.Target{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Path: ".spec.replicas"}},
+	}}
+	assert.NoError(t, validator.ValidateCreate(context.Background(), calc))
+
+	calc.Spec.Targets[0].Kind = "StatefulSet"
+	assert.True(t, apierrors.IsInvalid(validator.ValidateCreate(context.Background(), calc)))
+}
+
+func TestCalculatorValidatorRolloutSelector(t *testing.T) {
+	t.Parallel()
+
+	validator := &webhook.CalculatorValidator{Validator: service.CalculatorService{}}
+
+	calc := &calcv1alpha1.Calculator{Spec: calcv1alpha1.CalculatorSpec{
+		Output: &calcv1alpha1.OutputSpec{RolloutSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "web"},
+		}},
+	}}
+	assert.NoError(t, validator.ValidateCreate(context.Background(), calc))
+
+	calc.Spec.Output.RolloutSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
+		{Key: "tier", Operator: metav1.LabelSelectorOpIn},
+	}
+	assert.True(t, apierrors.IsInvalid(validator.ValidateCreate(context.Background(), calc)))
+}
+
+func TestCalculatorValidatorQuota(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, calcv1alpha1.AddToScheme(scheme))
+
+	calculators := int64(1)
+
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&calcv1alpha1.CalculatorQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "count", Namespace: "default"},
+			Spec:       calcv1alpha1.CalculatorQuotaSpec{Hard: calcv1alpha1.CalculatorQuotaLimits{Calculators: &calculators}},
+		},
+		&calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: "sum", Namespace: "default"}},
+	).Build()
+
+	validator := &webhook.CalculatorValidator{Validator: service.CalculatorService{}, Reader: reader}
+
+	existing := &calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: "sum", Namespace: "default"}}
+	assert.NoError(t, validator.ValidateUpdate(context.Background(), existing, existing))
+
+	created := &calcv1alpha1.Calculator{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}}
+	err := validator.ValidateCreate(context.Background(), created)
+	assert.True(t, apierrors.IsForbidden(err))
+	assert.ErrorContains(t, err, "exceeded quota count: calculators 2 > 1")
+
+	// The quotas of other namespaces do not apply.
+	created.Namespace = "other"
+	assert.NoError(t, validator.ValidateCreate(context.Background(), created))
+}
+
+func TestCalculatorValidatorNamespaces(t *testing.T) {
+	t.Parallel()
+
+	validator := &webhook.CalculatorValidator{
+		Validator: service.CalculatorService{},
+		// The cache of a multi-namespace manager fails to list the other namespaces.
+		Reader:     failingReader{},
+		Namespaces: []string{"team-a", "team-b"},
+	}
+
+	calc := &calcv1alpha1.Calculator{
+		ObjectMeta: metav1.ObjectMeta{Name: "sum", Namespace: "team-c"},
+		Spec:       calcv1alpha1.CalculatorSpec{Expression: "x +"},
+	}
+
+	// The Calculators of the other namespaces are left to the operators watching them.
+	assert.NoError(t, validator.ValidateCreate(context.Background(), calc))
+
+	calc.Namespace = "team-b"
+	assert.True(t, apierrors.IsInvalid(validator.ValidateCreate(context.Background(), calc)))
+
+	calc.Spec.Expression = ""
+	assert.ErrorContains(t, validator.ValidateCreate(context.Background(), calc), "unknown namespace")
+}
+
+// failingReader fails every read, as the cache of the namespaces it does not hold.
+type failingReader struct {
+	client.Reader
+}
+
+func (failingReader) List(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+	return errors.New("unable to get: unknown namespace for the cache")
+}